@@ -1,7 +1,7 @@
 package client
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,12 +9,23 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type APICardImage struct {
 	ImageURL string `json:"image_url"`
 }
 
+// APICardSet is a printing of a card in a set.
+type APICardSet struct {
+	SetName       string `json:"set_name"`
+	SetCode       string `json:"set_code"`
+	SetRarity     string `json:"set_rarity"`
+	SetRarityCode string `json:"set_rarity_code"`
+}
+
 type APICard struct {
 	ID                int            `json:"id"`
 	Name              string         `json:"name"`
@@ -29,6 +40,7 @@ type APICard struct {
 	Attribute         string         `json:"attribute"`
 	Archetype         string         `json:"archetype"`
 	CardImages        []APICardImage `json:"card_images"`
+	CardSets          []APICardSet   `json:"card_sets"`
 	LinkValue         int            `json:"linkval"`
 	LinkMarkers       []string       `json:"linkmarkers"`
 	Scale             int            `json:"scale"`
@@ -39,36 +51,100 @@ type CardResponse struct {
 	Data []APICard `json:"data"`
 }
 
-const MaxFetch = 10
+// DefaultBaseURL is the YGOProDeck API the client talks to unless configured otherwise.
+const DefaultBaseURL = "https://db.ygoprodeck.com/api/v7"
+
+// CardInfoURL returns the full card database when called without parameters.
+const CardInfoURL = DefaultBaseURL + "/cardinfo.php"
+
+// YGOProDeck allows 20 requests per second; clients going over it are temporarily blocked.
+const DefaultRequestsPerSecond = 20
 
 var ErrBadRequestFromAPI = errors.New("external API returned 400 Bad Request")
+var ErrCardNotFound = errors.New("card not found or no card image found")
 
-func FetchCardByIDOrName(id int, name string) (*APICard, error) {
-	var endpoint string
-	if id > 0 {
-		endpoint = fmt.Sprintf("https://db.ygoprodeck.com/api/v7/cardinfo.php?id=%d", id)
-	} else {
-		endpoint = fmt.Sprintf("https://db.ygoprodeck.com/api/v7/cardinfo.php?name=%s", url.QueryEscape(name))
-	}
+// YGOClient defines the calls made to the YGOProDeck API.
+type YGOClient interface {
+	FetchCardByIDOrName(ctx context.Context, id int, name string) (*APICard, error)
+	FetchCardsByName(ctx context.Context, name string) ([]APICard, error)
+	FetchCardCatalog(ctx context.Context, url string) ([]APICard, error)
+}
+
+// Config configures a YGOClient. Zero values fall back to the defaults; a negative MaxRetries disables retries.
+type Config struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// CatalogTimeout replaces the timeout of HTTPClient for the download of the full card database.
+	CatalogTimeout    time.Duration
+	MaxRetries        int
+	Backoff           time.Duration
+	RequestsPerSecond int
+}
 
-	resp, err := http.Get(endpoint)
-	if err != nil || resp.StatusCode != 200 {
-		return nil, fmt.Errorf("external API error")
+type ygoClient struct {
+	baseURL       string
+	httpClient    *http.Client
+	catalogClient *http.Client
+	maxRetries    int
+	backoff       time.Duration
+	limiter       *rateLimiter
+}
+
+// NewYGOClient creates a client for the YGOProDeck API. Requests are rate limited, and retried with an
+// exponential backoff on network errors, 5xx responses and 429 responses, honouring Retry-After.
+func NewYGOClient(cfg Config) YGOClient {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
 	}
-	defer resp.Body.Close()
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.CatalogTimeout == 0 {
+		cfg.CatalogTimeout = 5 * time.Minute
+	}
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = 3
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+	if cfg.RequestsPerSecond == 0 {
+		cfg.RequestsPerSecond = DefaultRequestsPerSecond
+	}
+
+	// The catalog download shares the transport of the other requests.
+	catalogClient := *cfg.HTTPClient
+	catalogClient.Timeout = cfg.CatalogTimeout
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	fmt.Println("Raw Body:\n", string(bodyBytes))
+	return &ygoClient{
+		baseURL:       strings.TrimSuffix(cfg.BaseURL, "/"),
+		httpClient:    cfg.HTTPClient,
+		catalogClient: &catalogClient,
+		maxRetries:    cfg.MaxRetries,
+		backoff:       cfg.Backoff,
+		limiter:       newRateLimiter(cfg.RequestsPerSecond),
+	}
+}
 
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+// FetchCardByIDOrName returns the card with the given passcode, or with the exact given name when id is 0.
+func (c *ygoClient) FetchCardByIDOrName(ctx context.Context, id int, name string) (*APICard, error) {
+	query := url.Values{}
+	if id > 0 {
+		query.Set("id", strconv.Itoa(id))
+	} else {
+		query.Set("name", name)
+	}
 
 	var result CardResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, c.baseURL+"/cardinfo.php?"+query.Encode(), &result); err != nil {
+		return nil, err
 	}
 
 	if len(result.Data) == 0 || len(result.Data[0].CardImages) == 0 {
-		return nil, errors.New("card not found or no card image found")
+		return nil, ErrCardNotFound
 	}
 
 	card := result.Data[0]
@@ -76,72 +152,153 @@ func FetchCardByIDOrName(id int, name string) (*APICard, error) {
 	return &card, nil
 }
 
-// FetchRandomCards retrieves a specified number of random Yu-Gi-Oh! cards from the YGOProDeck API.
-// It ensures that no more than MaxFetch cards are requested in a single operation.
-func FetchRandomCards(n int) ([]APICard, error) {
-	if n > MaxFetch {
-		n = MaxFetch
+// FetchCardsByName queries the YGOProDeck API for cards that match the given name (partial match).
+// It uses the 'fname' query parameter to perform fuzzy name search.
+func (c *ygoClient) FetchCardsByName(ctx context.Context, name string) ([]APICard, error) {
+	// The API requires both num and offset when paginating.
+	query := url.Values{}
+	query.Set("fname", name)
+	query.Set("num", "50")
+	query.Set("offset", "0")
+
+	var result CardResponse
+	if err := c.getJSON(ctx, c.baseURL+"/cardinfo.php?"+query.Encode(), &result); err != nil {
+		return nil, err
 	}
+	return result.Data, nil
+}
 
-	url := "https://db.ygoprodeck.com/api/v7/randomcard.php"
-	var cards []APICard
+// FetchCardCatalog downloads the full card database from a cardinfo.php URL, or from the
+// cardinfo.php endpoint of the base URL when url is empty.
+func (c *ygoClient) FetchCardCatalog(ctx context.Context, url string) ([]APICard, error) {
+	if url == "" {
+		url = c.baseURL + "/cardinfo.php"
+	}
 
-	for i := 0; i < n; i++ {
-		resp, err := http.Get(url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch card %d: %w", i, err)
+	resp, err := c.get(ctx, c.catalogClient, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch card catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return DecodeCardCatalog(resp.Body)
+}
+
+// DecodeCardCatalog reads a cardinfo.php response, such as a saved dump of the full card database.
+func DecodeCardCatalog(r io.Reader) ([]APICard, error) {
+	var result CardResponse
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode card catalog: %w", err)
+	}
+
+	for i := range result.Data {
+		if len(result.Data[i].CardImages) > 0 {
+			result.Data[i].ImageURL = result.Data[i].CardImages[0].ImageURL
 		}
-		defer resp.Body.Close()
+	}
+	return result.Data, nil
+}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+// getJSON performs a GET request and decodes the JSON response into out.
+func (c *ygoClient) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	resp, err := c.get(ctx, c.httpClient, endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// get performs a rate-limited GET request with the given HTTP client, retrying transient failures. The caller
+// must close the body of the returned response, which always has a 200 status.
+func (c *ygoClient) get(ctx context.Context, httpClient *http.Client, endpoint string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.retryDelay(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
 		}
 
-		var apiResp CardResponse
-		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-			return nil, fmt.Errorf("failed to decode card %d: %w", i, err)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
 		}
 
-		if len(apiResp.Data) == 0 || apiResp.Data[0].ID == 0 {
-			fmt.Println("Invalid card received")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
 			continue
 		}
 
-		cards = append(cards, apiResp.Data[0])
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		// Drain the body so that the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusBadRequest:
+			return nil, ErrBadRequestFromAPI
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = &statusError{code: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+			log.Printf("YGOProDeck API returned %d for %s (attempt %d)", resp.StatusCode, endpoint, attempt+1)
+		default:
+			return nil, &statusError{code: resp.StatusCode}
+		}
 	}
 
-	return cards, nil
+	return nil, fmt.Errorf("external API error after %d attempts: %w", c.maxRetries+1, lastErr)
 }
 
-// FetchCardsByName queries the YGOProDeck API for cards that match the given name (partial match).
-// It uses the 'fname' query parameter to perform fuzzy name search.
-func FetchCardsByName(name string) ([]APICard, error) {
-	// Usa ambos parámetros como exige la API: num y offset
-	url := fmt.Sprintf("https://db.ygoprodeck.com/api/v7/cardinfo.php?fname=%s&num=50&offset=0", url.QueryEscape(name))
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
+// retryDelay doubles the backoff on every attempt, unless the API asked to wait for a given time.
+func (c *ygoClient) retryDelay(attempt int, lastErr error) time.Duration {
+	var statusErr *statusError
+	if errors.As(lastErr, &statusErr) && statusErr.retryAfter > 0 {
+		return statusErr.retryAfter
 	}
-	defer resp.Body.Close()
+	return c.backoff << (attempt - 1)
+}
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
+// statusError is an unexpected HTTP status returned by the API.
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
 
-	if resp.StatusCode == http.StatusBadRequest {
-		log.Printf("API returned 400: %s\n", string(bodyBytes))
-		return nil, ErrBadRequestFromAPI
-	}
+func (e *statusError) Error() string {
+	return fmt.Sprintf("external API error %d", e.code)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("API returned %d: %s\n", resp.StatusCode, string(bodyBytes))
-		return nil, fmt.Errorf("external API error %d: %s", resp.StatusCode, string(bodyBytes))
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
 	}
+	return time.Duration(seconds) * time.Second
+}
 
-	var result struct {
-		Data []APICard `json:"data"`
-	}
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
-		return nil, err
-	}
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	return result.Data, nil
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ashResponse = `{"data": [{"id": 14558127, "name": "Ash Blossom & Joyous Spring", "card_images": [{"image_url": "https://example.com/14558127.jpg"}]}]}`
+
+func newTestClient(url string) YGOClient {
+	return NewYGOClient(Config{BaseURL: url, Backoff: time.Millisecond, RequestsPerSecond: 1000})
+}
+
+func TestYGOClient_RetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			assert.Equal(t, "14558127", r.URL.Query().Get("id"))
+			_, _ = w.Write([]byte(ashResponse))
+		}
+	}))
+	defer server.Close()
+
+	card, err := newTestClient(server.URL).FetchCardByIDOrName(context.Background(), 14558127, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Ash Blossom & Joyous Spring", card.Name)
+	assert.Equal(t, "https://example.com/14558127.jpg", card.ImageURL)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestYGOClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).FetchCardsByName(context.Background(), "ash")
+	require.Error(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestYGOClient_RetriesCanBeDisabled(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := NewYGOClient(Config{BaseURL: server.URL, MaxRetries: -1}).FetchCardsByName(context.Background(), "ash")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestYGOClient_CatalogHasItsOwnTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(ashResponse))
+	}))
+	defer server.Close()
+
+	c := NewYGOClient(Config{
+		BaseURL:        server.URL,
+		HTTPClient:     &http.Client{Timeout: 20 * time.Millisecond},
+		CatalogTimeout: time.Second,
+		MaxRetries:     -1,
+	})
+
+	_, err := c.FetchCardsByName(context.Background(), "ash")
+	require.Error(t, err)
+
+	cards, err := c.FetchCardCatalog(context.Background(), "")
+	require.NoError(t, err)
+	assert.Len(t, cards, 1)
+}
+
+func TestYGOClient_DoesNotRetryBadRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).FetchCardsByName(context.Background(), "nothing")
+	assert.True(t, errors.Is(err, ErrBadRequestFromAPI))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestYGOClient_HonoursContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewYGOClient(Config{BaseURL: server.URL}).FetchCardByIDOrName(ctx, 1, "")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	limiter := newRateLimiter(100)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
@@ -0,0 +1,52 @@
+// Command catalogsync syncs the card catalog with a full YGOProDeck cardinfo.php dump.
+//
+// Usage:
+//
+//	go run ./cmd/catalogsync [-file cardinfo.json] [-url https://...] [-prune] [-dry-run]
+//
+// Without -file the dump is downloaded from -url, which defaults to CARD_CATALOG_URL or the public API.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+)
+
+func main() {
+	file := flag.String("file", "", "path to a saved cardinfo.php dump")
+	url := flag.String("url", services.CatalogURL(), "cardinfo.php URL to download the dump from")
+	prune := flag.Bool("prune", false, "delete the cards missing from the dump")
+	dryRun := flag.Bool("dry-run", false, "report the changes without saving them")
+	flag.Parse()
+
+	database.DBConnect()
+	if err := database.AutoMigrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ygoClient := client.NewYGOClient(client.Config{BaseURL: os.Getenv("YGOPRODECK_API_URL")})
+	service := services.NewCatalogService(repository.NewCatalogRepository(), services.NewCardFactory(), ygoClient)
+
+	var (
+		report *services.CatalogSyncReport
+		err    error
+	)
+	if *file != "" {
+		report, err = service.SyncFromFile(*file, *prune, *dryRun)
+	} else {
+		report, err = service.SyncFromURL(context.Background(), *url, *prune, *dryRun)
+	}
+	if err != nil {
+		log.Fatalf("Card catalog sync failed: %v", err)
+	}
+
+	log.Printf("Synced %d cards: %d added, %d changed, %d removed, %d unchanged (dry run: %t, pruned: %t)",
+		report.Total, len(report.Added), len(report.Changed), len(report.Removed), report.Unchanged, report.DryRun, report.Pruned)
+}
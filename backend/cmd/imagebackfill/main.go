@@ -0,0 +1,46 @@
+// Command imagebackfill creates the missing thumbnails and cropped artwork of the stored cards.
+//
+// Usage:
+//
+//	go run ./cmd/imagebackfill [-limit 500]
+//
+// Images are stored in the backend configured by IMAGE_STORE, as for the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+)
+
+func main() {
+	limit := flag.Int("limit", 0, "maximum number of cards to process, 0 for all of them")
+	flag.Parse()
+
+	database.DBConnect()
+	if err := database.AutoMigrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ctx := context.Background()
+	imageStore, err := storage.NewImageStore(ctx, storage.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure image store: %v", err)
+	}
+
+	service := services.NewCardImageService(repository.NewCardRepository(), imageStore)
+	report, err := service.BackfillDerivatives(ctx, *limit)
+	if err != nil {
+		log.Fatalf("Card image backfill failed: %v", err)
+	}
+
+	for _, failure := range report.Failed {
+		log.Printf("Card %d (%s): %s", failure.CardYGOID, failure.Name, failure.Error)
+	}
+	log.Printf("Processed %d cards: %d updated, %d failed", report.Processed, report.Updated, len(report.Failed))
+}
@@ -60,15 +60,153 @@ func DBConnect() {
 }
 
 func AutoMigrate() error {
-	return DB.AutoMigrate(
+	if err := migrateUserCardPrintings(); err != nil {
+		return err
+	}
+
+	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Card{},
 		&models.SpellTrapCard{},
 		&models.MonsterCard{},
 		&models.LinkMonsterCard{},
 		&models.PendulumMonsterCard{},
+		&models.CardSet{},
+		&models.CardPrinting{},
 		&models.UserCard{},
+		&models.StorageLocation{},
+		&models.StoredCard{},
+		&models.WishlistEntry{},
+		&models.Format{},
 		&models.Deck{},
 		&models.DeckCard{},
+		&models.Banlist{},
+		&models.BanlistEntry{},
+		&models.ImageJob{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateDeckCardPrimaryKey(); err != nil {
+		return err
+	}
+	if err := migrateUserCardCopyKey(); err != nil {
+		return err
+	}
+
+	if err := migrateCardSearchVector(); err != nil {
+		return err
+	}
+
+	return migrateCardNameTrigrams()
+}
+
+// migrateDeckCardPrimaryKey adds the zone to the primary key of deck_cards on databases
+// created before side deck support, so the same card can be stored once per zone.
+// AutoMigrate does not alter existing primary keys, and this is a no-op once applied.
+func migrateDeckCardPrimaryKey() error {
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	var zoneInKey int64
+	err := DB.Raw(`SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_name = 'deck_cards' AND constraint_name = 'deck_cards_pkey' AND column_name = 'zone'`).
+		Scan(&zoneInKey).Error
+	if err != nil || zoneInKey > 0 {
+		return err
+	}
+
+	return DB.Exec(`ALTER TABLE deck_cards DROP CONSTRAINT IF EXISTS deck_cards_pkey,
+		ADD PRIMARY KEY (deck_id, card_id, zone)`).Error
+}
+
+// migrateUserCardCopyKey adds the copy attributes to the primary key of user_cards on databases created
+// before they were tracked. AutoMigrate adds the columns, with every existing copy left unspecified.
+func migrateUserCardCopyKey() error {
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	var conditionInKey int64
+	err := DB.Raw(`SELECT COUNT(*) FROM information_schema.key_column_usage
+		WHERE table_name = 'user_cards' AND constraint_name = 'user_cards_pkey' AND column_name = 'condition'`).
+		Scan(&conditionInKey).Error
+	if err != nil || conditionInKey > 0 {
+		return err
+	}
+
+	return DB.Exec(`ALTER TABLE user_cards DROP CONSTRAINT IF EXISTS user_cards_pkey,
+		ADD PRIMARY KEY (user_id, printing_id, condition, edition, language, finish)`).Error
+}
+
+// migrateUserCardPrintings moves the collection entries of databases created before card printings were
+// tracked to the unspecified printing of their card, and keys user_cards by printing. It runs before
+// AutoMigrate, which cannot add the required printing column to a table with rows, and is a no-op once
+// applied or on new databases.
+func migrateUserCardPrintings() error {
+	if DB.Dialector.Name() != "postgres" || !DB.Migrator().HasTable("user_cards") ||
+		DB.Migrator().HasColumn("user_cards", "printing_id") {
+		return nil
+	}
+
+	if err := DB.AutoMigrate(&models.CardSet{}, &models.CardPrinting{}); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`INSERT INTO card_printings (card_id, set_code, rarity, rarity_code)
+				SELECT DISTINCT card_id, '', '', '' FROM user_cards
+				ON CONFLICT (card_id, set_code, rarity) DO NOTHING`,
+			`ALTER TABLE user_cards ADD COLUMN printing_id bigint`,
+			`UPDATE user_cards SET printing_id = card_printings.id FROM card_printings
+				WHERE card_printings.card_id = user_cards.card_id
+				AND card_printings.set_code = '' AND card_printings.rarity = ''`,
+			`ALTER TABLE user_cards ALTER COLUMN printing_id SET NOT NULL,
+				DROP CONSTRAINT IF EXISTS user_cards_pkey,
+				ADD PRIMARY KEY (user_id, printing_id)`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// migrateCardSearchVector adds the full-text search column of cards and its GIN index on PostgreSQL.
+// The column is generated from the name (weighted higher) and the effect text, so it never needs to be
+// written. Other databases fall back to substring matching.
+func migrateCardSearchVector() error {
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	err := DB.Exec(`ALTER TABLE cards ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce("desc", '')), 'B')
+		) STORED`).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Exec(`CREATE INDEX IF NOT EXISTS idx_cards_search_vector ON cards USING GIN (search_vector)`).Error
+}
+
+// migrateCardNameTrigrams enables the pg_trgm extension and indexes card names by trigrams on PostgreSQL,
+// for typo-tolerant name lookups. Other databases match names in memory instead.
+func migrateCardNameTrigrams() error {
+	if DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	if err := DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		return err
+	}
+
+	return DB.Exec(`CREATE INDEX IF NOT EXISTS idx_cards_name_trgm ON cards USING GIN (name gin_trgm_ops)`).Error
 }
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// BanlistHandler defines the handler interface for banlist-related routes.
+type BanlistHandler interface {
+	GetBanlists(c *gin.Context)
+	GetBanlist(c *gin.Context)
+	ImportBanlist(c *gin.Context)
+}
+
+type banlistHandler struct {
+	banlistService services.BanlistService
+}
+
+// NewBanlistHandler creates a new instance of BanlistHandler with the provided service.
+func NewBanlistHandler(banlistService services.BanlistService) BanlistHandler {
+	return &banlistHandler{
+		banlistService: banlistService,
+	}
+}
+
+// GetBanlists returns every stored banlist, newest first.
+func (h *banlistHandler) GetBanlists(c *gin.Context) {
+	banlists, err := h.banlistService.GetBanlists()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve banlists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, banlists)
+}
+
+// GetBanlist returns a single banlist with all of its entries.
+func (h *banlistHandler) GetBanlist(c *gin.Context) {
+	banlistID, err := strconv.ParseUint(c.Param("banlistId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid banlist ID"})
+		return
+	}
+
+	banlist, err := h.banlistService.GetBanlist(uint(banlistID))
+	if err != nil {
+		if errors.Is(err, services.ErrBanlistNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Banlist not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve banlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, banlist)
+}
+
+// ImportBanlist imports a banlist from a .json or .csv file.
+// Form fields:
+// - file: the banlist file
+// - format: format the banlist applies to (e.g. "TCG", "OCG")
+// - effective_date: date the banlist takes effect, as YYYY-MM-DD
+// - name (optional): display name of the banlist
+func (h *banlistHandler) ImportBanlist(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	effectiveDate, err := time.Parse("2006-01-02", c.PostForm("effective_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective date, expected YYYY-MM-DD"})
+		return
+	}
+
+	fileType := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+
+	banlist, err := h.banlistService.ImportBanlist(c.PostForm("name"), c.PostForm("format"), effectiveDate, fileType, file)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBanlist) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import banlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, banlist)
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +18,7 @@ type CardHandler interface {
 	GetCardByParam(c *gin.Context)
 	GetCards(c *gin.Context)
 	SearchCards(c *gin.Context)
+	GetArchetypes(c *gin.Context)
 }
 
 type cardHandler struct {
@@ -30,7 +33,9 @@ func NewCardHandler(service services.CardService) CardHandler {
 // Example routes:
 // - GET /cards/42 → by ID
 // - GET /cards/Dark%20Magician → by name
-// Returns 200 with the card if found, 404 if not, and 400 if param is missing.
+// Misspelt names resolve to the closest stored card when it is clearly the one meant.
+// Returns 200 with the card if found, 404 if not, with up to five similar names as suggestions when
+// searching by name, 400 if param is missing, and 500 if the card could not be looked up.
 func (h *cardHandler) GetCardByParam(c *gin.Context) {
 	param := strings.TrimSpace(c.Param("param"))
 	if param == "" {
@@ -49,25 +54,40 @@ func (h *cardHandler) GetCardByParam(c *gin.Context) {
 		return
 	}
 
-	card, err := h.service.GetCardByName(param)
+	card, err := h.service.GetCardByName(c.Request.Context(), param)
+	if err != nil && !services.IsUnknownCard(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		suggestions, suggestErr := h.service.SuggestCardNames(param)
+		if suggestErr != nil {
+			suggestions = []string{}
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "suggestions": suggestions})
 		return
 	}
 	c.JSON(http.StatusOK, card)
 }
 
 // GetCards handles GET requests to retrieve a paginated list of all cards.
-// Query params:
-// - limit (default: 20): max number of results
-// - offset (default: 0): number of results to skip
-// Returns 200 with total count and array of cards, or 500 on error.
+// Query params: the pagination params (see pageParams), with sort one of name (default), atk, def, level
+// or added.
+// Returns 200 with total count, array of cards and the cursor of the next page, 400 if the sort or cursor
+// is invalid, or 500 on error.
 func (h *cardHandler) GetCards(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	page, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	cards, err := h.service.GetCards(limit, offset)
+	cards, nextCursor, err := h.service.GetCards(page)
 	if err != nil {
+		if isPageError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cards"})
 		return
 	}
@@ -81,28 +101,47 @@ func (h *cardHandler) GetCards(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"totalCards": total,
 		"cards":      cards,
+		"nextCursor": nextCursorValue(nextCursor),
 	})
 }
 
 // SearchCards handles GET requests to retrieve cards that match optional filters.
 // Query params:
 // - name: partial or full name of the card
+// - q: full-text search over the name and effect text, supporting "quoted phrases", OR and -exclusions
 // - type: card type (e.g. "Spell Card", "Normal Monster")
 // - frameType: card frame type (e.g. "normal", "link", "pendulum")
-// - limit (default: 20): max number of results
-// - offset (default: 0): number of results to skip
-// Returns 200 with total count and results, 400 if the API call was invalid,
-// or 500 if an internal error occurred.
+// - archetype: exact archetype name, as listed by GET /api/archetypes (e.g. "Blue-Eyes")
+// - atkMin, atkMax, defMin, defMax: inclusive ATK and DEF ranges
+// - levelMin, levelMax: inclusive level or rank range
+// - attribute: monster attribute (e.g. "DARK")
+// - race: monster type (e.g. "Spellcaster"), or spell/trap property (e.g. "Quick-Play")
+// - linkMin, linkMax: inclusive link rating range
+// - linkMarkers: comma-separated markers the card must all have (e.g. "Top,Bottom-Left")
+// - scaleMin, scaleMax: inclusive pendulum scale range
+// - the pagination params (see pageParams), with sort one of name, atk, def, level, added or relevance;
+// searches with q default to relevance, others to name
+// Returns 200 with total count, results and the cursor of the next page, 400 if a filter, the sort,
+// the cursor or the API call was invalid, or 500 if an internal error occurred.
 func (h *cardHandler) SearchCards(c *gin.Context) {
-	name := c.Query("name")
-	cardType := c.Query("type")
-	frameType := c.Query("frameType")
+	query, err := parseCardQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	page, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	cards, err := h.service.GetFilteredCards(name, cardType, frameType, limit, offset)
+	cards, nextCursor, err := h.service.GetFilteredCards(c.Request.Context(), query, page)
 	if err != nil {
+		if isPageError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, client.ErrBadRequestFromAPI) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search term"})
 			return
@@ -112,7 +151,7 @@ func (h *cardHandler) SearchCards(c *gin.Context) {
 		return
 	}
 
-	total, err := h.service.CountFilteredCards(name, cardType, frameType)
+	total, err := h.service.CountFilteredCards(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count filtered cards"})
 		return
@@ -121,5 +160,79 @@ func (h *cardHandler) SearchCards(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"totalCards": total,
 		"cards":      cards,
+		"nextCursor": nextCursorValue(nextCursor),
 	})
 }
+
+// linkMarkers maps the accepted spellings of link markers to the way they are stored.
+var linkMarkers = map[string]string{
+	"top":          "Top",
+	"bottom":       "Bottom",
+	"left":         "Left",
+	"right":        "Right",
+	"top-left":     "Top-Left",
+	"top-right":    "Top-Right",
+	"bottom-left":  "Bottom-Left",
+	"bottom-right": "Bottom-Right",
+}
+
+// parseCardQuery reads the search filters of SearchCards from the query string.
+func parseCardQuery(c *gin.Context) (repository.CardQuery, error) {
+	query := repository.CardQuery{
+		Name:      c.Query("name"),
+		Text:      c.Query("q"),
+		Type:      c.Query("type"),
+		FrameType: c.Query("frameType"),
+		Archetype: c.Query("archetype"),
+		Attribute: c.Query("attribute"),
+		Race:      c.Query("race"),
+	}
+
+	bounds := []struct {
+		param string
+		value **int
+	}{
+		{"atkMin", &query.AtkMin}, {"atkMax", &query.AtkMax},
+		{"defMin", &query.DefMin}, {"defMax", &query.DefMax},
+		{"levelMin", &query.LevelMin}, {"levelMax", &query.LevelMax},
+		{"linkMin", &query.LinkMin}, {"linkMax", &query.LinkMax},
+		{"scaleMin", &query.ScaleMin}, {"scaleMax", &query.ScaleMax},
+	}
+	for _, bound := range bounds {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid %s value", bound.param)
+		}
+		*bound.value = &value
+	}
+
+	if raw := c.Query("linkMarkers"); raw != "" {
+		for _, marker := range strings.Split(raw, ",") {
+			stored, ok := linkMarkers[strings.ToLower(strings.TrimSpace(marker))]
+			if !ok {
+				return query, fmt.Errorf("Invalid link marker %q", marker)
+			}
+			query.LinkMarkers = append(query.LinkMarkers, stored)
+		}
+	}
+
+	return query, nil
+}
+
+// GetArchetypes handles GET requests to list the archetypes of the stored cards.
+// Query params:
+// - name: optional part of the archetype name, case-insensitive
+// Returns 200 with the archetypes, sorted by name, and their number of cards, or 500 on error.
+func (h *cardHandler) GetArchetypes(c *gin.Context) {
+	archetypes, err := h.service.GetArchetypes(strings.TrimSpace(c.Query("name")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve archetypes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archetypes": archetypes})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CardImageHandler defines the handler interface for card image administration routes.
+type CardImageHandler interface {
+	BackfillImages(c *gin.Context)
+	GetImageStatus(c *gin.Context)
+	GetQueueStats(c *gin.Context)
+}
+
+type cardImageHandler struct {
+	cardImageService  services.CardImageService
+	imageQueueService services.ImageQueueService
+}
+
+// NewCardImageHandler creates a new instance of CardImageHandler with the provided services.
+func NewCardImageHandler(cardImageService services.CardImageService, imageQueueService services.ImageQueueService) CardImageHandler {
+	return &cardImageHandler{
+		cardImageService:  cardImageService,
+		imageQueueService: imageQueueService,
+	}
+}
+
+// BackfillImages creates the missing thumbnails and cropped artwork of stored cards.
+// Query params:
+// - limit (default: 0): maximum number of cards to process, 0 for all of them
+func (h *cardImageHandler) BackfillImages(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		return
+	}
+
+	report, err := h.cardImageService.BackfillDerivatives(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("Card image backfill failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill card images"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetImageStatus returns the status of the image download of a card, identified by its ID, together with
+// its current image URLs.
+// Returns 404 if the card's image was never queued.
+func (h *cardImageHandler) GetImageStatus(c *gin.Context) {
+	cardID, err := strconv.ParseUint(c.Param("param"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	status, err := h.imageQueueService.GetJobStatus(uint(cardID))
+	if err != nil {
+		if errors.Is(err, services.ErrImageJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to get image status of card %d: %v", cardID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetQueueStats returns the number of image jobs in each status.
+func (h *cardImageHandler) GetQueueStats(c *gin.Context) {
+	stats, err := h.imageQueueService.GetQueueStats()
+	if err != nil {
+		log.Printf("Failed to get image queue stats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get image queue stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
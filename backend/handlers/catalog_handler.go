@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogHandler defines the handler interface for card catalog administration routes.
+type CatalogHandler interface {
+	SyncCatalog(c *gin.Context)
+}
+
+type catalogHandler struct {
+	catalogService services.CatalogService
+}
+
+// NewCatalogHandler creates a new instance of CatalogHandler with the provided service.
+func NewCatalogHandler(catalogService services.CatalogService) CatalogHandler {
+	return &catalogHandler{
+		catalogService: catalogService,
+	}
+}
+
+// SyncCatalog syncs the card catalog with a cardinfo.php dump uploaded as the "file" form field, or
+// downloaded from the configured catalog URL when no file is sent.
+// Query params:
+// - prune (default: false): delete the cards missing from the dump
+// - dryRun (default: false): report the changes without saving them
+func (h *catalogHandler) SyncCatalog(c *gin.Context) {
+	prune, err := strconv.ParseBool(c.DefaultQuery("prune", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prune value"})
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dryRun value"})
+		return
+	}
+
+	var report *services.CatalogSyncReport
+	if file, _, fileErr := c.Request.FormFile("file"); fileErr == nil {
+		defer file.Close()
+		report, err = h.catalogService.SyncFromReader(file, prune, dryRun)
+	} else {
+		report, err = h.catalogService.SyncFromURL(c.Request.Context(), services.CatalogURL(), prune, dryRun)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCatalog) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Card catalog sync failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync card catalog"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -4,29 +4,89 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// CopyAttributesInput defines the optional condition, edition, language and finish of copies.
+type CopyAttributesInput struct {
+	Condition string `json:"condition"`
+	Edition   string `json:"edition"`
+	Language  string `json:"language"`
+	Finish    string `json:"finish"`
+}
+
 // AddCardInput defines the structure for adding cards to the collection.
+// Copies without a printing are added to the unspecified printing of the card.
 type AddCardInput struct {
-	CardID   uint `json:"card_id" binding:"required"`
-	Quantity int  `json:"quantity" binding:"required"`
+	CardID     uint `json:"card_id" binding:"required"`
+	PrintingID uint `json:"printing_id"`
+	Quantity   int  `json:"quantity" binding:"required"`
+	CopyAttributesInput
 }
 
 // DeleteCardInput defines the structure for deleting a quantity of cards.
+// Without a printing, copies are removed from any printing, the unspecified one first. Attributes narrow
+// the copies removed.
 type DeleteCardInput struct {
-	Quantity int `json:"quantity" binding:"required"`
+	PrintingID uint `json:"printing_id"`
+	Quantity   int  `json:"quantity" binding:"required"`
+	CopyAttributesInput
+}
+
+// attributes returns the copy attributes of the input, lowercased and trimmed.
+func (in CopyAttributesInput) attributes() models.CopyAttributes {
+	return models.CopyAttributes{
+		Condition: normalizeAttribute(in.Condition),
+		Edition:   normalizeAttribute(in.Edition),
+		Language:  normalizeAttribute(in.Language),
+		Finish:    normalizeAttribute(in.Finish),
+	}
+}
+
+func normalizeAttribute(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// parseCollectionFilter reads the condition, edition, language and finish query params, and location, the ID
+// of a storage location or unassigned.
+func parseCollectionFilter(c *gin.Context) (repository.CollectionFilter, error) {
+	input := CopyAttributesInput{
+		Condition: c.Query("condition"),
+		Edition:   c.Query("edition"),
+		Language:  c.Query("language"),
+		Finish:    c.Query("finish"),
+	}
+	filter := repository.CollectionFilter{CopyAttributes: input.attributes()}
+
+	switch location := c.Query("location"); location {
+	case "":
+	case "unassigned":
+		filter.Unassigned = true
+	default:
+		locationID, err := strconv.ParseUint(location, 10, 64)
+		if err != nil || locationID == 0 {
+			return filter, errors.New("location must be a location ID or unassigned")
+		}
+		filter.LocationID = uint(locationID)
+	}
+	return filter, nil
 }
 
 // CollectionHandler defines the interface for collection-related HTTP operations.
 type CollectionHandler interface {
 	GetCollection(c *gin.Context)
+	GetCollectionGroups(c *gin.Context)
 	GetCollectionCard(c *gin.Context)
 	AddCardToCollection(c *gin.Context)
 	DeleteQuantityFromCollection(c *gin.Context)
+	GetCardReservations(c *gin.Context)
+	GetCollectionCardPrintings(c *gin.Context)
 }
 
 type collectionHandler struct {
@@ -39,16 +99,74 @@ func NewCollectionHandler(service services.CollectionService) CollectionHandler
 }
 
 // GET /collection
+// Returns the whole collection, or a page of it when any pagination param (see pageParams) is given, with
+// sort one of name (default), atk, def or level. nextCursor is null on the last page.
+// The condition, edition, language and finish params keep only the copies with those attributes, and
+// location those stored in a location, or not stored anywhere.
 func (h *collectionHandler) GetCollection(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
+	filter, err := parseCollectionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !isPaged(c) {
+		collection, err := h.service.GetUserCollection(userID, filter)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCopyAttributes) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+			return
+		}
 
-	collection, err := h.service.GetUserCollection(userID)
+		c.JSON(http.StatusOK, gin.H{"collection": collection, "nextCursor": nil})
+		return
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, nextCursor, err := h.service.GetUserCollectionPage(userID, filter, page)
 	if err != nil {
+		if isPageError(err) || errors.Is(err, services.ErrInvalidCopyAttributes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"collection": collection})
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "nextCursor": nextCursorValue(nextCursor)})
+}
+
+// GET /collection/groups?by=
+// Sums up the collection by condition, edition, language, finish, rarity or set, with the same attribute
+// filters as GetCollection.
+func (h *collectionHandler) GetCollectionGroups(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	filter, err := parseCollectionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groups, err := h.service.GroupUserCollection(userID, filter, c.Query("by"))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidGroup) || errors.Is(err, services.ErrInvalidCopyAttributes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to group collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
 }
 
 func (h *collectionHandler) GetCollectionCard(c *gin.Context) {
@@ -77,6 +195,25 @@ func (h *collectionHandler) GetCollectionCard(c *gin.Context) {
 	c.JSON(http.StatusOK, userCard)
 }
 
+// GET /collection/:cardId/printings
+// Lists the user's copies of the card, one entry per printing.
+func (h *collectionHandler) GetCollectionCardPrintings(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	cardIDUint, err := strconv.ParseUint(c.Param("cardId"), 10, 64)
+	if err != nil || cardIDUint == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	printings, err := h.service.GetUserCardPrintings(userID, uint(cardIDUint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve printings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"printings": printings})
+}
+
 // POST /collection
 func (h *collectionHandler) AddCardToCollection(c *gin.Context) {
 	var input AddCardInput
@@ -87,7 +224,15 @@ func (h *collectionHandler) AddCardToCollection(c *gin.Context) {
 
 	userID := c.MustGet("user_id").(uint)
 
-	if err := h.service.AddCardToCollection(userID, input.CardID, input.Quantity); err != nil {
+	if err := h.service.AddCardToCollection(userID, input.CardID, input.PrintingID, input.attributes(), input.Quantity); err != nil {
+		if errors.Is(err, services.ErrInvalidCopyAttributes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPrintingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Printing not found for this card"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add card to collection"})
 		return
 	}
@@ -111,10 +256,41 @@ func (h *collectionHandler) DeleteQuantityFromCollection(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DecreaseCardQuantity(userID, uint(cardIDUint), input.Quantity); err != nil {
+	if err := h.service.DecreaseCardQuantity(userID, uint(cardIDUint), input.PrintingID, input.attributes(), input.Quantity); err != nil {
+		if errors.Is(err, services.ErrInvalidCopyAttributes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrCardReserved) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Copies are reserved by physical decks"})
+			return
+		}
+		if errors.Is(err, services.ErrPrintingNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Printing not found for this card"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update card quantity"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Card quantity updated or removed successfully"})
 }
+
+// GET /collection/:cardId/reservations
+// Lists the physical decks holding copies of the card.
+func (h *collectionHandler) GetCardReservations(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	cardIDUint, err := strconv.ParseUint(c.Param("cardId"), 10, 64)
+	if err != nil || cardIDUint == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	reservations, err := h.service.GetCardReservations(userID, uint(cardIDUint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reservations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
 	"github.com/gin-gonic/gin"
 )
@@ -13,15 +17,39 @@ import (
 type CreateDeckRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	FormatID    uint   `json:"format_id"`
+}
+
+type SetDeckFormatRequest struct {
+	FormatID uint `json:"format_id" binding:"required"`
 }
 
 type AddCardRequest struct {
-	CardID   uint `json:"card_id" binding:"required"`
-	Quantity int  `json:"quantity" binding:"required"`
+	CardID   uint   `json:"card_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+	Zone     string `json:"zone"`
 }
 
 type RemoveCardRequest struct {
-	Quantity int `json:"quantity" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
+	Zone     string `json:"zone"`
+}
+
+// ImportDeckRequest carries a deck as text, for formats that are shared by pasting rather than as files.
+type ImportDeckRequest struct {
+	Format string `json:"format" binding:"required"`
+	Data   string `json:"data" binding:"required"`
+}
+
+type SetDeckPhysicalRequest struct {
+	Physical       bool `json:"physical"`
+	WarnOnShortage bool `json:"warn_on_shortage"`
+}
+
+type MoveCardRequest struct {
+	From     string `json:"from" binding:"required"`
+	To       string `json:"to" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required"`
 }
 
 // DeckHandler defines the handler interface for deck-related routes.
@@ -32,18 +60,28 @@ type DeckHandler interface {
 	GetCardByDeck(c *gin.Context)
 	AddCardToDeck(c *gin.Context)
 	RemoveCardFromDeck(c *gin.Context)
+	MoveCardBetweenZones(c *gin.Context)
 	ExportDeckHandler(c *gin.Context)
 	ImportDeckHandler(c *gin.Context)
+	ImportNewDeckHandler(c *gin.Context)
+	GetDeckLegality(c *gin.Context)
+	SetDeckFormat(c *gin.Context)
+	GetMissingCards(c *gin.Context)
+	SetDeckPhysical(c *gin.Context)
 }
 
 type deckHandler struct {
-	deckService services.DeckService
+	deckService    services.DeckService
+	formatService  services.FormatService
+	banlistService services.BanlistService
 }
 
-// NewDeckHandler creates a new instance of DeckHandler with the provided service.
-func NewDeckHandler(deckService services.DeckService) DeckHandler {
+// NewDeckHandler creates a new instance of DeckHandler with the provided services.
+func NewDeckHandler(deckService services.DeckService, formatService services.FormatService, banlistService services.BanlistService) DeckHandler {
 	return &deckHandler{
-		deckService: deckService,
+		deckService:    deckService,
+		formatService:  formatService,
+		banlistService: banlistService,
 	}
 }
 
@@ -57,10 +95,12 @@ func (h *deckHandler) CreateDeck(c *gin.Context) {
 		return
 	}
 
-	deck, err := h.deckService.CreateDeck(userID, req.Name, req.Description)
+	deck, err := h.deckService.CreateDeck(userID, req.Name, req.Description, req.FormatID)
 	if err != nil {
 		if errors.Is(err, services.ErrDeckAlreadyExists) || errors.Is(err, services.ErrMaximumNumberOfDecks) {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrFormatNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create deck"})
 		}
@@ -70,7 +110,9 @@ func (h *deckHandler) CreateDeck(c *gin.Context) {
 	c.JSON(http.StatusCreated, deck)
 }
 
-// GetUserDecks returns all decks associated with the authenticated user.
+// GetUserDecks returns all decks associated with the authenticated user as an array.
+// When any pagination param (see pageParams) is given, it returns a page of them as {"decks", "nextCursor"}
+// instead, with sort one of added (default) or name. nextCursor is null on the last page.
 func (h *deckHandler) GetUserDecks(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -78,6 +120,27 @@ func (h *deckHandler) GetUserDecks(c *gin.Context) {
 		return
 	}
 
+	if isPaged(c) {
+		page, err := parsePage(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		decks, nextCursor, err := h.deckService.GetDecksPageByUserID(userID.(uint), page)
+		if err != nil {
+			if isPageError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch decks"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"decks": decks, "nextCursor": nextCursorValue(nextCursor)})
+		return
+	}
+
 	decks, err := h.deckService.GetDecksByUserID(userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch decks"})
@@ -132,6 +195,7 @@ func (h *deckHandler) GetCardByDeck(c *gin.Context) {
 }
 
 // AddCardToDeck adds a card to a deck, respecting quantity and deck constraints.
+// The optional zone ("main", "extra" or "side") defaults to the main or extra deck depending on the card.
 func (h *deckHandler) AddCardToDeck(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 
@@ -148,12 +212,19 @@ func (h *deckHandler) AddCardToDeck(c *gin.Context) {
 		return
 	}
 
-	err = h.deckService.AddCardToDeck(userID, req.CardID, uint(deckID), req.Quantity)
+	warnings, err := h.deckService.AddCardToDeck(userID, req.CardID, uint(deckID), req.Quantity, req.Zone)
 	if err != nil {
 		switch {
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		case errors.Is(err, services.ErrNotEnoughCopies):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		case errors.Is(err, services.ErrCardCopyLimitExceeded),
+			errors.Is(err, services.ErrCardForbidden),
 			errors.Is(err, services.ErrDeckLimitReached),
-			errors.Is(err, services.ErrExtraDeckLimitReached):
+			errors.Is(err, services.ErrExtraDeckLimitReached),
+			errors.Is(err, services.ErrSideDeckLimitReached),
+			errors.Is(err, services.ErrInvalidZone):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add card to deck"})
@@ -161,7 +232,7 @@ func (h *deckHandler) AddCardToDeck(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Card added successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Card added successfully", "warnings": warnings})
 }
 
 // RemoveCardFromDeck removes a specific quantity of a card from a deck.
@@ -185,7 +256,7 @@ func (h *deckHandler) RemoveCardFromDeck(c *gin.Context) {
 		return
 	}
 
-	err := h.deckService.RemoveCardFromDeck(userID, uint(deckID), uint(cardID), req.Quantity)
+	err := h.deckService.RemoveCardFromDeck(userID, uint(deckID), uint(cardID), req.Quantity, req.Zone)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -194,9 +265,50 @@ func (h *deckHandler) RemoveCardFromDeck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Card removed from deck"})
 }
 
+// MoveCardBetweenZones moves copies of a card between the main, extra and side zones of a deck.
+func (h *deckHandler) MoveCardBetweenZones(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	deckID, err1 := strconv.ParseUint(c.Param("deckId"), 10, 64)
+	cardID, err2 := strconv.ParseUint(c.Param("cardId"), 10, 64)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IDs"})
+		return
+	}
+
+	var req MoveCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	err := h.deckService.MoveCardBetweenZones(userID, uint(deckID), uint(cardID), req.From, req.To, req.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		case errors.Is(err, services.ErrCardNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not enough copies of the card in the source zone"})
+		case errors.Is(err, services.ErrInvalidZone),
+			errors.Is(err, services.ErrDeckLimitReached),
+			errors.Is(err, services.ErrExtraDeckLimitReached),
+			errors.Is(err, services.ErrSideDeckLimitReached):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move card"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Card moved successfully"})
+}
+
 // Allows you to export a deck in .ydk format for use in clients such as EDOPro.
 // according to their number in the deck. (card_ygo_id) of each card, repeated
 // according to their quantity in the deck.
+// Query params:
+// - format (default: ydk): "ydk", "text", "csv", "json" or "pdf" (Konami registration sheet) for a file
+// named after the deck, or "ydke" for a ydke:// URL returned as JSON
 func (h *deckHandler) ExportDeckHandler(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 
@@ -207,17 +319,46 @@ func (h *deckHandler) ExportDeckHandler(c *gin.Context) {
 		return
 	}
 
-	ydkContent, err := h.deckService.ExportDeckAsYDK(userID, uint(deckID))
+	format := c.DefaultQuery("format", "ydk")
+	if format == "ydke" {
+		url, err := h.deckService.ExportDeckAsYDKE(userID, uint(deckID))
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrDeckNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export deck"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"format": "ydke", "data": url})
+		return
+	}
+
+	export, err := h.deckService.ExportDeck(userID, uint(deckID), format)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrUnsupportedExportFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format"})
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.Header("Content-Disposition", "attachment; filename=deck.ydk")
-	c.Data(http.StatusOK, "text/plain", []byte(ydkContent))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	c.Data(http.StatusOK, export.ContentType, export.Content)
 }
 
-// ImportDeckHandler imports a .ydk file into an existing deck, adding the cards accordingly.
+// ImportDeckHandler imports a deck into an existing deck, keeping each card in the section it was listed in.
+// The deck is either a .ydk or .txt decklist file sent as multipart form data, or a JSON body with a
+// format ("ydk", "ydke" or "text") and the deck as text.
+// The import is all-or-nothing: if a passcode is unknown or a deck rule is broken, nothing is added.
+// Query params:
+// - dryRun (default: false): report what the import would do without changing the deck
 func (h *deckHandler) ImportDeckHandler(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 
@@ -228,21 +369,53 @@ func (h *deckHandler) ImportDeckHandler(c *gin.Context) {
 		return
 	}
 
-	file, _, err := c.Request.FormFile("file")
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dryRun value"})
 		return
 	}
 
-	err = h.deckService.ImportDeckFromYDK(userID, uint(deckID), file)
+	var report *services.ImportReport
+	if c.ContentType() == "application/json" {
+		var req ImportDeckRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		switch req.Format {
+		case "ydk":
+			report, err = h.deckService.ImportDeckFromYDK(c.Request.Context(), userID, uint(deckID), strings.NewReader(req.Data), dryRun)
+		case "ydke":
+			report, err = h.deckService.ImportDeckFromYDKE(c.Request.Context(), userID, uint(deckID), req.Data, dryRun)
+		case "text":
+			report, err = h.deckService.ImportDeckFromText(c.Request.Context(), userID, uint(deckID), strings.NewReader(req.Data), dryRun)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported import format"})
+			return
+		}
+	} else {
+		file, header, fileErr := c.Request.FormFile("file")
+		if fileErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer file.Close()
+
+		if strings.EqualFold(filepath.Ext(header.Filename), ".txt") {
+			report, err = h.deckService.ImportDeckFromText(c.Request.Context(), userID, uint(deckID), file, dryRun)
+		} else {
+			report, err = h.deckService.ImportDeckFromYDK(c.Request.Context(), userID, uint(deckID), file, dryRun)
+		}
+	}
 	if err != nil {
 		switch {
-		case errors.Is(err, services.ErrCardCopyLimitExceeded):
-			c.JSON(http.StatusConflict, gin.H{"error": "Too many copies of a card in the deck"})
-		case errors.Is(err, services.ErrDeckLimitReached):
-			c.JSON(http.StatusConflict, gin.H{"error": "Main deck exceeds maximum size"})
-		case errors.Is(err, services.ErrExtraDeckLimitReached):
-			c.JSON(http.StatusConflict, gin.H{"error": "Extra deck exceeds maximum size"})
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		case errors.Is(err, services.ErrInvalidDeckData):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImportRejected):
+			c.JSON(http.StatusConflict, gin.H{"error": "Deck import rejected", "report": report})
 		default:
 			log.Printf("Unexpected error importing deck ID %d: %v", deckID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import deck"})
@@ -250,5 +423,192 @@ func (h *deckHandler) ImportDeckHandler(c *gin.Context) {
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, report)
+}
+
+// ImportNewDeckHandler creates a new deck from an uploaded .ydk file.
+// The deck name is taken from the optional "name" form field, the "#created by" header of the file
+// or the file name. Unknown passcodes are skipped and returned as warnings.
+func (h *deckHandler) ImportNewDeckHandler(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	deck, report, err := h.deckService.ImportNewDeckFromYDK(c.Request.Context(), userID, c.PostForm("name"), header.Filename, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeckAlreadyExists), errors.Is(err, services.ErrMaximumNumberOfDecks):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImportRejected):
+			c.JSON(http.StatusConflict, gin.H{"error": "Deck import rejected", "report": report})
+		default:
+			log.Printf("Unexpected error importing new deck for user %d: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import deck"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"deck":     deck,
+		"cards":    deck.DeckCards,
+		"warnings": report.Warnings,
+	})
+}
+
+// GetDeckLegality checks a deck against the rules of its format and a banlist, reporting every violation.
+// Query params:
+// - banlistId (optional): banlist to check against; defaults to the banlist in effect for the deck's format
+func (h *deckHandler) GetDeckLegality(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		return
+	}
+
+	deckCards, err := h.deckService.GetCardsByDeck(userID, uint(deckID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return
+	}
+
+	format, err := h.formatService.GetDeckFormat(uint(deckID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deck format"})
+		return
+	}
+
+	var banlist *models.Banlist
+	if banlistIDStr := c.Query("banlistId"); banlistIDStr != "" {
+		banlistID, err := strconv.ParseUint(banlistIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid banlist ID"})
+			return
+		}
+		banlist, err = h.banlistService.GetBanlist(uint(banlistID))
+		if err != nil {
+			if errors.Is(err, services.ErrBanlistNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Banlist not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve banlist"})
+			return
+		}
+	} else if format.BanlistFormat != "" {
+		banlist, err = h.banlistService.GetActiveBanlist(format.BanlistFormat)
+		if err != nil && !errors.Is(err, services.ErrBanlistNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve banlist"})
+			return
+		}
+	}
+
+	report := h.banlistService.CheckDeckLegality(format, banlist, deckCards)
+	report.AddFormatViolations(h.formatService.CheckDeckRules(format, deckCards))
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SetDeckFormat assigns a format to a deck. Existing cards are kept; use the legality endpoint to check them.
+func (h *deckHandler) SetDeckFormat(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		return
+	}
+
+	var req SetDeckFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	deck, err := h.deckService.SetDeckFormat(userID, uint(deckID), req.FormatID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		case errors.Is(err, services.ErrFormatNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck format"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, deck)
+}
+
+// GetMissingCards compares a deck with the user's collection and lists the cards still needed to build it.
+// Query params:
+// - includeOtherDecks (default: false): treat copies used by the user's other decks as unavailable
+func (h *deckHandler) GetMissingCards(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		return
+	}
+
+	includeOtherDecks, err := strconv.ParseBool(c.DefaultQuery("includeOtherDecks", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid includeOtherDecks value"})
+		return
+	}
+
+	report, err := h.deckService.GetMissingCards(userID, uint(deckID), includeOtherDecks)
+	if err != nil {
+		if errors.Is(err, services.ErrDeckNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+			return
+		}
+		log.Printf("Unexpected error checking deck ID %d against the collection: %v", deckID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check deck"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SetDeckPhysical marks a deck as physical, reserving the copies it holds from the collection, or releases it.
+// Marking a deck as physical fails with the list of missing cards when the collection does not have enough
+// unreserved copies, unless warn_on_shortage is set.
+func (h *deckHandler) SetDeckPhysical(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	deckID, err := strconv.ParseUint(c.Param("deckId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid deck ID"})
+		return
+	}
+
+	var req SetDeckPhysicalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	deck, report, err := h.deckService.SetDeckPhysical(userID, uint(deckID), req.Physical, req.WarnOnShortage)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeckNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		case errors.Is(err, services.ErrNotEnoughCopies):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "report": report})
+		default:
+			log.Printf("Unexpected error updating deck ID %d: %v", deckID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deck"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deck": deck, "report": report})
 }
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateFormatRequest struct {
+	Name          string `json:"name" binding:"required"`
+	BanlistFormat string `json:"banlist_format"`
+	MainMin       int    `json:"main_min"`
+	MainMax       int    `json:"main_max" binding:"required"`
+	ExtraMax      int    `json:"extra_max"`
+	SideMax       int    `json:"side_max"`
+	MaxCopies     int    `json:"max_copies" binding:"required"`
+}
+
+// FormatHandler defines the handler interface for format-related routes.
+type FormatHandler interface {
+	GetFormats(c *gin.Context)
+	GetFormat(c *gin.Context)
+	CreateFormat(c *gin.Context)
+}
+
+type formatHandler struct {
+	formatService services.FormatService
+}
+
+// NewFormatHandler creates a new instance of FormatHandler with the provided service.
+func NewFormatHandler(formatService services.FormatService) FormatHandler {
+	return &formatHandler{
+		formatService: formatService,
+	}
+}
+
+// GetFormats returns the built-in formats and the custom formats of the authenticated user.
+func (h *formatHandler) GetFormats(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	formats, err := h.formatService.GetFormats(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve formats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, formats)
+}
+
+// GetFormat returns a single format by ID.
+func (h *formatHandler) GetFormat(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	formatID, err := strconv.ParseUint(c.Param("formatId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format ID"})
+		return
+	}
+
+	format, err := h.formatService.GetFormat(userID, uint(formatID))
+	if err != nil {
+		if errors.Is(err, services.ErrFormatNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Format not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve format"})
+		return
+	}
+
+	c.JSON(http.StatusOK, format)
+}
+
+// CreateFormat creates a custom format with house rules for the authenticated user.
+func (h *formatHandler) CreateFormat(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req CreateFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	format, err := h.formatService.CreateFormat(userID, models.Format{
+		Name:          req.Name,
+		BanlistFormat: req.BanlistFormat,
+		MainMin:       req.MainMin,
+		MainMax:       req.MainMax,
+		ExtraMax:      req.ExtraMax,
+		SideMax:       req.SideMax,
+		MaxCopies:     req.MaxCopies,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create format"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, format)
+}
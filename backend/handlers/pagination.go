@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams are the query params of paginated listings:
+// - limit (default: 20): max number of results
+// - offset (default: 0): number of results to skip, when no cursor is given
+// - cursor: the nextCursor of the previous page; it keeps the sort of the first page
+// - sort: sort key of the listing
+// - order (default: asc): asc or desc
+var pageParams = []string{"limit", "offset", "cursor", "sort", "order"}
+
+// isPaged reports whether the request uses any pagination param, for listings that return everything otherwise.
+func isPaged(c *gin.Context) bool {
+	for _, param := range pageParams {
+		if _, ok := c.GetQuery(param); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePage reads the pagination params of a listing.
+func parsePage(c *gin.Context) (repository.Page, error) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	page := repository.Page{
+		Sort:   c.Query("sort"),
+		Limit:  limit,
+		Offset: offset,
+		Cursor: c.Query("cursor"),
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return page, fmt.Errorf("Invalid order value")
+	}
+
+	return page, nil
+}
+
+// isPageError reports whether err was caused by an invalid sort or cursor.
+func isPageError(err error) bool {
+	return errors.Is(err, repository.ErrInvalidSort) || errors.Is(err, repository.ErrInvalidCursor)
+}
+
+// nextCursorValue returns the nextCursor of a response, null on the last page.
+func nextCursorValue(cursor string) interface{} {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateLocationRequest struct {
+	Name string `json:"name" binding:"required"`
+	Kind string `json:"kind"`
+}
+
+// StoragePositionInput defines a storage location, with an optional page and slot. A zero location stands
+// for the unassigned copies of the collection.
+type StoragePositionInput struct {
+	LocationID uint `json:"location_id"`
+	Page       int  `json:"page"`
+	Slot       int  `json:"slot"`
+}
+
+// MoveCopiesInput defines the structure for moving copies of a collection entry between storage locations.
+// Copies without a printing belong to the unspecified printing of the card.
+type MoveCopiesInput struct {
+	CardID     uint                 `json:"card_id" binding:"required"`
+	PrintingID uint                 `json:"printing_id"`
+	From       StoragePositionInput `json:"from"`
+	To         StoragePositionInput `json:"to"`
+	Quantity   int                  `json:"quantity" binding:"required"`
+	CopyAttributesInput
+}
+
+func (in StoragePositionInput) position() repository.StoragePosition {
+	return repository.StoragePosition{LocationID: in.LocationID, Page: in.Page, Slot: in.Slot}
+}
+
+// StorageHandler defines the handler interface for storage location routes.
+type StorageHandler interface {
+	GetInventory(c *gin.Context)
+	CreateLocation(c *gin.Context)
+	DeleteLocation(c *gin.Context)
+	GetLocationCards(c *gin.Context)
+	GetCardLocations(c *gin.Context)
+	MoveCopies(c *gin.Context)
+}
+
+type storageHandler struct {
+	service services.StorageService
+}
+
+// NewStorageHandler creates a new instance of StorageHandler with the provided service.
+func NewStorageHandler(service services.StorageService) StorageHandler {
+	return &storageHandler{service: service}
+}
+
+// GET /locations
+// Lists the storage locations of the user with the number of cards and copies each holds, and those of the
+// collection not stored anywhere.
+func (h *storageHandler) GetInventory(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	inventory, err := h.service.GetInventory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve storage locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
+
+// POST /locations
+func (h *storageHandler) CreateLocation(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var req CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	location, err := h.service.CreateLocation(userID, req.Name, req.Kind)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLocation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create storage location"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// DELETE /locations/:locationId
+// The copies held by the location become unassigned.
+func (h *storageHandler) DeleteLocation(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	locationID, err := strconv.ParseUint(c.Param("locationId"), 10, 64)
+	if err != nil || locationID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	if err := h.service.DeleteLocation(userID, uint(locationID)); err != nil {
+		if errors.Is(err, services.ErrLocationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Storage location not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete storage location"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Storage location deleted successfully"})
+}
+
+// GET /locations/:locationId/cards
+// Lists the copies kept in the location, by page and slot.
+func (h *storageHandler) GetLocationCards(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	locationID, err := strconv.ParseUint(c.Param("locationId"), 10, 64)
+	if err != nil || locationID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return
+	}
+
+	cards, err := h.service.GetLocationCards(userID, uint(locationID))
+	if err != nil {
+		if errors.Is(err, services.ErrLocationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Storage location not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stored cards"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cards": cards})
+}
+
+// GET /locations/cards/:cardId
+// Lists where the user's copies of the card are kept.
+func (h *storageHandler) GetCardLocations(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	cardID, err := strconv.ParseUint(c.Param("cardId"), 10, 64)
+	if err != nil || cardID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	locations, err := h.service.GetCardLocations(userID, uint(cardID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve card locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// POST /locations/move
+// Moves copies of a collection entry between locations, all of them or none.
+func (h *storageHandler) MoveCopies(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var input MoveCopiesInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.CardID == 0 || input.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err := h.service.MoveCopies(userID, repository.CopyMove{
+		CardID:         input.CardID,
+		PrintingID:     input.PrintingID,
+		CopyAttributes: input.attributes(),
+		From:           input.From.position(),
+		To:             input.To.position(),
+		Quantity:       input.Quantity,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMove), errors.Is(err, services.ErrInvalidCopyAttributes):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrLocationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Storage location not found"})
+		case errors.Is(err, repository.ErrNotEnoughCopies):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move copies"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Copies moved successfully"})
+}
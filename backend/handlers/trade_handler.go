@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// WishlistInput defines the structure for adding a card to the wishlist. Without a printing or condition,
+// any printing or condition will do.
+type WishlistInput struct {
+	CardID     uint   `json:"card_id" binding:"required"`
+	PrintingID uint   `json:"printing_id"`
+	Condition  string `json:"condition"` // worst condition accepted
+	Quantity   int    `json:"quantity" binding:"required"`
+}
+
+// TradeHandler defines the handler interface for wishlist and trade routes.
+type TradeHandler interface {
+	GetWishlist(c *gin.Context)
+	AddToWishlist(c *gin.Context)
+	RemoveFromWishlist(c *gin.Context)
+	GetTradeable(c *gin.Context)
+	GetMatches(c *gin.Context)
+}
+
+type tradeHandler struct {
+	service services.TradeService
+}
+
+// NewTradeHandler creates a new instance of TradeHandler with the provided service.
+func NewTradeHandler(service services.TradeService) TradeHandler {
+	return &tradeHandler{service: service}
+}
+
+// GET /wishlist
+func (h *tradeHandler) GetWishlist(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	wishlist, err := h.service.GetWishlist(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wishlist": wishlist})
+}
+
+// POST /wishlist
+// Adding a card already on the wishlist with the same printing and condition sets its quantity.
+func (h *tradeHandler) AddToWishlist(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var input WishlistInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.CardID == 0 || input.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	entry := models.WishlistEntry{
+		CardID:    input.CardID,
+		Condition: normalizeAttribute(input.Condition),
+		Quantity:  input.Quantity,
+	}
+	if input.PrintingID != 0 {
+		entry.PrintingID = &input.PrintingID
+	}
+
+	saved, err := h.service.AddToWishlist(userID, entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidWishlistEntry), errors.Is(err, services.ErrInvalidCopyAttributes):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPrintingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Printing not found for this card"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add card to wishlist"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DELETE /wishlist/:entryId
+func (h *tradeHandler) RemoveFromWishlist(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	entryID, err := strconv.ParseUint(c.Param("entryId"), 10, 64)
+	if err != nil || entryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlist entry ID"})
+		return
+	}
+
+	if err := h.service.RemoveFromWishlist(userID, uint(entryID)); err != nil {
+		if errors.Is(err, services.ErrWishlistEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove card from wishlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Card removed from wishlist successfully"})
+}
+
+// GET /trades/tradeable
+// Lists the cards of the collection with copies beyond a playset and the physical deck reservations.
+func (h *tradeHandler) GetTradeable(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	tradeable, err := h.service.GetTradeable(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tradeable cards"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tradeable": tradeable})
+}
+
+// GET /trades/matches
+// Lists the users who can spare cards on the wishlist, and those who wish for cards the user can spare.
+func (h *tradeHandler) GetMatches(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	matches, err := h.service.GetMatches(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find trade matches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Banlist statuses as published in the official Forbidden & Limited lists.
+const (
+	BanStatusForbidden   = "forbidden"
+	BanStatusLimited     = "limited"
+	BanStatusSemiLimited = "semi-limited"
+	BanStatusUnlimited   = "unlimited"
+	DefaultBanlistFormat = "TCG"
+)
+
+// Banlist represents a Forbidden & Limited list for a given format,
+// effective from a given date onwards.
+type Banlist struct {
+	ID            uint      `gorm:"primaryKey"`
+	Name          string    `gorm:"not null"`
+	Format        string    `gorm:"type:varchar(20);not null;index:idx_banlist_format_date"`
+	EffectiveDate time.Time `gorm:"not null;index:idx_banlist_format_date"`
+
+	Entries []BanlistEntry `gorm:"foreignKey:BanlistID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BanlistEntry stores the status of a single card (identified by its passcode) in a banlist.
+type BanlistEntry struct {
+	BanlistID uint `gorm:"primaryKey"`
+	CardYGOID int  `gorm:"primaryKey"`
+	Name      string
+	Status    string `gorm:"type:varchar(20);not null"`
+}
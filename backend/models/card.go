@@ -4,15 +4,21 @@ import "gorm.io/gorm"
 
 type Card struct {
 	gorm.Model
-	CardYGOID int `gorm:"unique;not null"`
-	Name      string
-	Desc      string
-	FrameType string
-	Type      string
-	ImageURL  string
+	CardYGOID    int `gorm:"unique;not null"`
+	Name         string
+	Desc         string
+	FrameType    string
+	Type         string
+	Archetype    string `gorm:"index"`
+	ImageURL     string
+	ThumbnailURL string
+	ArtURL       string
 
 	MonsterCard         *MonsterCard
 	SpellTrapCard       *SpellTrapCard
 	LinkMonsterCard     *LinkMonsterCard
 	PendulumMonsterCard *PendulumMonsterCard
+
+	// Printings are not saved with the card, see the card repository.
+	Printings []CardPrinting `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:",omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+// CardSet is a product cards are printed in, such as "Legend of Blue Eyes White Dragon".
+type CardSet struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"not null;uniqueIndex"`
+	Code string `gorm:"index"` // prefix of the set codes of its printings, e.g. "LOB"
+}
+
+// CardPrinting is a printing of a card in a set with a rarity, such as LOB-001 in Ultra Rare.
+// Every card a collection holds without a known printing has an unspecified printing, with no set,
+// set code or rarity.
+type CardPrinting struct {
+	ID         uint   `gorm:"primaryKey"`
+	CardID     uint   `gorm:"not null;uniqueIndex:idx_card_printing"`
+	CardSetID  *uint  `gorm:"index"`
+	SetCode    string `gorm:"not null;default:'';uniqueIndex:idx_card_printing"` // e.g. "LOB-001"
+	Rarity     string `gorm:"not null;default:'';uniqueIndex:idx_card_printing"` // e.g. "Ultra Rare"
+	RarityCode string // e.g. "(UR)"
+
+	CardSet *CardSet `gorm:"foreignKey:CardSetID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// Unspecified reports whether the printing is the unspecified printing of its card.
+func (p CardPrinting) Unspecified() bool {
+	return p.SetCode == "" && p.Rarity == ""
+}
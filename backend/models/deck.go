@@ -5,7 +5,13 @@ type Deck struct {
 	UserID      uint   `gorm:"not null;index"`
 	Name        string `gorm:"not null"`
 	Description string
+	FormatID    *uint
+	// Physical decks reserve the copies they hold from the owner's collection.
+	// With WarnOnShortage set, cards can still be added without enough unreserved copies.
+	Physical       bool `gorm:"not null;default:false"`
+	WarnOnShortage bool `gorm:"not null;default:false"`
 
 	DeckCards []DeckCard `gorm:"foreignKey:DeckID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	User      User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Format    *Format    `gorm:"foreignKey:FormatID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
@@ -1,10 +1,19 @@
 package models
 
+// Deck zones a card can be placed in.
+const (
+	ZoneMain  = "main"
+	ZoneExtra = "extra"
+	ZoneSide  = "side"
+)
+
+// DeckCard stores how many copies of a card a deck holds in a zone.
+// The same card can be present in several zones of a deck at once.
 type DeckCard struct {
 	DeckID   uint   `gorm:"primaryKey"`
 	CardID   uint   `gorm:"primaryKey"`
+	Zone     string `gorm:"primaryKey;type:varchar(10);not null"`
 	Quantity int    `gorm:"not null"`
-	Zone     string `gorm:"type:varchar(10);not null"`
 
 	Card Card `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Deck Deck `gorm:"foreignKey:DeckID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -0,0 +1,19 @@
+package models
+
+// DefaultFormatCode is the format used by decks that have not been assigned one.
+const DefaultFormatCode = "TCG"
+
+// Format holds the deck construction rules of a game format.
+// Built-in formats have no owner; custom house rules belong to the user who created them.
+type Format struct {
+	ID            uint   `gorm:"primaryKey"`
+	UserID        *uint  `gorm:"index"`
+	Code          string `gorm:"type:varchar(20);not null;index"`
+	Name          string `gorm:"not null"`
+	BanlistFormat string `gorm:"type:varchar(20)"` // Format whose banlists apply, empty for none
+	MainMin       int    `gorm:"not null"`
+	MainMax       int    `gorm:"not null"`
+	ExtraMax      int    `gorm:"not null"`
+	SideMax       int    `gorm:"not null"`
+	MaxCopies     int    `gorm:"not null"`
+}
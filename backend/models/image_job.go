@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Image job statuses.
+const (
+	ImageJobPending = "pending"
+	ImageJobRunning = "running"
+	ImageJobDone    = "done"
+	ImageJobFailed  = "failed"
+)
+
+// ImageJob tracks the download of a card's image into the image store. There is at most one job per card;
+// queueing the card again resets it.
+type ImageJob struct {
+	ID            uint   `gorm:"primaryKey"`
+	CardID        uint   `gorm:"not null;uniqueIndex"`
+	CardYGOID     int    `gorm:"not null"`
+	SourceURL     string `gorm:"not null"`
+	Status        string `gorm:"type:varchar(20);not null;index:idx_image_job_status_next"`
+	Attempts      int    `gorm:"not null;default:0"`
+	LastError     string
+	NextAttemptAt time.Time `gorm:"not null;index:idx_image_job_status_next"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	Card Card `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Kinds of storage locations.
+const (
+	LocationBinder  = "binder"
+	LocationBox     = "box"
+	LocationDeckBox = "deck-box"
+	LocationOther   = "other"
+)
+
+// StorageLocation is a place where a user keeps physical cards, such as a binder or a box.
+type StorageLocation struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"not null;index"`
+	Name   string `gorm:"not null"`
+	Kind   string `gorm:"type:varchar(20);not null"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// StoredCard stores how many copies of a collection entry are kept in a location, at a page and slot when
+// they are not zero. The copies of an entry that are not stored anywhere are unassigned, so the stored
+// copies of an entry never exceed its quantity.
+type StoredCard struct {
+	LocationID uint `gorm:"primaryKey"`
+	PrintingID uint `gorm:"primaryKey"`
+	CopyAttributes
+	Page     int  `gorm:"primaryKey;autoIncrement:false;not null;default:0"`
+	Slot     int  `gorm:"primaryKey;autoIncrement:false;not null;default:0"`
+	UserID   uint `gorm:"not null;index"`
+	CardID   uint `gorm:"not null;index"`
+	Quantity int  `gorm:"not null"`
+
+	Location *StorageLocation `gorm:"foreignKey:LocationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Card     Card             `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Printing *CardPrinting    `gorm:"foreignKey:PrintingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -8,6 +8,7 @@ type User struct {
 	Username string `gorm:"unique;not null"`
 	Email    string `gorm:"unique;not null"`
 	Password string // Hashed password
+	IsAdmin  bool   `gorm:"not null;default:false"` // Grants access to the /api/admin routes
 
 	Collection []UserCard `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Decks      []Deck     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
@@ -1,15 +1,53 @@
 package models
 
-// UserCard represents the relationship between a user and a card in the collection.
-// It includes fields for the unique ID of the relationship, the user's ID, the card's ID,
-// and the quantity of the card the user owns.
-// The struct also establishes a foreign key relationship with the Card model,
-// ensuring that updates or deletions to a card are cascaded to the UserCard table.
+// Conditions of a copy, from best to worst.
+const (
+	ConditionMint             = "mint"
+	ConditionNearMint         = "near-mint"
+	ConditionLightlyPlayed    = "lightly-played"
+	ConditionModeratelyPlayed = "moderately-played"
+	ConditionHeavilyPlayed    = "heavily-played"
+	ConditionDamaged          = "damaged"
+)
+
+// Editions a copy can be printed in.
+const (
+	EditionFirst     = "1st"
+	EditionUnlimited = "unlimited"
+	EditionLimited   = "limited"
+)
+
+// Finishes of a copy.
+const (
+	FinishNonFoil = "non-foil"
+	FinishFoil    = "foil"
+)
+
+// CopyLanguages are the languages copies can be printed in, as ISO 639-1 codes.
+var CopyLanguages = []string{"en", "fr", "de", "it", "pt", "es", "ja", "ko", "zh"}
+
+// CopyAttributes describe the physical copies of a collection entry. Empty attributes are unspecified.
+type CopyAttributes struct {
+	Condition string `gorm:"primaryKey;type:varchar(20);not null;default:''"`
+	Edition   string `gorm:"primaryKey;type:varchar(20);not null;default:''"`
+	Language  string `gorm:"primaryKey;type:varchar(5);not null;default:''"`
+	Finish    string `gorm:"primaryKey;type:varchar(20);not null;default:''"`
+}
+
+// UserCard represents the copies of a card printing a user owns with the same condition, edition,
+// language and finish.
+// It includes fields for the user's ID, the printing's ID, the ID of its card, the attributes of the copies
+// and their quantity. The card ID is kept alongside the printing so that copies can be counted per card.
+// The struct also establishes foreign key relationships with the Card, CardPrinting and User models,
+// ensuring that updates or deletions are cascaded to the UserCard table.
 type UserCard struct {
-	UserID   uint `gorm:"primaryKey"`
-	CardID   uint `gorm:"primaryKey"`
+	UserID     uint `gorm:"primaryKey"`
+	PrintingID uint `gorm:"primaryKey"`
+	CopyAttributes
+	CardID   uint `gorm:"not null;index"`
 	Quantity int
 
-	Card Card `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Card     Card          `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Printing *CardPrinting `gorm:"foreignKey:PrintingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	User     User          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
@@ -0,0 +1,16 @@
+package models
+
+// WishlistEntry is a card a user wants to get and how many copies. It can ask for a specific printing, and
+// for copies in a given condition or better.
+type WishlistEntry struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index"`
+	CardID     uint   `gorm:"not null;index"`
+	PrintingID *uint  `gorm:"index"`
+	Condition  string `gorm:"type:varchar(20);not null;default:''"` // Worst condition accepted, empty for any
+	Quantity   int    `gorm:"not null"`
+
+	User     User          `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Card     Card          `gorm:"foreignKey:CardID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Printing *CardPrinting `gorm:"foreignKey:PrintingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+// BanlistRepository defines the interface for banlist-related database operations.
+type BanlistRepository interface {
+	Create(banlist *models.Banlist) error
+	FindAll() ([]models.Banlist, error)
+	FindByID(id uint) (*models.Banlist, error)
+	FindActive(format string, at time.Time) (*models.Banlist, error)
+	GetEntry(banlistID uint, cardYGOID int) (*models.BanlistEntry, error)
+}
+
+type banlistRepository struct {
+	db *gorm.DB
+}
+
+// NewBanlistRepository creates a new instance of banlistRepository using the default DB.
+func NewBanlistRepository() BanlistRepository {
+	return &banlistRepository{
+		db: database.DB,
+	}
+}
+
+func NewBanlistRepositoryWithDB(db *gorm.DB) BanlistRepository {
+	return &banlistRepository{
+		db: db,
+	}
+}
+
+// Create stores a banlist together with all of its entries in a single transaction.
+func (r *banlistRepository) Create(banlist *models.Banlist) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(banlist).Error
+	})
+}
+
+// FindAll returns every stored banlist without its entries, newest first.
+func (r *banlistRepository) FindAll() ([]models.Banlist, error) {
+	var banlists []models.Banlist
+	err := r.db.Order("effective_date DESC").Find(&banlists).Error
+	return banlists, err
+}
+
+// FindByID retrieves a banlist by ID, including its entries.
+func (r *banlistRepository) FindByID(id uint) (*models.Banlist, error) {
+	var banlist models.Banlist
+	err := r.db.Preload("Entries").First(&banlist, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &banlist, nil
+}
+
+// FindActive returns the most recent banlist of a format that is already in effect at the given time.
+// Returns nil without error when the format has no banlist yet.
+func (r *banlistRepository) FindActive(format string, at time.Time) (*models.Banlist, error) {
+	var banlist models.Banlist
+	err := r.db.Where("format = ? AND effective_date <= ?", format, at).
+		Order("effective_date DESC").
+		First(&banlist).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &banlist, nil
+}
+
+// GetEntry returns the entry of a card in a banlist, or nil if the card is unlimited.
+func (r *banlistRepository) GetEntry(banlistID uint, cardYGOID int) (*models.BanlistEntry, error) {
+	var entry models.BanlistEntry
+	err := r.db.Where("banlist_id = ? AND card_ygo_id = ?", banlistID, cardYGOID).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
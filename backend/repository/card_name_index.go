@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cardNameIndex keeps the normalized names of every card in memory, to find names close to a misspelt one
+// on databases without trigram matching. It is reloaded whenever the number of cards or their latest
+// update changes.
+type cardNameIndex struct {
+	mu      sync.Mutex
+	stamp   nameIndexStamp
+	loaded  bool
+	entries []nameIndexEntry
+}
+
+// nameIndexStamp tells whether the cards table changed since the index was loaded.
+type nameIndexStamp struct {
+	Count     int64
+	UpdatedAt string
+}
+
+type nameIndexEntry struct {
+	id   uint
+	name string
+}
+
+// similarCard is a card found by the index and how far its name is from the searched one.
+type similarCard struct {
+	id       uint
+	distance int
+}
+
+// findSimilar returns the IDs of up to limit cards whose normalized name is within half its length of the
+// normalized name, or contains it, closest first.
+func (idx *cardNameIndex) findSimilar(db *gorm.DB, name string, limit int) ([]uint, error) {
+	target := utils.NormalizeCardName(name)
+	if target == "" {
+		return nil, nil
+	}
+
+	entries, err := idx.load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []similarCard
+	for _, entry := range entries {
+		distance := utils.EditDistance(target, entry.name)
+		if distance <= max(len(target), len(entry.name))/2 || strings.Contains(entry.name, target) {
+			matches = append(matches, similarCard{id: entry.id, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.id)
+	}
+	return ids, nil
+}
+
+// load returns the indexed names, reading them again if the cards table changed.
+func (idx *cardNameIndex) load(db *gorm.DB) ([]nameIndexEntry, error) {
+	var stamp nameIndexStamp
+	err := db.Model(&models.Card{}).
+		Select("COUNT(*) AS count, COALESCE(CAST(MAX(updated_at) AS TEXT), '') AS updated_at").
+		Scan(&stamp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.loaded && idx.stamp == stamp {
+		return idx.entries, nil
+	}
+
+	var cards []models.Card
+	if err := db.Select("id", "name").Order("id").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]nameIndexEntry, 0, len(cards))
+	for _, card := range cards {
+		entries = append(entries, nameIndexEntry{id: card.ID, name: utils.NormalizeCardName(card.Name)})
+	}
+	idx.entries, idx.stamp, idx.loaded = entries, stamp, true
+	return entries, nil
+}
+
+// whereSimilarName keeps the cards whose name is similar to name by trigram matching, most similar first
+// and then by ID.
+// It needs the pg_trgm extension, see database.AutoMigrate.
+func whereSimilarName(db *gorm.DB, name string) *gorm.DB {
+	return db.Where("cards.name % ?", name).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "similarity(cards.name, ?) DESC, cards.id", Vars: []interface{}{name}}})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saveCardPrintings stores the printings of a saved card, creating or updating their sets, and deletes the
+// printings it no longer has unless a collection holds them. setIDs caches the IDs of the sets already
+// saved, by name, across calls.
+func saveCardPrintings(tx *gorm.DB, card *models.Card, setIDs map[string]uint) error {
+	if err := deleteStalePrintings(tx, card); err != nil {
+		return err
+	}
+	if len(card.Printings) == 0 {
+		return nil
+	}
+
+	for i := range card.Printings {
+		printing := &card.Printings[i]
+		printing.CardID = card.ID
+		if printing.CardSet == nil {
+			continue
+		}
+
+		id, ok := setIDs[printing.CardSet.Name]
+		if !ok {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"code"}),
+			}).Create(printing.CardSet).Error
+			if err != nil {
+				return err
+			}
+			id = printing.CardSet.ID
+			setIDs[printing.CardSet.Name] = id
+		}
+		printing.CardSetID = &id
+	}
+
+	return tx.Omit("CardSet").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "card_id"}, {Name: "set_code"}, {Name: "rarity"}},
+		DoUpdates: clause.AssignmentColumns([]string{"card_set_id", "rarity_code"}),
+	}).Create(&card.Printings).Error
+}
+
+// deleteStalePrintings deletes the printings of a card that are not in card.Printings, keeping its unspecified
+// printing and the printings collections hold.
+func deleteStalePrintings(tx *gorm.DB, card *models.Card) error {
+	current := make(map[[2]string]bool, len(card.Printings))
+	for _, printing := range card.Printings {
+		current[[2]string{printing.SetCode, printing.Rarity}] = true
+	}
+
+	var stored []models.CardPrinting
+	if err := withoutUnspecifiedPrintings(tx.Where("card_id = ?", card.ID)).Find(&stored).Error; err != nil {
+		return err
+	}
+	var stale []uint
+	for _, printing := range stored {
+		if !current[[2]string{printing.SetCode, printing.Rarity}] {
+			stale = append(stale, printing.ID)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	return tx.Where("id IN ? AND id NOT IN (?)", stale, tx.Model(&models.UserCard{}).Select("printing_id")).
+		Delete(&models.CardPrinting{}).Error
+}
+
+// unspecifiedPrinting returns the unspecified printing of a card, creating it if needed.
+func unspecifiedPrinting(tx *gorm.DB, cardID uint) (*models.CardPrinting, error) {
+	var printing models.CardPrinting
+	err := tx.Where("card_id = ? AND set_code = '' AND rarity = ''", cardID).
+		Attrs(models.CardPrinting{CardID: cardID}).
+		FirstOrCreate(&printing).Error
+	return &printing, err
+}
+
+// withoutUnspecifiedPrintings leaves out the unspecified printings of cards when preloading printings.
+func withoutUnspecifiedPrintings(db *gorm.DB) *gorm.DB {
+	return db.Where("set_code <> '' OR rarity <> ''").Order("set_code, rarity")
+}
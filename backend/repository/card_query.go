@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CardQuery describes a card search. Empty strings and nil bounds are not filtered on; ranges are inclusive.
+type CardQuery struct {
+	Name string // part of the name, case-insensitive
+	// Text is a full-text search over the name and effect text, with quoted phrases, OR and -exclusions.
+	// Results are sorted by relevance.
+	Text      string
+	Type      string // e.g. "Spell Card", "Effect Monster"
+	FrameType string // e.g. "normal", "link", "pendulum"
+	Archetype string // exact name, e.g. "Blue-Eyes"
+
+	// Monster stats, looked up in whichever subtype table holds the card. Link monsters have no DEF or level.
+	AtkMin, AtkMax     *int
+	DefMin, DefMax     *int
+	LevelMin, LevelMax *int // level, or rank for Xyz monsters
+	Attribute          string
+	// Race is the monster type (e.g. "Spellcaster"), or the property of spells and traps (e.g. "Quick-Play").
+	Race string
+
+	LinkMin, LinkMax *int
+	// LinkMarkers lists markers the card must all have, as stored (e.g. "Top", "Bottom-Left").
+	LinkMarkers []string
+
+	ScaleMin, ScaleMax *int
+}
+
+// Columns of the card stats across the subtype tables joined by applyCardQuery.
+const (
+	cardAtkColumn       = "COALESCE(monster_cards.atk, pendulum_monster_cards.atk, link_monster_cards.atk)"
+	cardDefColumn       = "COALESCE(monster_cards.def, pendulum_monster_cards.def)"
+	cardLevelColumn     = "COALESCE(monster_cards.level, pendulum_monster_cards.level)"
+	cardAttributeColumn = "COALESCE(monster_cards.attribute, pendulum_monster_cards.attribute, link_monster_cards.attribute)"
+	cardRaceColumn      = "COALESCE(monster_cards.race, pendulum_monster_cards.race, link_monster_cards.race, spell_trap_cards.type)"
+)
+
+// NameOnly reports whether the query searches by name and filters on nothing else.
+func (q CardQuery) NameOnly() bool {
+	return q.Name != "" && strings.TrimSpace(q.Text) == "" && q.Type == "" && q.FrameType == "" && q.Archetype == "" &&
+		!q.needsSubtypes()
+}
+
+// needsSubtypes reports whether the query filters on data stored in the subtype tables.
+func (q CardQuery) needsSubtypes() bool {
+	return q.AtkMin != nil || q.AtkMax != nil || q.DefMin != nil || q.DefMax != nil ||
+		q.LevelMin != nil || q.LevelMax != nil || q.Attribute != "" || q.Race != "" ||
+		q.LinkMin != nil || q.LinkMax != nil || len(q.LinkMarkers) > 0 ||
+		q.ScaleMin != nil || q.ScaleMax != nil
+}
+
+// applyCardQuery adds the conditions of query to a query on the cards table, joining the subtype tables
+// when needed. Card columns are qualified, so callers selecting cards must select "cards.*".
+func applyCardQuery(db *gorm.DB, query CardQuery) *gorm.DB {
+	if query.Name != "" {
+		db = db.Where("LOWER(cards.name) LIKE ?", "%"+strings.ToLower(query.Name)+"%")
+	}
+	if strings.TrimSpace(query.Text) != "" {
+		db = whereText(db, query.Text)
+	}
+	if query.Type != "" {
+		db = db.Where("cards.type = ?", query.Type)
+	}
+	if query.FrameType != "" {
+		db = db.Where("cards.frame_type = ?", query.FrameType)
+	}
+	if query.Archetype != "" {
+		db = db.Where("cards.archetype = ?", query.Archetype)
+	}
+
+	if !query.needsSubtypes() {
+		return db
+	}
+
+	db = joinCardSubtypes(db)
+
+	db = whereRange(db, cardAtkColumn, query.AtkMin, query.AtkMax)
+	db = whereRange(db, cardDefColumn, query.DefMin, query.DefMax)
+	db = whereRange(db, cardLevelColumn, query.LevelMin, query.LevelMax)
+	db = whereRange(db, "link_monster_cards.link_value", query.LinkMin, query.LinkMax)
+	db = whereRange(db, "pendulum_monster_cards.scale", query.ScaleMin, query.ScaleMax)
+
+	if query.Attribute != "" {
+		db = db.Where("LOWER("+cardAttributeColumn+") = ?", strings.ToLower(query.Attribute))
+	}
+	if query.Race != "" {
+		db = db.Where("LOWER("+cardRaceColumn+") = ?", strings.ToLower(query.Race))
+	}
+	for _, marker := range query.LinkMarkers {
+		// Markers are stored as a JSON array; matching the quoted name keeps "Top" from matching "Top-Left".
+		db = db.Where("CAST(link_monster_cards.link_markers AS TEXT) LIKE ?", `%"`+marker+`"%`)
+	}
+
+	return db
+}
+
+func whereRange(db *gorm.DB, column string, min, max *int) *gorm.DB {
+	if min != nil {
+		db = db.Where(column+" >= ?", *min)
+	}
+	if max != nil {
+		db = db.Where(column+" <= ?", *max)
+	}
+	return db
+}
@@ -1,9 +1,14 @@
 package repository
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CardRepository defines the interface for accessing and managing Card data in the database.
@@ -11,68 +16,121 @@ type CardRepository interface {
 	GetByID(id uint) (*models.Card, error)
 	GetByYGOProID(id int) (*models.Card, error)
 	GetByName(name string) (*models.Card, error)
-	GetAll(limit, offset int) ([]models.Card, error)
+	GetSimilarByName(name string, limit int) ([]models.Card, error)
+	GetAll(page Page) ([]models.Card, string, error)
 	CountAll() (int64, error)
-	GetFiltered(name, cardType, frameType string, limit, offset int) ([]models.Card, error)
-	CountFiltered(name, cardType, frameType string) (int64, error)
+	GetFiltered(query CardQuery, page Page) ([]models.Card, string, error)
+	GetWithoutDerivatives(afterID uint, limit int) ([]models.Card, error)
+	UpdateImages(id uint, imageURL, thumbnailURL, artURL string) error
+	CountFiltered(query CardQuery) (int64, error)
+	GetArchetypes(name string) ([]ArchetypeCount, error)
 	Create(card *models.Card) error
 	ExistsByYGOProID(id int) (bool, error)
 }
 
+// ArchetypeCount is an archetype and the number of cards that belong to it.
+type ArchetypeCount struct {
+	Archetype string
+	Cards     int64
+}
+
 type cardRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	names *cardNameIndex
 }
 
 func NewCardRepository() CardRepository {
 	return &cardRepository{
-		db: database.DB,
+		db:    database.DB,
+		names: &cardNameIndex{},
+	}
+}
+
+// NewCardRepositoryWithDB creates a new instance of cardRepository using the provided DB.
+func NewCardRepositoryWithDB(db *gorm.DB) CardRepository {
+	return &cardRepository{
+		db:    db,
+		names: &cardNameIndex{},
 	}
 }
 
-// GetByID retrieves a Card by its internal database ID, including all related subtypes.
+// GetByID retrieves a Card by its internal database ID, including all related subtypes and its printings.
 func (r *cardRepository) GetByID(id uint) (*models.Card, error) {
 	var card models.Card
 	err := r.db.Preload("MonsterCard").
 		Preload("SpellTrapCard").
 		Preload("LinkMonsterCard").
 		Preload("PendulumMonsterCard").
+		Preload("Printings", withoutUnspecifiedPrintings).
+		Preload("Printings.CardSet").
 		First(&card, "id = ?", id).Error
 
 	return &card, err
 }
 
-// GetByYGOProID retrieves a Card by its YGOProDeck ID, including all related subtypes.
+// GetByYGOProID retrieves a Card by its YGOProDeck ID, including all related subtypes and its printings.
 func (r *cardRepository) GetByYGOProID(id int) (*models.Card, error) {
 	var card models.Card
 	err := r.db.Preload("MonsterCard").
 		Preload("SpellTrapCard").
 		Preload("LinkMonsterCard").
 		Preload("PendulumMonsterCard").
+		Preload("Printings", withoutUnspecifiedPrintings).
+		Preload("Printings.CardSet").
 		First(&card, "card_ygo_id = ?", id).Error
 	return &card, err
 }
 
-// GetByName retrieves a Card by its exact name, case-insensitive
+// GetByName retrieves a Card by its exact name, case-insensitive, including its subtypes and printings.
 func (r *cardRepository) GetByName(name string) (*models.Card, error) {
 	var card models.Card
 	err := r.db.Preload("MonsterCard").
 		Preload("SpellTrapCard").
 		Preload("LinkMonsterCard").
 		Preload("PendulumMonsterCard").
+		Preload("Printings", withoutUnspecifiedPrintings).
+		Preload("Printings.CardSet").
 		First(&card, "name ILIKE ?", name).Error
 	return &card, err
 }
 
-// GetAll retrieves a paginated list of Cards, including their subtypes.
-func (r *cardRepository) GetAll(limit, offset int) ([]models.Card, error) {
-	var cards []models.Card
-	err := r.db.Preload("MonsterCard").
+// GetSimilarByName retrieves up to limit Cards whose name is close to the given one, closest first,
+// including their subtypes. PostgreSQL matches names by trigram similarity; other databases compare
+// normalized names by edit distance in memory.
+func (r *cardRepository) GetSimilarByName(name string, limit int) ([]models.Card, error) {
+	db := r.db.Preload("MonsterCard").
 		Preload("SpellTrapCard").
 		Preload("LinkMonsterCard").
-		Preload("PendulumMonsterCard").
-		Limit(limit).Offset(offset).
-		Find(&cards).Error
-	return cards, err
+		Preload("PendulumMonsterCard")
+
+	var cards []models.Card
+	if isPostgres(r.db) {
+		err := whereSimilarName(db, name).Limit(limit).Find(&cards).Error
+		return cards, err
+	}
+
+	ids, err := r.names.findSimilar(r.db, name, limit)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	if err := db.Find(&cards, ids).Error; err != nil {
+		return nil, err
+	}
+
+	// Find does not keep the order of the IDs.
+	rank := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		rank[id] = i
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return rank[cards[i].ID] < rank[cards[j].ID]
+	})
+	return cards, nil
+}
+
+// GetAll retrieves a page of Cards, including their subtypes, and the cursor of the next page.
+func (r *cardRepository) GetAll(page Page) ([]models.Card, string, error) {
+	return r.GetFiltered(CardQuery{}, page)
 }
 
 // CountAll returns the total number of Cards in the database.
@@ -82,50 +140,124 @@ func (r *cardRepository) CountAll() (int64, error) {
 	return count, err
 }
 
-// GetFiltered retrieves a paginated list of Cards matching the given filters (name, type, frameType).
-func (r *cardRepository) GetFiltered(name, cardType, frameType string, limit, offset int) ([]models.Card, error) {
-	query := r.db.Model(&models.Card{}).
+// GetFiltered retrieves a page of Cards matching the given search query, including their subtypes, and the
+// cursor of the next page, which is empty on the last page.
+// Cards are sorted by name unless the page selects another sort; full-text searches default to relevance.
+func (r *cardRepository) GetFiltered(query CardQuery, page Page) ([]models.Card, string, error) {
+	hasText := strings.TrimSpace(query.Text) != ""
+	defaultSort := CardSortName
+	if hasText {
+		defaultSort = CardSortRelevance
+	}
+
+	sort, desc, cursor, err := resolvePage(page, defaultSort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	db := applyCardQuery(r.db.Model(&models.Card{}), query).
+		Select("cards.*").
 		Preload("MonsterCard").
 		Preload("SpellTrapCard").
 		Preload("LinkMonsterCard").
 		Preload("PendulumMonsterCard")
+	limit := page.limit()
 
-	if name != "" {
-		query = query.Where("name ILIKE ?", "%"+name+"%")
+	if sort == CardSortRelevance {
+		if !hasText {
+			return nil, "", fmt.Errorf("%w: relevance requires a full-text search", ErrInvalidSort)
+		}
+		offset := page.Offset
+		if cursor != nil {
+			offset = cursor.Offset
+		}
+
+		var cards []models.Card
+		err := orderByTextRank(db, query.Text).
+			Limit(limit + 1).Offset(offset).
+			Find(&cards).Error
+		if err != nil || len(cards) <= limit {
+			return cards, "", err
+		}
+		return cards[:limit], encodeCursor(pageCursor{Sort: sort, Offset: offset + limit}), nil
+	}
+
+	cs, ok := cardListSorts[sort]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrInvalidSort, sort)
+	}
+	if cs.subtypes && !query.needsSubtypes() {
+		db = joinCardSubtypes(db)
 	}
-	if cardType != "" {
-		query = query.Where("type = ?", cardType)
+
+	var cards []models.Card
+	if err := cs.key.seek(db, desc, cursor, page.Offset).Limit(limit + 1).Find(&cards).Error; err != nil {
+		return nil, "", err
 	}
-	if frameType != "" {
-		query = query.Where("frame_type = ?", frameType)
+	if len(cards) <= limit {
+		return cards, "", nil
 	}
 
+	cards = cards[:limit]
+	last := &cards[limit-1]
+	num, text := cs.value(last)
+	return cards, cs.key.nextCursor(sort, desc, num, text, last.ID), nil
+}
+
+// GetWithoutDerivatives retrieves up to limit Cards with an ID above afterID that have no thumbnail or
+// cropped artwork yet, ordered by ID. Subtypes are not loaded.
+func (r *cardRepository) GetWithoutDerivatives(afterID uint, limit int) ([]models.Card, error) {
 	var cards []models.Card
-	err := query.Limit(limit).Offset(offset).Find(&cards).Error
+	err := r.db.Where("id > ?", afterID).
+		Where("thumbnail_url = '' OR thumbnail_url IS NULL OR art_url = '' OR art_url IS NULL").
+		Order("id").Limit(limit).
+		Find(&cards).Error
 	return cards, err
 }
 
-// CountFiltered returns the number of Cards that match the given filters.
-func (r *cardRepository) CountFiltered(name, cardType, frameType string) (int64, error) {
-	query := r.db.Model(&models.Card{})
-	if name != "" {
-		query = query.Where("name ILIKE ?", "%"+name+"%")
-	}
-	if cardType != "" {
-		query = query.Where("type = ?", cardType)
-	}
-	if frameType != "" {
-		query = query.Where("frame_type = ?", frameType)
-	}
+// UpdateImages sets the image URLs of a Card.
+func (r *cardRepository) UpdateImages(id uint, imageURL, thumbnailURL, artURL string) error {
+	return r.db.Model(&models.Card{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image_url":     imageURL,
+		"thumbnail_url": thumbnailURL,
+		"art_url":       artURL,
+	}).Error
+}
 
+// CountFiltered returns the number of Cards that match the given search query.
+func (r *cardRepository) CountFiltered(query CardQuery) (int64, error) {
 	var count int64
-	err := query.Count(&count).Error
+	err := applyCardQuery(r.db.Model(&models.Card{}), query).Count(&count).Error
 	return count, err
 }
 
-// Create saves a new Card and its associated subtype data into the database.
+// GetArchetypes returns the archetypes of the stored Cards whose name contains the given one, case-insensitive,
+// with their number of cards, sorted by name. An empty name returns every archetype.
+func (r *cardRepository) GetArchetypes(name string) ([]ArchetypeCount, error) {
+	db := r.db.Model(&models.Card{}).Where("archetype <> ''")
+	if name != "" {
+		db = db.Where("LOWER(archetype) LIKE ?", "%"+strings.ToLower(name)+"%")
+	}
+
+	var archetypes []ArchetypeCount
+	err := db.Select("archetype, COUNT(*) AS cards").Group("archetype").Order("archetype").Scan(&archetypes).Error
+	return archetypes, err
+}
+
+// Create saves a new Card and its associated subtype data and printings into the database.
+// If a card with the same YGOProDeck ID already exists, its catalog data is updated instead, keeping its
+// stored images, and card gets its ID. A card removed from the catalog by a prune is restored.
 func (r *cardRepository) Create(card *models.Card) error {
-	return r.db.Create(card).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Omit("Printings").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "card_ygo_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "desc", "frame_type", "type", "archetype", "updated_at", "deleted_at"}),
+		}).Create(card).Error
+		if err != nil {
+			return err
+		}
+		return saveCardPrintings(tx, card, map[string]uint{})
+	})
 }
 
 // ExistsByYGOProID checks if a Card with the given YGOProDeck ID already exists in the database.
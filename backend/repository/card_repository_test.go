@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cardRepository_CreateUpserts(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	repo := NewCardRepositoryWithDB(db)
+
+	first := &models.Card{CardYGOID: 83764718, Name: "Monster Reborn", ImageURL: "/images/cards/83764718.jpg"}
+	require.NoError(t, repo.Create(first))
+	card := &models.Card{CardYGOID: 83764718, Name: "Monster Reborn (updated)", ImageURL: "https://example.com/83764718.jpg"}
+	require.NoError(t, repo.Create(card))
+
+	var stored []models.Card
+	require.NoError(t, db.Find(&stored).Error)
+	require.Len(t, stored, 1)
+	assert.Equal(t, first.ID, card.ID)
+	assert.Equal(t, "Monster Reborn (updated)", stored[0].Name)
+	assert.Equal(t, "/images/cards/83764718.jpg", stored[0].ImageURL)
+
+	// A card removed by a catalog prune is restored when it is saved again.
+	require.NoError(t, db.Delete(&models.Card{}, first.ID).Error)
+	_, err := repo.GetByYGOProID(83764718)
+	require.Error(t, err)
+	require.NoError(t, repo.Create(&models.Card{CardYGOID: 83764718, Name: "Monster Reborn"}))
+	restored, err := repo.GetByYGOProID(83764718)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, restored.ID)
+}
+
+func Test_cardRepository_GetFiltered(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	utils.SeedTestData(db,
+		&models.Card{CardYGOID: 46986414, Name: "Dark Magician", Type: "Normal Monster", FrameType: "normal",
+			MonsterCard: &models.MonsterCard{Atk: 2500, Def: 2100, Level: 7, Attribute: "DARK", Race: "Spellcaster"}},
+		&models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", Type: "Tuner Monster", FrameType: "effect",
+			MonsterCard: &models.MonsterCard{Atk: 0, Def: 1800, Level: 3, Attribute: "FIRE", Race: "Zombie"}},
+		&models.Card{CardYGOID: 1861629, Name: "Decode Talker", Type: "Link Monster", FrameType: "link",
+			LinkMonsterCard: &models.LinkMonsterCard{LinkValue: 3, LinkMarkers: `["Top","Bottom-Left","Bottom-Right"]`, Atk: 2300, Attribute: "DARK", Race: "Cyberse"}},
+		&models.Card{CardYGOID: 16178681, Name: "Odd-Eyes Pendulum Dragon", Type: "Pendulum Effect Monster", FrameType: "effect_pendulum",
+			PendulumMonsterCard: &models.PendulumMonsterCard{Scale: 4, Atk: 2500, Def: 2000, Level: 7, Attribute: "DARK", Race: "Dragon"}},
+		&models.Card{CardYGOID: 83764718, Name: "Monster Reborn", Type: "Spell Card", FrameType: "spell",
+			SpellTrapCard: &models.SpellTrapCard{Type: "Normal"}},
+	)
+	repo := NewCardRepositoryWithDB(db)
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name  string
+		query CardQuery
+		want  []string
+	}{
+		{"name is case-insensitive", CardQuery{Name: "dark"}, []string{"Dark Magician"}},
+		{"atk range across subtypes", CardQuery{AtkMin: intPtr(2300), AtkMax: intPtr(2500)},
+			[]string{"Dark Magician", "Decode Talker", "Odd-Eyes Pendulum Dragon"}},
+		{"atk 0 is a value", CardQuery{AtkMax: intPtr(0)}, []string{"Ash Blossom & Joyous Spring"}},
+		{"link monsters have no def", CardQuery{DefMin: intPtr(0)},
+			[]string{"Dark Magician", "Ash Blossom & Joyous Spring", "Odd-Eyes Pendulum Dragon"}},
+		{"level and attribute", CardQuery{LevelMin: intPtr(7), Attribute: "dark"},
+			[]string{"Dark Magician", "Odd-Eyes Pendulum Dragon"}},
+		{"race", CardQuery{Race: "Cyberse"}, []string{"Decode Talker"}},
+		{"spell property", CardQuery{Race: "Normal", Type: "Spell Card"}, []string{"Monster Reborn"}},
+		{"link rating and markers", CardQuery{LinkMin: intPtr(3), LinkMarkers: []string{"Top", "Bottom-Left"}},
+			[]string{"Decode Talker"}},
+		{"marker names are matched whole", CardQuery{LinkMarkers: []string{"Left"}}, nil},
+		{"scale", CardQuery{ScaleMin: intPtr(4), ScaleMax: intPtr(4)}, []string{"Odd-Eyes Pendulum Dragon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, _, err := repo.GetFiltered(tt.query, Page{})
+			require.NoError(t, err)
+
+			var names []string
+			for _, card := range cards {
+				names = append(names, card.Name)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+
+			count, err := repo.CountFiltered(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+
+	cards, _, err := repo.GetFiltered(CardQuery{Race: "Cyberse"}, Page{})
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	require.NotNil(t, cards[0].LinkMonsterCard)
+	assert.NotZero(t, cards[0].ID)
+}
+
+func Test_cardRepository_Archetypes(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	utils.SeedTestData(db,
+		&models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon", Archetype: "Blue-Eyes"},
+		&models.Card{CardYGOID: 38517737, Name: "Blue-Eyes Alternative White Dragon", Archetype: "Blue-Eyes"},
+		&models.Card{CardYGOID: 46986414, Name: "Dark Magician", Archetype: "Dark Magician"},
+		&models.Card{CardYGOID: 12580477, Name: "Raigeki"},
+	)
+	repo := NewCardRepositoryWithDB(db)
+
+	cards, _, err := repo.GetFiltered(CardQuery{Archetype: "Blue-Eyes"}, Page{})
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, "Blue-Eyes Alternative White Dragon", cards[0].Name)
+	assert.Equal(t, "Blue-Eyes White Dragon", cards[1].Name)
+
+	archetypes, err := repo.GetArchetypes("")
+	require.NoError(t, err)
+	assert.Equal(t, []ArchetypeCount{
+		{Archetype: "Blue-Eyes", Cards: 2},
+		{Archetype: "Dark Magician", Cards: 1},
+	}, archetypes)
+
+	archetypes, err = repo.GetArchetypes("magic")
+	require.NoError(t, err)
+	assert.Equal(t, []ArchetypeCount{{Archetype: "Dark Magician", Cards: 1}}, archetypes)
+}
+
+func Test_cardRepository_GetFilteredText(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	utils.SeedTestData(db,
+		&models.Card{CardYGOID: 12580477, Name: "Raigeki", Type: "Spell Card", FrameType: "spell",
+			Desc: "Destroy all monsters your opponent controls."},
+		&models.Card{CardYGOID: 65681983, Name: "Crossout Designator", Type: "Spell Card", FrameType: "spell",
+			Desc: "Declare 1 card name; banish 1 of that declared card from your Deck."},
+		&models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", Type: "Tuner Monster", FrameType: "effect",
+			Desc: "When a card or effect is activated that includes any of these effects, discard this card; negate that effect."},
+		&models.Card{CardYGOID: 24224830, Name: "Called by the Grave", Type: "Spell Card", FrameType: "spell",
+			Desc: "Target 1 monster in your opponent's GY; banish it from the GY, and if you do, negate its effects."},
+		&models.Card{CardYGOID: 34267821, Name: "Banisher of the Radiance", Type: "Normal Monster", FrameType: "normal",
+			Desc: "Any card sent to the GY is banished instead."},
+	)
+	repo := NewCardRepositoryWithDB(db)
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"all words", "banish GY", []string{"Banisher of the Radiance", "Called by the Grave"}},
+		{"phrase", `"from the GY"`, []string{"Called by the Grave"}},
+		{"or", "destroy OR discard", []string{"Raigeki", "Ash Blossom & Joyous Spring"}},
+		{"exclusion", "banish -GY", []string{"Crossout Designator"}},
+		{"alternatives and exclusion", "negate -discard", []string{"Called by the Grave"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards, _, err := repo.GetFiltered(CardQuery{Text: tt.text}, Page{})
+			require.NoError(t, err)
+
+			var names []string
+			for _, card := range cards {
+				names = append(names, card.Name)
+			}
+			assert.ElementsMatch(t, tt.want, names)
+
+			count, err := repo.CountFiltered(CardQuery{Text: tt.text})
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+
+	// Matches in the name rank first.
+	cards, _, err := repo.GetFiltered(CardQuery{Text: "banish"}, Page{})
+	require.NoError(t, err)
+	require.Len(t, cards, 3)
+	assert.Equal(t, "Banisher of the Radiance", cards[0].Name)
+}
+
+func Test_cardRepository_GetFilteredPages(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	// Several cards share an ATK, and spells have none, so pages must break ties to stay stable.
+	utils.SeedTestData(db,
+		&models.Card{CardYGOID: 1, Name: "Blue-Eyes White Dragon", MonsterCard: &models.MonsterCard{Atk: 3000, Level: 8}},
+		&models.Card{CardYGOID: 2, Name: "Dark Magician", MonsterCard: &models.MonsterCard{Atk: 2500, Level: 7}},
+		&models.Card{CardYGOID: 3, Name: "Odd-Eyes Pendulum Dragon", PendulumMonsterCard: &models.PendulumMonsterCard{Atk: 2500, Level: 7}},
+		&models.Card{CardYGOID: 4, Name: "Decode Talker", LinkMonsterCard: &models.LinkMonsterCard{Atk: 2300, LinkValue: 3}},
+		&models.Card{CardYGOID: 5, Name: "Raigeki", Desc: "Destroy all monsters.", SpellTrapCard: &models.SpellTrapCard{Type: "Normal"}},
+		&models.Card{CardYGOID: 6, Name: "Dark Hole", Desc: "Destroy all monsters on the field.", SpellTrapCard: &models.SpellTrapCard{Type: "Normal"}},
+		&models.Card{CardYGOID: 7, Name: "Summoned Skull", MonsterCard: &models.MonsterCard{Atk: 2500, Level: 6}},
+	)
+	repo := NewCardRepositoryWithDB(db)
+
+	readAll := func(t *testing.T, query CardQuery, page Page) []string {
+		var names []string
+		for i := 0; i < 10; i++ {
+			cards, next, err := repo.GetFiltered(query, page)
+			require.NoError(t, err)
+			for _, card := range cards {
+				names = append(names, card.Name)
+			}
+			if next == "" {
+				return names
+			}
+			page = Page{Limit: page.Limit, Cursor: next}
+		}
+		t.Fatal("too many pages")
+		return nil
+	}
+
+	t.Run("name", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"Blue-Eyes White Dragon", "Dark Hole", "Dark Magician", "Decode Talker", "Odd-Eyes Pendulum Dragon", "Raigeki", "Summoned Skull",
+		}, readAll(t, CardQuery{}, Page{Limit: 2}))
+	})
+
+	t.Run("atk descending with ties", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"Blue-Eyes White Dragon", "Summoned Skull", "Odd-Eyes Pendulum Dragon", "Dark Magician", "Decode Talker", "Dark Hole", "Raigeki",
+		}, readAll(t, CardQuery{}, Page{Sort: CardSortAtk, Desc: true, Limit: 2}))
+	})
+
+	t.Run("filtered by level", func(t *testing.T) {
+		seven := 7
+		assert.Equal(t, []string{"Dark Magician", "Odd-Eyes Pendulum Dragon", "Blue-Eyes White Dragon"},
+			readAll(t, CardQuery{LevelMin: &seven}, Page{Sort: CardSortLevel, Limit: 1}))
+	})
+
+	t.Run("relevance", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"Raigeki", "Dark Hole"},
+			readAll(t, CardQuery{Text: "destroy"}, Page{Limit: 1}))
+	})
+
+	t.Run("offset pages still return a cursor", func(t *testing.T) {
+		cards, next, err := repo.GetFiltered(CardQuery{}, Page{Limit: 2, Offset: 4})
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+		assert.Equal(t, "Odd-Eyes Pendulum Dragon", cards[0].Name)
+
+		cards, next, err = repo.GetFiltered(CardQuery{}, Page{Limit: 2, Cursor: next})
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+		assert.Equal(t, "Summoned Skull", cards[0].Name)
+		assert.Empty(t, next)
+	})
+
+	t.Run("invalid sort and cursor", func(t *testing.T) {
+		_, _, err := repo.GetFiltered(CardQuery{}, Page{Sort: "price"})
+		assert.ErrorIs(t, err, ErrInvalidSort)
+
+		_, _, err = repo.GetFiltered(CardQuery{}, Page{Sort: CardSortRelevance})
+		assert.ErrorIs(t, err, ErrInvalidSort)
+
+		_, _, err = repo.GetFiltered(CardQuery{}, Page{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, next, err := repo.GetFiltered(CardQuery{}, Page{Limit: 2})
+		require.NoError(t, err)
+		_, _, err = repo.GetFiltered(CardQuery{}, Page{Sort: CardSortAtk, Cursor: next})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+// Sort keys of card listings.
+const (
+	CardSortName      = "name"
+	CardSortAtk       = "atk"
+	CardSortDef       = "def"
+	CardSortLevel     = "level"
+	CardSortAdded     = "added"
+	CardSortRelevance = "relevance" // full-text searches only
+)
+
+// cardSort is a sort key of card listings, and how to read its value from a loaded card.
+// Cards without the stat, such as spells when sorting by ATK, sort as -1.
+type cardSort struct {
+	key      sortKey
+	subtypes bool
+	value    func(card *models.Card) (int64, string)
+}
+
+func cardSorts(idColumn string) map[string]cardSort {
+	return map[string]cardSort{
+		CardSortName: {
+			key:   sortKey{column: "COALESCE(cards.name, '')", idColumn: idColumn},
+			value: func(card *models.Card) (int64, string) { return 0, card.Name },
+		},
+		CardSortAtk: {
+			key:      sortKey{column: "COALESCE(" + cardAtkColumn + ", -1)", idColumn: idColumn, numeric: true},
+			subtypes: true,
+			value:    cardAtk,
+		},
+		CardSortDef: {
+			key:      sortKey{column: "COALESCE(" + cardDefColumn + ", -1)", idColumn: idColumn, numeric: true},
+			subtypes: true,
+			value:    cardDef,
+		},
+		CardSortLevel: {
+			key:      sortKey{column: "COALESCE(" + cardLevelColumn + ", -1)", idColumn: idColumn, numeric: true},
+			subtypes: true,
+			value:    cardLevel,
+		},
+		CardSortAdded: {
+			key:   sortKey{column: "cards.id", idColumn: idColumn, numeric: true},
+			value: func(card *models.Card) (int64, string) { return int64(card.ID), "" },
+		},
+	}
+}
+
+var cardListSorts = cardSorts("cards.id")
+
+// The stat functions read the same subtype fields as cardAtkColumn, cardDefColumn and cardLevelColumn.
+
+func cardAtk(card *models.Card) (int64, string) {
+	switch {
+	case card.MonsterCard != nil:
+		return int64(card.MonsterCard.Atk), ""
+	case card.PendulumMonsterCard != nil:
+		return int64(card.PendulumMonsterCard.Atk), ""
+	case card.LinkMonsterCard != nil:
+		return int64(card.LinkMonsterCard.Atk), ""
+	}
+	return -1, ""
+}
+
+func cardDef(card *models.Card) (int64, string) {
+	switch {
+	case card.MonsterCard != nil:
+		return int64(card.MonsterCard.Def), ""
+	case card.PendulumMonsterCard != nil:
+		return int64(card.PendulumMonsterCard.Def), ""
+	}
+	return -1, ""
+}
+
+func cardLevel(card *models.Card) (int64, string) {
+	switch {
+	case card.MonsterCard != nil:
+		return int64(card.MonsterCard.Level), ""
+	case card.PendulumMonsterCard != nil:
+		return int64(card.PendulumMonsterCard.Level), ""
+	}
+	return -1, ""
+}
+
+// joinCardSubtypes joins the subtype tables to a query on cards.
+func joinCardSubtypes(db *gorm.DB) *gorm.DB {
+	return db.
+		Joins("LEFT JOIN monster_cards ON monster_cards.card_id = cards.id").
+		Joins("LEFT JOIN pendulum_monster_cards ON pendulum_monster_cards.card_id = cards.id").
+		Joins("LEFT JOIN link_monster_cards ON link_monster_cards.card_id = cards.id").
+		Joins("LEFT JOIN spell_trap_cards ON spell_trap_cards.card_id = cards.id")
+}
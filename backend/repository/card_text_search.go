@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// textSearchConfig is the PostgreSQL text search configuration of the cards.search_vector column.
+const textSearchConfig = "english"
+
+// cardTextColumn is what the portable fallback searches: the name and effect text of a card.
+const cardTextColumn = `LOWER(COALESCE(cards.name, '') || ' ' || COALESCE(cards."desc", ''))`
+
+// searchTerm is a word or quoted phrase of a text search. Negated terms must not appear.
+type searchTerm struct {
+	text    string
+	negated bool
+}
+
+// parseSearchText splits a text search the way PostgreSQL's websearch_to_tsquery does: terms are ANDed,
+// "OR" between two terms makes them alternatives, quotes group a phrase and a leading "-" excludes a term.
+// Each returned group holds alternatives, and every group must match.
+func parseSearchText(text string) [][]searchTerm {
+	var groups [][]searchTerm
+	pendingOr := false
+
+	for rest := strings.TrimSpace(text); rest != ""; rest = strings.TrimSpace(rest) {
+		negated := false
+		if rest[0] == '-' {
+			negated = true
+			rest = rest[1:]
+		}
+
+		var word string
+		quoted := false
+		if strings.HasPrefix(rest, `"`) {
+			quoted = true
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				word, rest = rest[1:], ""
+			} else {
+				word, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.IndexAny(rest, " \t\n"); end >= 0 {
+			word, rest = rest[:end], rest[end:]
+		} else {
+			word, rest = rest, ""
+		}
+
+		if !quoted && !negated && strings.EqualFold(word, "or") && len(groups) > 0 {
+			pendingOr = true
+			continue
+		}
+		word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+		if word == "" {
+			continue
+		}
+
+		term := searchTerm{text: word, negated: negated}
+		if pendingOr {
+			last := len(groups) - 1
+			groups[last] = append(groups[last], term)
+			pendingOr = false
+			continue
+		}
+		groups = append(groups, []searchTerm{term})
+	}
+
+	return groups
+}
+
+// whereText adds a full-text condition on the name and effect text. PostgreSQL uses the indexed
+// search_vector column, other databases a case-insensitive substring match of every term.
+func whereText(db *gorm.DB, text string) *gorm.DB {
+	if isPostgres(db) {
+		return db.Where("cards.search_vector @@ websearch_to_tsquery('"+textSearchConfig+"', ?)", text)
+	}
+
+	for _, group := range parseSearchText(text) {
+		var alternatives []string
+		var vars []interface{}
+		for _, term := range group {
+			if term.negated {
+				alternatives = append(alternatives, cardTextColumn+" NOT LIKE ?")
+			} else {
+				alternatives = append(alternatives, cardTextColumn+" LIKE ?")
+			}
+			vars = append(vars, "%"+term.text+"%")
+		}
+		db = db.Where("("+strings.Join(alternatives, " OR ")+")", vars...)
+	}
+	return db
+}
+
+// orderByTextRank sorts the results of a full-text search by relevance, with matches in the name first, and
+// then by ID. The fallback ranks cards by the number of terms found in their name.
+// gorm drops an order expression when another order is added, so the ID is part of the expression.
+func orderByTextRank(db *gorm.DB, text string) *gorm.DB {
+	if isPostgres(db) {
+		return db.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(cards.search_vector, websearch_to_tsquery('" + textSearchConfig + "', ?)) DESC, cards.id",
+			Vars: []interface{}{text},
+		}})
+	}
+
+	var ranks []string
+	var vars []interface{}
+	for _, group := range parseSearchText(text) {
+		for _, term := range group {
+			if !term.negated {
+				ranks = append(ranks, "CASE WHEN LOWER(cards.name) LIKE ? THEN 1 ELSE 0 END")
+				vars = append(vars, "%"+term.text+"%")
+			}
+		}
+	}
+	if len(ranks) == 0 {
+		return db.Order("cards.id")
+	}
+	return db.Order(clause.OrderBy{Expression: clause.Expr{SQL: "(" + strings.Join(ranks, " + ") + ") DESC, cards.id", Vars: vars}})
+}
+
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+// CatalogRepository defines the bulk operations used to synchronise the card catalog.
+type CatalogRepository interface {
+	GetCatalog() ([]models.Card, error)
+	ApplySync(added, changed []*models.Card, removed []uint) error
+}
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+// NewCatalogRepository creates a new instance of catalogRepository using the default DB.
+func NewCatalogRepository() CatalogRepository {
+	return &catalogRepository{
+		db: database.DB,
+	}
+}
+
+// NewCatalogRepositoryWithDB creates a new instance of catalogRepository using the provided DB.
+func NewCatalogRepositoryWithDB(db *gorm.DB) CatalogRepository {
+	return &catalogRepository{
+		db: db,
+	}
+}
+
+// GetCatalog returns every card with its subtype data and printings, including the cards removed by a
+// previous sync.
+func (r *catalogRepository) GetCatalog() ([]models.Card, error) {
+	var cards []models.Card
+	err := r.db.Unscoped().Preload("MonsterCard").
+		Preload("SpellTrapCard").
+		Preload("LinkMonsterCard").
+		Preload("PendulumMonsterCard").
+		Preload("Printings", withoutUnspecifiedPrintings).
+		Preload("Printings.CardSet").
+		Find(&cards).Error
+	return cards, err
+}
+
+// ApplySync creates the added cards, overwrites the changed ones and their subtype data, restoring them
+// if they had been removed, and soft-deletes the removed ones, all in a single transaction.
+// The printings of added and changed cards are saved too.
+// Soft deletion keeps collections and decks referencing removed cards intact.
+func (r *catalogRepository) ApplySync(added, changed []*models.Card, removed []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		setIDs := map[string]uint{}
+		if len(added) > 0 {
+			if err := tx.Omit("Printings").CreateInBatches(added, 500).Error; err != nil {
+				return err
+			}
+			for _, card := range added {
+				if err := saveCardPrintings(tx, card, setIDs); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, card := range changed {
+			err := tx.Unscoped().Model(&models.Card{}).Where("id = ?", card.ID).Updates(map[string]interface{}{
+				"name":       card.Name,
+				"desc":       card.Desc,
+				"frame_type": card.FrameType,
+				"type":       card.Type,
+				"archetype":  card.Archetype,
+				"deleted_at": nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			if err := replaceCardSubtype(tx, card); err != nil {
+				return err
+			}
+			if err := saveCardPrintings(tx, card, setIDs); err != nil {
+				return err
+			}
+		}
+
+		if len(removed) > 0 {
+			if err := tx.Delete(&models.Card{}, removed).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// replaceCardSubtype deletes the subtype rows of a card and stores the subtype it now has.
+func replaceCardSubtype(tx *gorm.DB, card *models.Card) error {
+	for _, subtype := range []interface{}{&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}} {
+		if err := tx.Where("card_id = ?", card.ID).Delete(subtype).Error; err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case card.MonsterCard != nil:
+		card.MonsterCard.CardID = card.ID
+		return tx.Create(card.MonsterCard).Error
+	case card.SpellTrapCard != nil:
+		card.SpellTrapCard.CardID = card.ID
+		return tx.Create(card.SpellTrapCard).Error
+	case card.LinkMonsterCard != nil:
+		card.LinkMonsterCard.CardID = card.ID
+		return tx.Create(card.LinkMonsterCard).Error
+	case card.PendulumMonsterCard != nil:
+		card.PendulumMonsterCard.CardID = card.ID
+		return tx.Create(card.PendulumMonsterCard).Error
+	}
+	return nil
+}
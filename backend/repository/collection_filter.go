@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidGroup = errors.New("invalid group")
+
+// CollectionFilter narrows a collection to the entries whose copies have the given attributes. Empty
+// attributes are not filtered on. A LocationID keeps the entries with copies stored in that location, and
+// Unassigned those with copies not stored anywhere.
+type CollectionFilter struct {
+	models.CopyAttributes
+	LocationID uint
+	Unassigned bool
+}
+
+// applyCollectionFilter adds the conditions of filter to a query on the user_cards table.
+func applyCollectionFilter(db *gorm.DB, filter CollectionFilter) *gorm.DB {
+	if filter.LocationID != 0 {
+		db = db.Where("EXISTS (SELECT 1 FROM stored_cards WHERE "+storedCardOfEntry+" AND stored_cards.location_id = ?)",
+			filter.LocationID)
+	}
+	if filter.Unassigned {
+		db = db.Where(unstoredQuantity + " > 0")
+	}
+	return whereCopyAttributes(db, filter.CopyAttributes)
+}
+
+// whereCopyAttributes keeps the entries whose copies have the given attributes, ignoring empty ones.
+func whereCopyAttributes(db *gorm.DB, attrs models.CopyAttributes) *gorm.DB {
+	for column, value := range map[string]string{
+		"user_cards.condition": attrs.Condition,
+		"user_cards.edition":   attrs.Edition,
+		"user_cards.language":  attrs.Language,
+		"user_cards.finish":    attrs.Finish,
+	} {
+		if value != "" {
+			db = db.Where(column+" = ?", value)
+		}
+	}
+	return db
+}
+
+// whereUserCard selects a single collection entry by its whole key. gorm leaves empty strings out of the
+// primary key conditions it builds, which would match the entries with other attributes too.
+func whereUserCard(db *gorm.DB, userCard *models.UserCard) *gorm.DB {
+	return db.Where("user_id = ? AND printing_id = ? AND condition = ? AND edition = ? AND language = ? AND finish = ?",
+		userCard.UserID, userCard.PrintingID, userCard.Condition, userCard.Edition, userCard.Language, userCard.Finish)
+}
+
+// Groups a collection can be summarized by.
+const (
+	CollectionGroupCondition = "condition"
+	CollectionGroupEdition   = "edition"
+	CollectionGroupLanguage  = "language"
+	CollectionGroupFinish    = "finish"
+	CollectionGroupRarity    = "rarity"
+	CollectionGroupSet       = "set"
+)
+
+// collectionGroups are the columns of each group, on user_cards joined with card_printings and card_sets.
+var collectionGroups = map[string]string{
+	CollectionGroupCondition: "user_cards.condition",
+	CollectionGroupEdition:   "user_cards.edition",
+	CollectionGroupLanguage:  "user_cards.language",
+	CollectionGroupFinish:    "user_cards.finish",
+	CollectionGroupRarity:    "card_printings.rarity",
+	CollectionGroupSet:       "COALESCE(card_sets.name, '')",
+}
+
+// CollectionGroup sums up the entries of a collection sharing a value of the grouped attribute. An empty
+// value groups the copies where it is unspecified.
+type CollectionGroup struct {
+	Value    string
+	Cards    int64 // distinct cards
+	Quantity int64 // copies
+}
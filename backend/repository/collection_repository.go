@@ -7,13 +7,23 @@ import (
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrCardReserved = errors.New("copies are reserved by physical decks")
+
 type CollectionRepository interface {
-	GetUserCollection(userID uint) ([]models.UserCard, error)
+	GetUserCollection(userID uint, filter CollectionFilter) ([]models.UserCard, error)
+	GetUserCollectionPage(userID uint, filter CollectionFilter, page Page) ([]models.UserCard, string, error)
+	GroupUserCollection(userID uint, filter CollectionFilter, group string) ([]CollectionGroup, error)
 	GetUserCard(userID, cardID uint) (*models.UserCard, error)
-	AddCardToCollection(userID uint, cardID uint, quantity int) error
-	DecreaseCardQuantity(userID, cardID uint, quantityToRemove int) error
+	GetUserCardPrintings(userID, cardID uint) ([]models.UserCard, error)
+	GetPrinting(printingID uint) (*models.CardPrinting, error)
+	AddCardToCollection(userID, cardID, printingID uint, attrs models.CopyAttributes, quantity int) error
+	DecreaseCardQuantity(userID, cardID, printingID uint, attrs models.CopyAttributes, quantityToRemove int) error
+	GetReservations(userID, cardID uint) ([]models.DeckCard, error)
+	GetReservedQuantity(userID, cardID uint) (int, error)
+	GetOwnedQuantity(userID, cardID uint) (int, error)
 }
 
 type collectionRepository struct {
@@ -32,75 +42,299 @@ func NewCollectionRepositoryWithDB(db *gorm.DB) CollectionRepository {
 	}
 }
 
-func (r *collectionRepository) GetUserCollection(userID uint) ([]models.UserCard, error) {
+// GetUserCollection returns the entries of a user's collection matching the filter, one per printing and
+// copy attributes held.
+func (r *collectionRepository) GetUserCollection(userID uint, filter CollectionFilter) ([]models.UserCard, error) {
 	var userCards []models.UserCard
 
-	err := r.db.
+	err := applyCollectionFilter(r.db, filter).
 		Preload("Card").
 		Preload("Card.MonsterCard").
 		Preload("Card.SpellTrapCard").
 		Preload("Card.LinkMonsterCard").
 		Preload("Card.PendulumMonsterCard").
+		Preload("Printing.CardSet").
 		Where("user_id = ?", userID).
 		Find(&userCards).Error
 
 	return userCards, err
 }
 
+// collectionSorts are the sort keys of collections: those of cards, except the order cards were added to the catalog.
+var collectionSorts = func() map[string]cardSort {
+	sorts := cardSorts("user_cards.printing_id")
+	delete(sorts, CardSortAdded)
+	return sorts
+}()
+
+// GetUserCollectionPage retrieves a page of the entries of a user's collection matching the filter, sorted
+// by card name unless the page selects another card sort, and the cursor of the next page, which is empty on
+// the last page.
+// Entries of the same printing, which differ only in their copy attributes, are listed together, so a page
+// can hold more entries than its limit.
+func (r *collectionRepository) GetUserCollectionPage(userID uint, filter CollectionFilter, page Page) ([]models.UserCard, string, error) {
+	sort, desc, cursor, err := resolvePage(page, CardSortName)
+	if err != nil {
+		return nil, "", err
+	}
+	cs, ok := collectionSorts[sort]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrInvalidSort, sort)
+	}
+
+	// Cards removed from the catalog are still listed, so the loaded cards must match the sorted ones.
+	db := applyCollectionFilter(r.db.Model(&models.UserCard{}), filter).
+		Select("user_cards.*").
+		Joins("JOIN cards ON cards.id = user_cards.card_id").
+		Where("user_cards.user_id = ?", userID).
+		Preload("Card", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Card.MonsterCard").
+		Preload("Card.SpellTrapCard").
+		Preload("Card.LinkMonsterCard").
+		Preload("Card.PendulumMonsterCard").
+		Preload("Printing.CardSet")
+	if cs.subtypes {
+		db = joinCardSubtypes(db)
+	}
+	// db is reused for the rest of the last printing.
+	db = db.Session(&gorm.Session{})
+
+	limit := page.limit()
+	var userCards []models.UserCard
+	err = cs.key.seek(db, desc, cursor, page.Offset).Order(copyAttributesOrder).Limit(limit + 1).Find(&userCards).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if len(userCards) <= limit {
+		return userCards, "", nil
+	}
+
+	// The next page starts after the last printing, so its entries cut off by the limit are added.
+	if userCards[limit].PrintingID == userCards[limit-1].PrintingID {
+		printingID := userCards[limit-1].PrintingID
+		var rest []models.UserCard
+		err := db.Where("user_cards.printing_id = ?", printingID).Order(copyAttributesOrder).Find(&rest).Error
+		if err != nil {
+			return nil, "", err
+		}
+
+		userCards = userCards[:limit]
+		for len(userCards) > 0 && userCards[len(userCards)-1].PrintingID == printingID {
+			userCards = userCards[:len(userCards)-1]
+		}
+		userCards = append(userCards, rest...)
+	} else {
+		userCards = userCards[:limit]
+	}
+
+	last := &userCards[len(userCards)-1]
+	num, text := cs.value(&last.Card)
+	return userCards, cs.key.nextCursor(sort, desc, num, text, last.PrintingID), nil
+}
+
+// copyAttributesOrder sorts the entries of a printing.
+const copyAttributesOrder = "user_cards.condition, user_cards.edition, user_cards.language, user_cards.finish"
+
+// GroupUserCollection sums up the entries of a user's collection matching the filter by the given group,
+// sorted by value.
+func (r *collectionRepository) GroupUserCollection(userID uint, filter CollectionFilter, group string) ([]CollectionGroup, error) {
+	column, ok := collectionGroups[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroup, group)
+	}
+
+	var groups []CollectionGroup
+	err := applyCollectionFilter(r.db.Model(&models.UserCard{}), filter).
+		Select(column+" AS value, COUNT(DISTINCT user_cards.card_id) AS cards, SUM(user_cards.quantity) AS quantity").
+		Joins("JOIN card_printings ON card_printings.id = user_cards.printing_id").
+		Joins("LEFT JOIN card_sets ON card_sets.id = card_printings.card_set_id").
+		Where("user_cards.user_id = ?", userID).
+		Group(column).
+		Order(column).
+		Scan(&groups).Error
+	return groups, err
+}
+
+// GetUserCard returns the copies of a card in a user's collection, across all its printings, as a single
+// entry without a printing. It fails with gorm.ErrRecordNotFound when the user holds no copies.
 func (r *collectionRepository) GetUserCard(userID, cardID uint) (*models.UserCard, error) {
 	var userCard models.UserCard
-	err := r.db.Preload("Card").
+	err := r.db.Model(&models.UserCard{}).
+		Select("user_id, card_id, SUM(quantity) AS quantity").
+		Where("user_id = ? AND card_id = ?", userID, cardID).
+		Group("user_id, card_id").
+		Preload("Card").
 		Preload("Card.MonsterCard").
 		Preload("Card.SpellTrapCard").
 		Preload("Card.LinkMonsterCard").
 		Preload("Card.PendulumMonsterCard").
 		Preload("User").
-		First(&userCard, "user_id = ? AND card_id = ?", userID, cardID).Error
+		Take(&userCard).Error
 
 	return &userCard, err
 }
 
-func (r *collectionRepository) AddCardToCollection(userID uint, cardID uint, quantity int) error {
-	var userCard models.UserCard
+// GetUserCardPrintings returns the entries of a user's collection holding a card, one per printing and copy
+// attributes held, with the unspecified printing first.
+func (r *collectionRepository) GetUserCardPrintings(userID, cardID uint) ([]models.UserCard, error) {
+	var userCards []models.UserCard
+	err := r.db.Preload("Printing.CardSet").
+		Joins("JOIN card_printings ON card_printings.id = user_cards.printing_id").
+		Where("user_cards.user_id = ? AND user_cards.card_id = ?", userID, cardID).
+		Order("card_printings.set_code, card_printings.rarity, card_printings.id, " + copyAttributesOrder).
+		Find(&userCards).Error
+	return userCards, err
+}
 
-	err := r.db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&userCard).Error
-	if err == nil {
-		userCard.Quantity += quantity
-		return r.db.Save(&userCard).Error
-	}
+// GetPrinting returns a card printing with its set.
+func (r *collectionRepository) GetPrinting(printingID uint) (*models.CardPrinting, error) {
+	var printing models.CardPrinting
+	err := r.db.Preload("CardSet").First(&printing, printingID).Error
+	return &printing, err
+}
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		newUserCard := models.UserCard{
-			UserID:   userID,
-			CardID:   cardID,
-			Quantity: quantity,
+// AddCardToCollection adds copies of a card printing with the given attributes to a user's collection.
+// A zero printingID adds them to the unspecified printing of the card.
+func (r *collectionRepository) AddCardToCollection(userID, cardID, printingID uint, attrs models.CopyAttributes, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if printingID == 0 {
+			printing, err := unspecifiedPrinting(tx, cardID)
+			if err != nil {
+				return err
+			}
+			printingID = printing.ID
 		}
-		return r.db.Create(&newUserCard).Error
-	}
 
-	return err
+		// Locking the entry keeps concurrent removals and moves of its copies from being overwritten.
+		db := tx
+		if isPostgres(tx) {
+			db = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		userCard := models.UserCard{
+			UserID:         userID,
+			PrintingID:     printingID,
+			CopyAttributes: attrs,
+			CardID:         cardID,
+		}
+		err := whereUserCard(db, &userCard).First(&userCard).Error
+		if err == nil {
+			return whereUserCard(tx.Model(&models.UserCard{}), &userCard).
+				Update("quantity", userCard.Quantity+quantity).Error
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			userCard.Quantity = quantity
+			return tx.Create(&userCard).Error
+		}
+
+		return err
+	})
 }
 
 func (r *collectionRepository) RemoveCard(userID, cardID uint) error {
-	var userCard models.UserCard
-	if err := r.db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&userCard).Error; err != nil {
-		return fmt.Errorf("user card not found: %w", err)
-	}
-	return r.db.Delete(&userCard).Error
+	return r.db.Where("user_id = ? AND card_id = ?", userID, cardID).Delete(&models.UserCard{}).Error
 }
 
-func (r *collectionRepository) DecreaseCardQuantity(userID, cardID uint, quantityToRemove int) error {
-	var userCard models.UserCard
+// DecreaseCardQuantity removes copies of a card from a user's collection, deleting the entries left without
+// copies. A zero printingID takes them from any printing of the card, the unspecified one first; otherwise
+// only the given printing is decreased. Likewise, only copies with the given attributes are removed, and
+// empty attributes match any value, unspecified first. Removing more copies than held removes them all.
+// Unassigned copies are removed first, then stored ones, see trimStoredCards. Returns ErrCardReserved when
+// fewer copies than physical decks hold would be left.
+func (r *collectionRepository) DecreaseCardQuantity(userID, cardID, printingID uint, attrs models.CopyAttributes, quantityToRemove int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking every entry of the card keeps concurrent removals and moves of its copies from overdrawing
+		// it, as MoveCopies takes the same lock, and from leaving fewer copies than are reserved.
+		lock := tx
+		if isPostgres(tx) {
+			lock = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var entries []models.UserCard
+		if err := lock.Where("user_id = ? AND card_id = ?", userID, cardID).Find(&entries).Error; err != nil {
+			return err
+		}
 
-	err := r.db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&userCard).Error
-	if err != nil {
-		return fmt.Errorf("card not found in collection: %w", err)
-	}
+		reserved, err := (&collectionRepository{db: tx}).GetReservedQuantity(userID, cardID)
+		if err != nil {
+			return err
+		}
+		owned := 0
+		for _, entry := range entries {
+			owned += entry.Quantity
+		}
+		if reserved > 0 && owned-quantityToRemove < reserved {
+			return ErrCardReserved
+		}
 
-	if userCard.Quantity > quantityToRemove {
-		userCard.Quantity -= quantityToRemove
-		return r.db.Save(&userCard).Error
-	}
+		db := tx.Joins("JOIN card_printings ON card_printings.id = user_cards.printing_id").
+			Where("user_cards.user_id = ? AND user_cards.card_id = ?", userID, cardID)
+		if printingID != 0 {
+			db = db.Where("user_cards.printing_id = ?", printingID)
+		}
+		db = whereCopyAttributes(db, attrs)
+
+		var userCards []models.UserCard
+		err = db.Order("card_printings.set_code <> '' OR card_printings.rarity <> '', user_cards.printing_id").
+			Order(copyAttributesOrder).
+			Find(&userCards).Error
+		if err != nil {
+			return err
+		}
+		if len(userCards) == 0 {
+			return fmt.Errorf("card not found in collection: %w", gorm.ErrRecordNotFound)
+		}
+
+		for _, userCard := range userCards {
+			if quantityToRemove <= 0 {
+				break
+			}
+			if userCard.Quantity > quantityToRemove {
+				left := userCard.Quantity - quantityToRemove
+				if err := trimStoredCards(tx, &userCard, left); err != nil {
+					return err
+				}
+				return whereUserCard(tx.Model(&models.UserCard{}), &userCard).Update("quantity", left).Error
+			}
+
+			quantityToRemove -= userCard.Quantity
+			if err := trimStoredCards(tx, &userCard, 0); err != nil {
+				return err
+			}
+			if err := whereUserCard(tx, &userCard).Delete(&models.UserCard{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetReservations returns the entries of the user's physical decks holding the card, with their deck.
+func (r *collectionRepository) GetReservations(userID, cardID uint) ([]models.DeckCard, error) {
+	var deckCards []models.DeckCard
+	err := r.db.Preload("Deck").
+		Joins("JOIN decks ON decks.id = deck_cards.deck_id").
+		Where("decks.user_id = ? AND decks.physical = ? AND deck_cards.card_id = ?", userID, true, cardID).
+		Find(&deckCards).Error
+	return deckCards, err
+}
+
+// GetOwnedQuantity returns how many copies of the card the user owns, 0 when the card is not in the collection.
+func (r *collectionRepository) GetOwnedQuantity(userID, cardID uint) (int, error) {
+	var total int
+	err := r.db.Model(&models.UserCard{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("user_id = ? AND card_id = ?", userID, cardID).
+		Scan(&total).Error
+	return total, err
+}
 
-	return r.db.Delete(&userCard).Error
+// GetReservedQuantity returns how many copies of the card the user's physical decks hold.
+func (r *collectionRepository) GetReservedQuantity(userID, cardID uint) (int, error) {
+	var total int
+	err := r.db.Model(&models.DeckCard{}).
+		Select("COALESCE(SUM(deck_cards.quantity), 0)").
+		Joins("JOIN decks ON decks.id = deck_cards.deck_id").
+		Where("decks.user_id = ? AND decks.physical = ? AND deck_cards.card_id = ?", userID, true, cardID).
+		Scan(&total).Error
+	return total, err
 }
@@ -8,14 +8,18 @@ import (
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DeckCardRepository defines the interface for operations on deck-card relations.
 type DeckCardRepository interface {
 	AddCardToDeck(deckID, cardID uint, quantity int, zone string) error
-	GetDeckCard(deckID, cardID uint) (*models.DeckCard, error)
+	AddCardsToDeck(deckID uint, entries []models.DeckCard, check func(DeckCardRepository) error) error
+	GetDeckCard(deckID, cardID uint, zone string) (*models.DeckCard, error)
+	GetDeckCardZones(deckID, cardID uint) ([]models.DeckCard, error)
 	UpdateDeckCardQuantity(card *models.DeckCard) error
 	DeleteDeckCard(card *models.DeckCard) error
+	MoveDeckCard(deckID, cardID uint, fromZone, toZone string, quantity int) error
 	GetTotalCardsInZone(deckID uint, zone string) (int, error)
 	GetCardQuantityInDeck(deckID, cardID uint) (int, error)
 }
@@ -31,9 +35,15 @@ func NewDeckCardRepository() DeckCardRepository {
 	}
 }
 
-// AddCardToDeck adds a card to a deck, or updates the quantity if the card already exists.
+func NewDeckCardRepositoryWithDB(db *gorm.DB) DeckCardRepository {
+	return &deckCardRepository{
+		db: db,
+	}
+}
+
+// AddCardToDeck adds a card to a deck zone, or updates the quantity if the card is already in that zone.
 func (r *deckCardRepository) AddCardToDeck(deckID, cardID uint, quantity int, zone string) error {
-	existing, err := r.GetDeckCard(deckID, cardID)
+	existing, err := r.GetDeckCard(deckID, cardID, zone)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return fmt.Errorf("error querying existing deck card: %w", err)
 	}
@@ -52,10 +62,50 @@ func (r *deckCardRepository) AddCardToDeck(deckID, cardID uint, quantity int, zo
 	return r.db.Create(newEntry).Error
 }
 
-// GetDeckCard retrieves the DeckCard entry (with full card data) for a given deck and card ID.
-func (r *deckCardRepository) GetDeckCard(deckID, cardID uint) (*models.DeckCard, error) {
+// AddCardsToDeck adds several cards to a deck in a single transaction.
+// Either every entry is added, or none of them is. When check is set, it runs first inside the
+// transaction against a repository bound to it, and nothing is added if it returns an error.
+// On Postgres the deck row is locked, so concurrent additions to the deck are checked one at a time.
+func (r *deckCardRepository) AddCardsToDeck(deckID uint, entries []models.DeckCard, check func(DeckCardRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if isPostgres(tx) {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.Deck{}, deckID).Error; err != nil {
+				return err
+			}
+		}
+		if check != nil {
+			if err := check(&deckCardRepository{db: tx}); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range entries {
+			var existing models.DeckCard
+			err := tx.Where("deck_id = ? AND card_id = ? AND zone = ?", deckID, entry.CardID, entry.Zone).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				newEntry := models.DeckCard{DeckID: deckID, CardID: entry.CardID, Zone: entry.Zone, Quantity: entry.Quantity}
+				if err := tx.Create(&newEntry).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			existing.Quantity += entry.Quantity
+			if err := tx.Save(&existing).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetDeckCard retrieves the DeckCard entry (with full card data) for a given deck, card ID and zone.
+func (r *deckCardRepository) GetDeckCard(deckID, cardID uint, zone string) (*models.DeckCard, error) {
 	var card models.DeckCard
-	err := r.db.Where("deck_id = ? AND card_id = ?", deckID, cardID).
+	err := r.db.Where("deck_id = ? AND card_id = ? AND zone = ?", deckID, cardID, zone).
 		Preload("Card").
 		Preload("Card.MonsterCard").
 		Preload("Card.SpellTrapCard").
@@ -74,6 +124,13 @@ func (r *deckCardRepository) GetDeckCard(deckID, cardID uint) (*models.DeckCard,
 	return &card, nil
 }
 
+// GetDeckCardZones returns every entry of a card in a deck, one per zone it is placed in.
+func (r *deckCardRepository) GetDeckCardZones(deckID, cardID uint) ([]models.DeckCard, error) {
+	var entries []models.DeckCard
+	err := r.db.Where("deck_id = ? AND card_id = ?", deckID, cardID).Find(&entries).Error
+	return entries, err
+}
+
 func (r *deckCardRepository) GetTotalCardsInZone(deckID uint, zone string) (int, error) {
 	var total sql.NullInt64
 	err := r.db.Model(&models.DeckCard{}).Where("deck_id = ? AND zone = ?", deckID, zone).Select("SUM(quantity)").Scan(&total).Error
@@ -86,16 +143,17 @@ func (r *deckCardRepository) GetTotalCardsInZone(deckID uint, zone string) (int,
 	return 0, nil
 }
 
+// GetCardQuantityInDeck returns the number of copies of a card across all zones of a deck.
 func (r *deckCardRepository) GetCardQuantityInDeck(deckID, cardID uint) (int, error) {
-	var existing models.DeckCard
-	err := r.db.Where("deck_id = ? AND card_id = ?", deckID, cardID).First(&existing).Error
+	var total sql.NullInt64
+	err := r.db.Model(&models.DeckCard{}).Where("deck_id = ? AND card_id = ?", deckID, cardID).Select("SUM(quantity)").Scan(&total).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, nil
-		}
 		return 0, err
 	}
-	return existing.Quantity, nil
+	if total.Valid {
+		return int(total.Int64), nil
+	}
+	return 0, nil
 }
 
 // UpdateDeckCardQuantity updates the quantity of an existing DeckCard entry.
@@ -107,3 +165,37 @@ func (r *deckCardRepository) UpdateDeckCardQuantity(card *models.DeckCard) error
 func (r *deckCardRepository) DeleteDeckCard(card *models.DeckCard) error {
 	return r.db.Delete(card).Error
 }
+
+// MoveDeckCard moves copies of a card from one zone of a deck to another in a single transaction.
+func (r *deckCardRepository) MoveDeckCard(deckID, cardID uint, fromZone, toZone string, quantity int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var source models.DeckCard
+		if err := tx.Where("deck_id = ? AND card_id = ? AND zone = ?", deckID, cardID, fromZone).First(&source).Error; err != nil {
+			return err
+		}
+		if source.Quantity < quantity {
+			return fmt.Errorf("only %d copies in %s zone", source.Quantity, fromZone)
+		}
+
+		source.Quantity -= quantity
+		if source.Quantity == 0 {
+			if err := tx.Delete(&source).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Save(&source).Error; err != nil {
+			return err
+		}
+
+		var target models.DeckCard
+		err := tx.Where("deck_id = ? AND card_id = ? AND zone = ?", deckID, cardID, toZone).First(&target).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&models.DeckCard{DeckID: deckID, CardID: cardID, Zone: toZone, Quantity: quantity}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		target.Quantity += quantity
+		return tx.Save(&target).Error
+	})
+}
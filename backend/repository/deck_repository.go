@@ -7,6 +7,7 @@ import (
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DeckRepository defines the interface for deck-related database operations.
@@ -14,10 +15,15 @@ type DeckRepository interface {
 	CountByUserID(userID uint) (int64, error)
 	ExistsByName(userID uint, name string) (bool, error)
 	Create(deck *models.Deck) error
+	CreateWithCards(deck *models.Deck, cards []models.DeckCard, check func(DeckRepository) error) error
 	FindByUserID(userID uint) ([]models.Deck, error)
+	FindPageByUserID(userID uint, page Page) ([]models.Deck, string, error)
 	FindByIDAndUserID(deckID, userID uint) (*models.Deck, error)
 	DeleteByIDAndUserID(deckID, userID uint) error
 	FindDeckCards(deckID, userID uint) ([]models.DeckCard, error)
+	UpdateFormat(deckID uint, formatID *uint) error
+	CountCardCopiesInOtherDecks(userID, excludeDeckID uint, physicalOnly bool) (map[uint]int, error)
+	UpdatePhysical(deckID uint, physical, warnOnShortage bool) error
 }
 
 type deckRepository struct {
@@ -59,10 +65,42 @@ func (r *deckRepository) Create(deck *models.Deck) error {
 	return r.db.Create(deck).Error
 }
 
+// Create a new deck together with its cards in a single transaction. When check is set, it runs
+// first inside the transaction against a repository bound to it, and nothing is created if it
+// returns an error. On Postgres the user row is locked, so concurrent creations of the user's
+// decks are checked one at a time.
+func (r *deckRepository) CreateWithCards(deck *models.Deck, cards []models.DeckCard, check func(DeckRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if isPostgres(tx) {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.User{}, deck.UserID).Error; err != nil {
+				return err
+			}
+		}
+		if check != nil {
+			if err := check(&deckRepository{db: tx}); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Create(deck).Error; err != nil {
+			return err
+		}
+
+		for _, card := range cards {
+			entry := models.DeckCard{DeckID: deck.ID, CardID: card.CardID, Zone: card.Zone, Quantity: card.Quantity}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Find all decks belonging to a user, preloading deck cards and their types
 func (r *deckRepository) FindByUserID(userID uint) ([]models.Deck, error) {
 	var decks []models.Deck
 	err := r.db.Where("user_id = ?", userID).
+		Preload("Format").
 		Preload("DeckCards").
 		Preload("DeckCards.Card").
 		Preload("DeckCards.Card.MonsterCard").
@@ -73,6 +111,52 @@ func (r *deckRepository) FindByUserID(userID uint) ([]models.Deck, error) {
 	return decks, err
 }
 
+// Sort keys of deck listings.
+const (
+	DeckSortName  = "name"
+	DeckSortAdded = "added"
+)
+
+var deckSorts = map[string]sortKey{
+	DeckSortName:  {column: "decks.name", idColumn: "decks.id"},
+	DeckSortAdded: {column: "decks.id", idColumn: "decks.id", numeric: true},
+}
+
+// FindPageByUserID retrieves a page of a user's decks, in the order they were created unless the page selects
+// another sort, and the cursor of the next page, which is empty on the last page.
+func (r *deckRepository) FindPageByUserID(userID uint, page Page) ([]models.Deck, string, error) {
+	sort, desc, cursor, err := resolvePage(page, DeckSortAdded)
+	if err != nil {
+		return nil, "", err
+	}
+	key, ok := deckSorts[sort]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrInvalidSort, sort)
+	}
+
+	db := r.db.Model(&models.Deck{}).Where("user_id = ?", userID).
+		Preload("Format").
+		Preload("DeckCards").
+		Preload("DeckCards.Card").
+		Preload("DeckCards.Card.MonsterCard").
+		Preload("DeckCards.Card.SpellTrapCard").
+		Preload("DeckCards.Card.LinkMonsterCard").
+		Preload("DeckCards.Card.PendulumMonsterCard")
+
+	limit := page.limit()
+	var decks []models.Deck
+	if err := key.seek(db, desc, cursor, page.Offset).Limit(limit + 1).Find(&decks).Error; err != nil {
+		return nil, "", err
+	}
+	if len(decks) <= limit {
+		return decks, "", nil
+	}
+
+	decks = decks[:limit]
+	last := &decks[limit-1]
+	return decks, key.nextCursor(sort, desc, int64(last.ID), last.Name, last.ID), nil
+}
+
 // Find a deck by ID and user ID
 func (r *deckRepository) FindByIDAndUserID(deckID, userID uint) (*models.Deck, error) {
 	var deck models.Deck
@@ -111,3 +195,40 @@ func (r *deckRepository) FindDeckCards(deckID, userID uint) ([]models.DeckCard,
 
 	return deckCards, err
 }
+
+// Assign a format to a deck
+func (r *deckRepository) UpdateFormat(deckID uint, formatID *uint) error {
+	return r.db.Model(&models.Deck{}).Where("id = ?", deckID).Update("format_id", formatID).Error
+}
+
+// CountCardCopiesInOtherDecks returns, per card ID, the copies used across all zones of the user's decks
+// other than the excluded one. With physicalOnly set, only physical decks are counted.
+func (r *deckRepository) CountCardCopiesInOtherDecks(userID, excludeDeckID uint, physicalOnly bool) (map[uint]int, error) {
+	var rows []struct {
+		CardID uint
+		Total  int
+	}
+	query := r.db.Model(&models.DeckCard{}).
+		Select("deck_cards.card_id, SUM(deck_cards.quantity) AS total").
+		Joins("JOIN decks ON decks.id = deck_cards.deck_id").
+		Where("decks.user_id = ? AND decks.id <> ?", userID, excludeDeckID)
+	if physicalOnly {
+		query = query.Where("decks.physical = ?", true)
+	}
+	err := query.Group("deck_cards.card_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		copies[row.CardID] = row.Total
+	}
+	return copies, nil
+}
+
+// UpdatePhysical sets whether the deck reserves its copies from the collection
+func (r *deckRepository) UpdatePhysical(deckID uint, physical, warnOnShortage bool) error {
+	return r.db.Model(&models.Deck{}).Where("id = ?", deckID).
+		Updates(map[string]interface{}{"physical": physical, "warn_on_shortage": warnOnShortage}).Error
+}
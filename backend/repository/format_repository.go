@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+// FormatRepository defines the interface for format-related database operations.
+type FormatRepository interface {
+	Create(format *models.Format) error
+	FindByID(id uint) (*models.Format, error)
+	FindBuiltInByCode(code string) (*models.Format, error)
+	FindAvailable(userID uint) ([]models.Format, error)
+	FindByDeckID(deckID uint) (*models.Format, error)
+}
+
+type formatRepository struct {
+	db *gorm.DB
+}
+
+// NewFormatRepository creates a new instance of formatRepository using the default DB.
+func NewFormatRepository() FormatRepository {
+	return &formatRepository{
+		db: database.DB,
+	}
+}
+
+func NewFormatRepositoryWithDB(db *gorm.DB) FormatRepository {
+	return &formatRepository{
+		db: db,
+	}
+}
+
+// Create inserts a new format.
+func (r *formatRepository) Create(format *models.Format) error {
+	return r.db.Create(format).Error
+}
+
+// FindByID retrieves a format by ID.
+func (r *formatRepository) FindByID(id uint) (*models.Format, error) {
+	var format models.Format
+	if err := r.db.First(&format, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
+
+// FindBuiltInByCode retrieves a built-in format by its code, or nil if it does not exist.
+func (r *formatRepository) FindBuiltInByCode(code string) (*models.Format, error) {
+	var format models.Format
+	err := r.db.First(&format, "code = ? AND user_id IS NULL", code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
+
+// FindAvailable returns the built-in formats plus the custom formats created by the user.
+func (r *formatRepository) FindAvailable(userID uint) ([]models.Format, error) {
+	var formats []models.Format
+	err := r.db.Where("user_id IS NULL OR user_id = ?", userID).Order("id").Find(&formats).Error
+	return formats, err
+}
+
+// FindByDeckID returns the format assigned to a deck, or nil if the deck has none.
+func (r *formatRepository) FindByDeckID(deckID uint) (*models.Format, error) {
+	var format models.Format
+	err := r.db.Joins("JOIN decks ON decks.format_id = formats.id").
+		Where("decks.id = ?", deckID).
+		First(&format).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
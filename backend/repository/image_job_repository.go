@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImageJobRepository defines the interface for the persistent card image download queue.
+type ImageJobRepository interface {
+	Enqueue(job *models.ImageJob) error
+	ClaimNext(now time.Time) (*models.ImageJob, error)
+	MarkDone(id uint) error
+	MarkFailed(id uint, attempts int, lastError string, retryAt *time.Time) error
+	RenewLease(id uint, now time.Time) error
+	RequeueStale(before time.Time) (int64, error)
+	FindByCardID(cardID uint) (*models.ImageJob, error)
+	CountByStatus() (map[string]int64, error)
+}
+
+type imageJobRepository struct {
+	db *gorm.DB
+}
+
+// NewImageJobRepository creates a new instance of imageJobRepository using the default DB.
+func NewImageJobRepository() ImageJobRepository {
+	return &imageJobRepository{
+		db: database.DB,
+	}
+}
+
+func NewImageJobRepositoryWithDB(db *gorm.DB) ImageJobRepository {
+	return &imageJobRepository{
+		db: db,
+	}
+}
+
+// Enqueue stores a pending job for the card, replacing its previous job if there is one.
+func (r *imageJobRepository) Enqueue(job *models.ImageJob) error {
+	job.Status = models.ImageJobPending
+	job.Attempts = 0
+	job.LastError = ""
+	if job.NextAttemptAt.IsZero() {
+		job.NextAttemptAt = time.Now()
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "card_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"card_ygo_id", "source_url", "status", "attempts", "last_error", "next_attempt_at", "updated_at",
+		}),
+	}).Create(job).Error
+}
+
+// ClaimNext marks the oldest pending job that is due as running and returns it, or returns nil when there is
+// no such job. A job is only claimed by one caller, even with several workers or backend instances.
+func (r *imageJobRepository) ClaimNext(now time.Time) (*models.ImageJob, error) {
+	for {
+		var job models.ImageJob
+		err := r.db.Where("status = ? AND next_attempt_at <= ?", models.ImageJobPending, now).
+			Order("next_attempt_at, id").
+			First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := r.db.Model(&models.ImageJob{}).
+			Where("id = ? AND status = ?", job.ID, models.ImageJobPending).
+			Updates(map[string]interface{}{"status": models.ImageJobRunning, "updated_at": now})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = models.ImageJobRunning
+			return &job, nil
+		}
+		// Another worker claimed it first.
+	}
+}
+
+// MarkDone marks a job as completed.
+func (r *imageJobRepository) MarkDone(id uint) error {
+	return r.db.Model(&models.ImageJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.ImageJobDone,
+		"last_error": "",
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// MarkFailed records a failed attempt. The job is retried at retryAt, or marked as failed when it is nil.
+func (r *imageJobRepository) MarkFailed(id uint, attempts int, lastError string, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":     models.ImageJobFailed,
+		"attempts":   attempts,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	}
+	if retryAt != nil {
+		updates["status"] = models.ImageJobPending
+		updates["next_attempt_at"] = *retryAt
+	}
+	return r.db.Model(&models.ImageJob{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// RenewLease marks a running job as still being worked on, so that RequeueStale leaves it alone.
+func (r *imageJobRepository) RenewLease(id uint, now time.Time) error {
+	return r.db.Model(&models.ImageJob{}).Where("id = ? AND status = ?", id, models.ImageJobRunning).
+		Update("updated_at", now).Error
+}
+
+// RequeueStale returns jobs left running by a stopped backend, whose lease was last renewed before the given
+// time, to the queue, and reports how many there were. Jobs other workers are still running are left alone.
+func (r *imageJobRepository) RequeueStale(before time.Time) (int64, error) {
+	result := r.db.Model(&models.ImageJob{}).Where("status = ? AND updated_at < ?", models.ImageJobRunning, before).
+		Updates(map[string]interface{}{"status": models.ImageJobPending, "updated_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// FindByCardID retrieves the job of a card.
+func (r *imageJobRepository) FindByCardID(cardID uint) (*models.ImageJob, error) {
+	var job models.ImageJob
+	err := r.db.First(&job, "card_id = ?", cardID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CountByStatus returns the number of jobs in each status.
+func (r *imageJobRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.ImageJob{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{
+		models.ImageJobPending: 0,
+		models.ImageJobRunning: 0,
+		models.ImageJobDone:    0,
+		models.ImageJobFailed:  0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultPageSize is used when a Page has no limit.
+const DefaultPageSize = 20
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Page selects a page of a listing. Pages are read from Cursor, returned as the next cursor of the previous
+// page, or from Offset when there is no cursor. A cursor keeps the sort it was created with.
+type Page struct {
+	Sort   string // sort key of the listing, "" for its default
+	Desc   bool
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+func (p Page) limit() int {
+	if p.Limit <= 0 {
+		return DefaultPageSize
+	}
+	return p.Limit
+}
+
+// pageCursor is the content of an opaque cursor: the sort key and position of the last row of a page.
+// Listings that cannot seek to a row, such as relevance-sorted searches, store an offset instead.
+type pageCursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Num    int64  `json:"n,omitempty"`
+	Text   string `json:"t,omitempty"`
+	ID     uint   `json:"i,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// sortKey is a column a listing can be sorted on. Rows with the same value are ordered by idColumn, so the
+// order is stable and every row has a unique position to continue from.
+type sortKey struct {
+	column   string
+	idColumn string
+	numeric  bool
+}
+
+// resolvePage returns the sort, direction and cursor of page. A cursor keeps the sort it was created with,
+// so a page with a cursor only needs a sort to check that it matches.
+func resolvePage(page Page, defaultSort string) (sort string, desc bool, cursor *pageCursor, err error) {
+	if page.Cursor == "" {
+		if page.Sort == "" {
+			return defaultSort, page.Desc, nil, nil
+		}
+		return page.Sort, page.Desc, nil, nil
+	}
+
+	cursor, err = decodeCursor(page.Cursor)
+	if err != nil {
+		return "", false, nil, err
+	}
+	if page.Sort != "" && (cursor.Sort != page.Sort || cursor.Desc != page.Desc) {
+		return "", false, nil, fmt.Errorf("%w: it was created for a different sort", ErrInvalidCursor)
+	}
+	return cursor.Sort, cursor.Desc, cursor, nil
+}
+
+// seek orders db by the key and, with a cursor, skips the rows up to and including its position.
+// Without a cursor, the page's offset is applied.
+func (k sortKey) seek(db *gorm.DB, desc bool, cursor *pageCursor, offset int) *gorm.DB {
+	if cursor == nil {
+		if offset > 0 {
+			db = db.Offset(offset)
+		}
+	} else {
+		var value interface{} = cursor.Text
+		if k.numeric {
+			value = cursor.Num
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		db = db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", k.column, op, k.column, k.idColumn, op),
+			value, value, cursor.ID)
+	}
+
+	return db.Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: k.column, Raw: true}, Desc: desc},
+		{Column: clause.Column{Name: k.idColumn, Raw: true}, Desc: desc},
+	}})
+}
+
+// nextCursor returns the cursor of the page following a row, given the row's sort value and ID.
+func (k sortKey) nextCursor(sort string, desc bool, num int64, text string, id uint) string {
+	c := pageCursor{Sort: sort, Desc: desc, ID: id}
+	if k.numeric {
+		c.Num = num
+	} else {
+		c.Text = text
+	}
+	return encodeCursor(c)
+}
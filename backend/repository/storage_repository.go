@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotEnoughCopies = errors.New("not enough copies")
+
+// StorageRepository defines the interface for managing storage locations and the copies kept in them.
+type StorageRepository interface {
+	CreateLocation(location *models.StorageLocation) error
+	GetLocation(id uint) (*models.StorageLocation, error)
+	GetLocations(userID uint) ([]models.StorageLocation, error)
+	DeleteLocation(id uint) error
+	CountStoredCards(userID uint) ([]LocationCount, error)
+	CountUnassigned(userID uint) (*LocationCount, error)
+	GetStoredCards(locationID uint) ([]models.StoredCard, error)
+	GetCardLocations(userID, cardID uint) ([]models.StoredCard, error)
+	MoveCopies(move CopyMove) error
+}
+
+// StoragePosition is where copies are kept: a location, and a page and slot in it when they are not zero.
+// A zero location stands for the copies of the collection not stored anywhere.
+type StoragePosition struct {
+	LocationID uint
+	Page       int
+	Slot       int
+}
+
+// CopyMove moves copies of a collection entry, the copies of a printing with the given attributes, from
+// one position to another.
+type CopyMove struct {
+	UserID     uint
+	CardID     uint
+	PrintingID uint // zero for the unspecified printing of the card
+	models.CopyAttributes
+	From     StoragePosition
+	To       StoragePosition
+	Quantity int
+}
+
+// LocationCount is the number of distinct cards and of copies kept in a storage location.
+type LocationCount struct {
+	LocationID uint
+	Cards      int64
+	Quantity   int64
+}
+
+// storedCardOfEntry matches the stored copies of a user_cards row.
+const storedCardOfEntry = `stored_cards.user_id = user_cards.user_id AND stored_cards.printing_id = user_cards.printing_id
+	AND stored_cards.condition = user_cards.condition AND stored_cards.edition = user_cards.edition
+	AND stored_cards.language = user_cards.language AND stored_cards.finish = user_cards.finish`
+
+// unstoredQuantity is the number of copies of a user_cards row not stored in any location.
+const unstoredQuantity = "user_cards.quantity - COALESCE((SELECT SUM(stored_cards.quantity) FROM stored_cards WHERE " +
+	storedCardOfEntry + "), 0)"
+
+type storageRepository struct {
+	db *gorm.DB
+}
+
+func NewStorageRepository() StorageRepository {
+	return &storageRepository{
+		db: database.DB,
+	}
+}
+
+// NewStorageRepositoryWithDB creates a new instance of storageRepository using the provided DB.
+func NewStorageRepositoryWithDB(db *gorm.DB) StorageRepository {
+	return &storageRepository{
+		db: db,
+	}
+}
+
+// CreateLocation inserts a new storage location.
+func (r *storageRepository) CreateLocation(location *models.StorageLocation) error {
+	return r.db.Create(location).Error
+}
+
+// GetLocation retrieves a storage location by ID.
+func (r *storageRepository) GetLocation(id uint) (*models.StorageLocation, error) {
+	var location models.StorageLocation
+	if err := r.db.First(&location, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetLocations returns the storage locations of a user, sorted by name.
+func (r *storageRepository) GetLocations(userID uint) ([]models.StorageLocation, error) {
+	var locations []models.StorageLocation
+	err := r.db.Where("user_id = ?", userID).Order("name, id").Find(&locations).Error
+	return locations, err
+}
+
+// DeleteLocation deletes a storage location, leaving the copies it held unassigned.
+func (r *storageRepository) DeleteLocation(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("location_id = ?", id).Delete(&models.StoredCard{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.StorageLocation{}, id).Error
+	})
+}
+
+// CountStoredCards returns the number of cards and copies kept in each storage location of a user holding
+// any.
+func (r *storageRepository) CountStoredCards(userID uint) ([]LocationCount, error) {
+	var counts []LocationCount
+	err := r.db.Model(&models.StoredCard{}).
+		Select("location_id, COUNT(DISTINCT card_id) AS cards, SUM(quantity) AS quantity").
+		Where("user_id = ?", userID).
+		Group("location_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// CountUnassigned returns the number of cards and copies of a user's collection not stored in any location,
+// as a LocationCount without location.
+func (r *storageRepository) CountUnassigned(userID uint) (*LocationCount, error) {
+	var count LocationCount
+	err := r.db.Model(&models.UserCard{}).
+		Select("COUNT(DISTINCT user_cards.card_id) AS cards, COALESCE(SUM("+unstoredQuantity+"), 0) AS quantity").
+		Where("user_cards.user_id = ?", userID).
+		Where(unstoredQuantity + " > 0").
+		Scan(&count).Error
+	return &count, err
+}
+
+// GetStoredCards returns the copies kept in a storage location with their card and printing, sorted by page
+// and slot.
+func (r *storageRepository) GetStoredCards(locationID uint) ([]models.StoredCard, error) {
+	var storedCards []models.StoredCard
+	err := r.db.Preload("Card").
+		Preload("Printing.CardSet").
+		Where("location_id = ?", locationID).
+		Order("page, slot, card_id, printing_id, condition, edition, language, finish").
+		Find(&storedCards).Error
+	return storedCards, err
+}
+
+// GetCardLocations returns where the copies of a card in a user's collection are kept, with their location
+// and printing.
+func (r *storageRepository) GetCardLocations(userID, cardID uint) ([]models.StoredCard, error) {
+	var storedCards []models.StoredCard
+	err := r.db.Preload("Location").
+		Preload("Printing.CardSet").
+		Where("user_id = ? AND card_id = ?", userID, cardID).
+		Order("location_id, page, slot, printing_id, condition, edition, language, finish").
+		Find(&storedCards).Error
+	return storedCards, err
+}
+
+// MoveCopies moves copies of a collection entry between two positions in a single transaction. It fails
+// with ErrNotEnoughCopies when the source position holds fewer copies than moved.
+func (r *storageRepository) MoveCopies(move CopyMove) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		printingID := move.PrintingID
+		if printingID == 0 {
+			printing, err := unspecifiedPrinting(tx, move.CardID)
+			if err != nil {
+				return err
+			}
+			printingID = printing.ID
+		}
+
+		// Locking the entry keeps concurrent moves and removals of its copies from overdrawing it.
+		db := tx
+		if isPostgres(tx) {
+			db = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		userCard := models.UserCard{UserID: move.UserID, PrintingID: printingID, CopyAttributes: move.CopyAttributes}
+		err := whereUserCard(db, &userCard).Where("card_id = ?", move.CardID).Take(&userCard).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: the copies are not in the collection", ErrNotEnoughCopies)
+		}
+		if err != nil {
+			return err
+		}
+
+		var available int
+		if move.From.LocationID == 0 {
+			stored, err := storedQuantity(tx, &userCard)
+			if err != nil {
+				return err
+			}
+			available = userCard.Quantity - stored
+		} else {
+			from := storedCardAt(&userCard, move.From)
+			err := whereStoredCard(tx, &from).Take(&from).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			available = from.Quantity
+		}
+		if available < move.Quantity {
+			return fmt.Errorf("%w: %d available", ErrNotEnoughCopies, available)
+		}
+
+		if move.From.LocationID != 0 {
+			if err := addStoredCopies(tx, storedCardAt(&userCard, move.From), -move.Quantity); err != nil {
+				return err
+			}
+		}
+		if move.To.LocationID != 0 {
+			return addStoredCopies(tx, storedCardAt(&userCard, move.To), move.Quantity)
+		}
+		return nil
+	})
+}
+
+// storedCardAt returns the stored copies of a collection entry at a position, without quantity.
+func storedCardAt(userCard *models.UserCard, position StoragePosition) models.StoredCard {
+	return models.StoredCard{
+		LocationID:     position.LocationID,
+		PrintingID:     userCard.PrintingID,
+		CopyAttributes: userCard.CopyAttributes,
+		Page:           position.Page,
+		Slot:           position.Slot,
+		UserID:         userCard.UserID,
+		CardID:         userCard.CardID,
+	}
+}
+
+// whereStoredCard selects a single stored_cards row by its whole key, see whereUserCard.
+func whereStoredCard(db *gorm.DB, storedCard *models.StoredCard) *gorm.DB {
+	return db.Where("location_id = ? AND page = ? AND slot = ?", storedCard.LocationID, storedCard.Page, storedCard.Slot).
+		Where("printing_id = ? AND condition = ? AND edition = ? AND language = ? AND finish = ?",
+			storedCard.PrintingID, storedCard.Condition, storedCard.Edition, storedCard.Language, storedCard.Finish)
+}
+
+// whereEntryStoredCards selects the stored copies of a collection entry, wherever they are.
+func whereEntryStoredCards(db *gorm.DB, userCard *models.UserCard) *gorm.DB {
+	return db.Where("user_id = ? AND printing_id = ? AND condition = ? AND edition = ? AND language = ? AND finish = ?",
+		userCard.UserID, userCard.PrintingID, userCard.Condition, userCard.Edition, userCard.Language, userCard.Finish)
+}
+
+// storedQuantity returns how many copies of a collection entry are stored in any location.
+func storedQuantity(tx *gorm.DB, userCard *models.UserCard) (int, error) {
+	var total int
+	err := whereEntryStoredCards(tx.Model(&models.StoredCard{}), userCard).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// addStoredCopies adds delta copies to the stored copies at a position, deleting them when none are left.
+func addStoredCopies(tx *gorm.DB, storedCard models.StoredCard, delta int) error {
+	err := whereStoredCard(tx, &storedCard).Take(&storedCard).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if delta <= 0 {
+			return nil
+		}
+		storedCard.Quantity = delta
+		return tx.Create(&storedCard).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if storedCard.Quantity+delta <= 0 {
+		return whereStoredCard(tx, &storedCard).Delete(&models.StoredCard{}).Error
+	}
+	return whereStoredCard(tx.Model(&models.StoredCard{}), &storedCard).
+		Update("quantity", storedCard.Quantity+delta).Error
+}
+
+// trimStoredCards takes stored copies of a collection entry out of their locations, the last location, page
+// and slot first, until no more than quantity remain stored. It keeps the stored copies of an entry within
+// its quantity when copies are removed from the collection.
+func trimStoredCards(tx *gorm.DB, userCard *models.UserCard, quantity int) error {
+	var storedCards []models.StoredCard
+	err := whereEntryStoredCards(tx, userCard).
+		Order("location_id DESC, page DESC, slot DESC").
+		Find(&storedCards).Error
+	if err != nil {
+		return err
+	}
+
+	stored := 0
+	for _, storedCard := range storedCards {
+		stored += storedCard.Quantity
+	}
+	for _, storedCard := range storedCards {
+		if stored <= quantity {
+			break
+		}
+		taken := min(storedCard.Quantity, stored-quantity)
+		if err := addStoredCopies(tx, storedCard, -taken); err != nil {
+			return err
+		}
+		stored -= taken
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"gorm.io/gorm"
+)
+
+// TradeRepository defines the interface for accessing wishlists and the copies users could trade.
+type TradeRepository interface {
+	GetWishlist(userID uint) ([]models.WishlistEntry, error)
+	GetWishlistEntry(id uint) (*models.WishlistEntry, error)
+	SaveWishlistEntry(entry *models.WishlistEntry) error
+	DeleteWishlistEntry(id uint) error
+	GetWishes(cardIDs []uint, excludeUserID uint) ([]models.WishlistEntry, error)
+	GetUserCopies(userID uint) ([]models.UserCard, error)
+	GetCardCopies(cardIDs []uint, excludeUserID uint) ([]models.UserCard, error)
+	GetReservedQuantities(cardIDs []uint) ([]ReservedQuantity, error)
+	GetUsernames(userIDs []uint) (map[uint]string, error)
+}
+
+// ReservedQuantity is how many copies of a card the physical decks of a user hold.
+type ReservedQuantity struct {
+	UserID   uint
+	CardID   uint
+	Quantity int
+}
+
+type tradeRepository struct {
+	db *gorm.DB
+}
+
+func NewTradeRepository() TradeRepository {
+	return &tradeRepository{
+		db: database.DB,
+	}
+}
+
+// NewTradeRepositoryWithDB creates a new instance of tradeRepository using the provided DB.
+func NewTradeRepositoryWithDB(db *gorm.DB) TradeRepository {
+	return &tradeRepository{
+		db: db,
+	}
+}
+
+// GetWishlist returns the wishlist of a user with its cards and printings, in the order they were added.
+func (r *tradeRepository) GetWishlist(userID uint) ([]models.WishlistEntry, error) {
+	var entries []models.WishlistEntry
+	err := r.db.Preload("Card").
+		Preload("Printing.CardSet").
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetWishlistEntry retrieves a wishlist entry by ID.
+func (r *tradeRepository) GetWishlistEntry(id uint) (*models.WishlistEntry, error) {
+	var entry models.WishlistEntry
+	if err := r.db.First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// SaveWishlistEntry adds an entry to a wishlist. When the user already wishes for the same card, printing
+// and condition, that entry takes the new quantity instead, and entry gets its ID.
+func (r *tradeRepository) SaveWishlistEntry(entry *models.WishlistEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		db := tx.Where("user_id = ? AND card_id = ? AND condition = ?", entry.UserID, entry.CardID, entry.Condition)
+		if entry.PrintingID == nil {
+			db = db.Where("printing_id IS NULL")
+		} else {
+			db = db.Where("printing_id = ?", *entry.PrintingID)
+		}
+
+		var existing models.WishlistEntry
+		err := db.Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(entry).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		entry.ID = existing.ID
+		return tx.Model(&existing).Update("quantity", entry.Quantity).Error
+	})
+}
+
+// DeleteWishlistEntry removes an entry from its wishlist.
+func (r *tradeRepository) DeleteWishlistEntry(id uint) error {
+	return r.db.Delete(&models.WishlistEntry{}, id).Error
+}
+
+// GetWishes returns the wishlist entries of every user but excludeUserID asking for the given cards, with
+// their card.
+func (r *tradeRepository) GetWishes(cardIDs []uint, excludeUserID uint) ([]models.WishlistEntry, error) {
+	var entries []models.WishlistEntry
+	if len(cardIDs) == 0 {
+		return entries, nil
+	}
+	err := r.db.Preload("Card").
+		Where("card_id IN ? AND user_id <> ?", cardIDs, excludeUserID).
+		Order("id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetUserCopies returns the entries of a user's collection with their card.
+func (r *tradeRepository) GetUserCopies(userID uint) ([]models.UserCard, error) {
+	var userCards []models.UserCard
+	err := r.db.Preload("Card").Where("user_id = ?", userID).Find(&userCards).Error
+	return userCards, err
+}
+
+// GetCardCopies returns the collection entries of every user but excludeUserID holding the given cards,
+// with their card.
+func (r *tradeRepository) GetCardCopies(cardIDs []uint, excludeUserID uint) ([]models.UserCard, error) {
+	var userCards []models.UserCard
+	if len(cardIDs) == 0 {
+		return userCards, nil
+	}
+	err := r.db.Preload("Card").
+		Where("card_id IN ? AND user_id <> ?", cardIDs, excludeUserID).
+		Find(&userCards).Error
+	return userCards, err
+}
+
+// GetReservedQuantities returns how many copies of the given cards the physical decks of each user hold.
+func (r *tradeRepository) GetReservedQuantities(cardIDs []uint) ([]ReservedQuantity, error) {
+	var reserved []ReservedQuantity
+	if len(cardIDs) == 0 {
+		return reserved, nil
+	}
+	err := r.db.Model(&models.DeckCard{}).
+		Select("decks.user_id, deck_cards.card_id, SUM(deck_cards.quantity) AS quantity").
+		Joins("JOIN decks ON decks.id = deck_cards.deck_id").
+		Where("decks.physical = ? AND deck_cards.card_id IN ?", true, cardIDs).
+		Group("decks.user_id, deck_cards.card_id").
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+// GetUsernames returns the usernames of the given users by ID.
+func (r *tradeRepository) GetUsernames(userIDs []uint) (map[uint]string, error) {
+	usernames := make(map[uint]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return usernames, nil
+	}
+
+	var users []models.User
+	if err := r.db.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
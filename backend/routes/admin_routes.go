@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(rg *gin.RouterGroup, h handlers.CatalogHandler, imageHandler handlers.CardImageHandler, userRepo repository.UserRepository) {
+	rg = rg.Group("/admin")
+	rg.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware(userRepo))
+	rg.POST("/catalog/sync", h.SyncCatalog)
+	rg.POST("/images/backfill", imageHandler.BackfillImages)
+	rg.GET("/images/queue", imageHandler.GetQueueStats)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterArchetypeRoutes(rg *gin.RouterGroup, h handlers.CardHandler) {
+	rg = rg.Group("/archetypes")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/", h.GetArchetypes)
+}
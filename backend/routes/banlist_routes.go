@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterBanlistRoutes(rg *gin.RouterGroup, h handlers.BanlistHandler, userRepo repository.UserRepository) {
+	rg = rg.Group("/banlists")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/", h.GetBanlists)
+	rg.GET("/:banlistId", h.GetBanlist)
+	// Imported banlists apply to every user, so only administrators can replace them.
+	rg.POST("/import", middleware.AdminMiddleware(userRepo), h.ImportBanlist)
+}
@@ -6,10 +6,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterCardRoutes(rg *gin.RouterGroup, h handlers.CardHandler) {
+func RegisterCardRoutes(rg *gin.RouterGroup, h handlers.CardHandler, imageHandler handlers.CardImageHandler) {
 	rg = rg.Group("/cards")
 	rg.Use(middleware.AuthMiddleware())
 	rg.GET("/:param", h.GetCardByParam)
+	rg.GET("/:param/image", imageHandler.GetImageStatus)
 	rg.GET("/", h.GetCards)
 	rg.GET("/search", h.SearchCards)
 }
@@ -10,7 +10,10 @@ func RegisterCollectionRoutes(rg *gin.RouterGroup, h handlers.CollectionHandler)
 	rg = rg.Group("/collections")
 	rg.Use(middleware.AuthMiddleware())
 	rg.GET("/", h.GetCollection)
+	rg.GET("/groups", h.GetCollectionGroups)
 	rg.GET("/:cardId", h.GetCollectionCard)
+	rg.GET("/:cardId/reservations", h.GetCardReservations)
+	rg.GET("/:cardId/printings", h.GetCollectionCardPrintings)
 	rg.POST("/", h.AddCardToCollection)
 	rg.DELETE("/:cardId", h.DeleteQuantityFromCollection)
 }
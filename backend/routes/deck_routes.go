@@ -11,10 +11,17 @@ func RegisterDeckRoutes(rg *gin.RouterGroup, h handlers.DeckHandler) {
 	rg.Use(middleware.AuthMiddleware())
 	rg.GET("/", h.GetUserDecks)
 	rg.POST("/", h.CreateDeck)
+	rg.POST("/import", h.ImportNewDeckHandler)
 	rg.POST("/import/:deckId", h.ImportDeckHandler)
 	rg.POST("/export/:deckId", h.ExportDeckHandler)
+	rg.GET("/:deckId/export", h.ExportDeckHandler)
 	rg.GET("/:deckId/cards", h.GetCardByDeck)
+	rg.GET("/:deckId/legality", h.GetDeckLegality)
+	rg.GET("/:deckId/missing", h.GetMissingCards)
+	rg.PATCH("/:deckId/format", h.SetDeckFormat)
+	rg.PATCH("/:deckId/physical", h.SetDeckPhysical)
 	rg.POST("/:deckId/cards", h.AddCardToDeck)
 	rg.DELETE("/:deckId", h.DeleteDeck)
 	rg.DELETE("/:deckId/cards/:cardId", h.RemoveCardFromDeck)
+	rg.POST("/:deckId/cards/:cardId/move", h.MoveCardBetweenZones)
 }
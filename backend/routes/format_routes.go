@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterFormatRoutes(rg *gin.RouterGroup, h handlers.FormatHandler) {
+	rg = rg.Group("/formats")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/", h.GetFormats)
+	rg.GET("/:formatId", h.GetFormat)
+	rg.POST("/", h.CreateFormat)
+}
@@ -2,18 +2,23 @@
 package routes
 
 import (
+	"context"
+	"log"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/services"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter configures and returns the main application router
-func SetupRouter() *gin.Engine {
+// SetupRouter configures and returns the main application router. The background workers it starts stop when ctx is done.
+func SetupRouter(ctx context.Context) *gin.Engine {
 	router := gin.Default()
 
 	allowedOrigins := "http://localhost:5173,https://sw-2-yugi-collection-manager.vercel.app"
@@ -36,28 +41,71 @@ func SetupRouter() *gin.Engine {
 
 	cardRepo := repository.NewCardRepository()
 	cardFactory := services.NewCardFactory()
-	cardService := services.NewCardService(cardRepo, cardFactory)
+	ygoClient := client.NewYGOClient(client.Config{BaseURL: os.Getenv("YGOPRODECK_API_URL")})
+	imageStore, err := storage.NewImageStore(ctx, storage.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to configure image store: %v", err)
+	}
+	if local, ok := imageStore.(*storage.LocalImageStore); ok {
+		router.Static(storage.ImagesPath, local.Dir())
+	}
+	imageJobRepo := repository.NewImageJobRepository()
+	imageQueueService := services.NewImageQueueService(imageJobRepo, cardRepo, imageStore, services.ImageQueueConfig{})
+	imageQueueService.Start(ctx)
+	cardService := services.NewCardService(cardRepo, cardFactory, ygoClient, imageQueueService)
 	cardHandler := handlers.NewCardHandler(cardService)
+	cardImageService := services.NewCardImageService(cardRepo, imageStore)
+	cardImageHandler := handlers.NewCardImageHandler(cardImageService, imageQueueService)
 
-	deckRepo := repository.NewDeckRepository()
-	deckCardRepo := repository.NewDeckCardRepository()
-	deckCardService := services.NewDeckCardService(deckCardRepo)
-	deckService := services.NewDeckService(deckRepo, cardService, deckCardService)
-	deckHandler := handlers.NewDeckHandler(deckService)
+	catalogRepo := repository.NewCatalogRepository()
+	catalogService := services.NewCatalogService(catalogRepo, cardFactory, ygoClient)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
+
+	formatRepo := repository.NewFormatRepository()
+	formatService := services.NewFormatService(formatRepo)
+	if err := formatService.EnsureDefaultFormats(); err != nil {
+		log.Printf("Failed to create default formats: %v", err)
+	}
+	formatHandler := handlers.NewFormatHandler(formatService)
+
+	banlistRepo := repository.NewBanlistRepository()
+	banlistService := services.NewBanlistService(banlistRepo)
+	banlistHandler := handlers.NewBanlistHandler(banlistService)
 
 	collectionRepo := repository.NewCollectionRepository()
 	collectionService := services.NewCollectionService(collectionRepo)
 	collectionHandler := handlers.NewCollectionHandler(collectionService)
 
+	storageRepo := repository.NewStorageRepository()
+	storageService := services.NewStorageService(storageRepo)
+	storageHandler := handlers.NewStorageHandler(storageService)
+
+	tradeRepo := repository.NewTradeRepository()
+	tradeService := services.NewTradeService(tradeRepo, collectionRepo)
+	tradeHandler := handlers.NewTradeHandler(tradeService)
+
+	deckRepo := repository.NewDeckRepository()
+	deckCardRepo := repository.NewDeckCardRepository()
+	deckCardService := services.NewDeckCardService(deckCardRepo, formatService, banlistService)
+	deckService := services.NewDeckService(deckRepo, cardService, deckCardService, formatService, collectionService)
+	deckHandler := handlers.NewDeckHandler(deckService, formatService, banlistService)
+
 	statsService := services.NewStatsService(collectionService, deckService)
 	statsHandler := handlers.NewStatsHandler(statsService, deckService)
 
 	api := router.Group("/api")
 	RegisterAuthRoutes(api, authHandler)
-	RegisterCardRoutes(api, cardHandler)
+	RegisterCardRoutes(api, cardHandler, cardImageHandler)
+	RegisterArchetypeRoutes(api, cardHandler)
 	RegisterDeckRoutes(api, deckHandler)
+	RegisterBanlistRoutes(api, banlistHandler, userRepo)
+	RegisterFormatRoutes(api, formatHandler)
 	RegisterStatsRoutes(api, statsHandler)
 	RegisterCollectionRoutes(api, collectionHandler)
+	RegisterStorageRoutes(api, storageHandler)
+	RegisterWishlistRoutes(api, tradeHandler)
+	RegisterTradeRoutes(api, tradeHandler)
+	RegisterAdminRoutes(api, catalogHandler, cardImageHandler, userRepo)
 
 	return router
 }
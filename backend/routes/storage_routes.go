@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterStorageRoutes(rg *gin.RouterGroup, h handlers.StorageHandler) {
+	rg = rg.Group("/locations")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/", h.GetInventory)
+	rg.POST("/", h.CreateLocation)
+	rg.POST("/move", h.MoveCopies)
+	rg.GET("/cards/:cardId", h.GetCardLocations)
+	rg.GET("/:locationId/cards", h.GetLocationCards)
+	rg.DELETE("/:locationId", h.DeleteLocation)
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTradeRoutes(rg *gin.RouterGroup, h handlers.TradeHandler) {
+	rg = rg.Group("/trades")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/tradeable", h.GetTradeable)
+	rg.GET("/matches", h.GetMatches)
+}
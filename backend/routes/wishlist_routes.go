@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/handlers"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWishlistRoutes(rg *gin.RouterGroup, h handlers.TradeHandler) {
+	rg = rg.Group("/wishlist")
+	rg.Use(middleware.AuthMiddleware())
+	rg.GET("/", h.GetWishlist)
+	rg.POST("/", h.AddToWishlist)
+	rg.DELETE("/:entryId", h.RemoveFromWishlist)
+}
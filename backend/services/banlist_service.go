@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBanlistNotFound = errors.New("banlist not found")
+	ErrInvalidBanlist  = errors.New("invalid banlist file")
+	ErrCardForbidden   = errors.New("card is forbidden by the active banlist")
+)
+
+// LegalityViolation describes a card whose quantity in a deck exceeds what the banlist allows.
+type LegalityViolation struct {
+	CardID    uint   `json:"card_id"`
+	CardYGOID int    `json:"card_ygo_id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Quantity  int    `json:"quantity"`
+	Limit     int    `json:"limit"`
+}
+
+// LegalityReport is the result of checking a deck against its format and a banlist.
+type LegalityReport struct {
+	FormatID         uint                `json:"format_id"`
+	FormatName       string              `json:"format_name"`
+	BanlistID        uint                `json:"banlist_id,omitempty"`
+	BanlistName      string              `json:"banlist_name,omitempty"`
+	Legal            bool                `json:"legal"`
+	Violations       []LegalityViolation `json:"violations"`
+	FormatViolations []FormatViolation   `json:"format_violations"`
+}
+
+// AddFormatViolations attaches deck size violations to the report and updates its verdict.
+func (r *LegalityReport) AddFormatViolations(violations []FormatViolation) {
+	r.FormatViolations = append(r.FormatViolations, violations...)
+	r.Legal = len(r.Violations) == 0 && len(r.FormatViolations) == 0
+}
+
+// BanlistService defines operations to import banlists and check cards and decks against them.
+type BanlistService interface {
+	ImportBanlist(name, format string, effectiveDate time.Time, fileType string, file io.Reader) (*models.Banlist, error)
+	GetBanlists() ([]models.Banlist, error)
+	GetBanlist(id uint) (*models.Banlist, error)
+	GetActiveBanlist(format string) (*models.Banlist, error)
+	GetCardLimit(format *models.Format, cardYGOID int) (int, error)
+	CheckDeckLegality(format *models.Format, banlist *models.Banlist, deckCards []models.DeckCard) *LegalityReport
+}
+
+type banlistService struct {
+	repo repository.BanlistRepository
+}
+
+// NewBanlistService creates a new instance of banlistService.
+func NewBanlistService(repo repository.BanlistRepository) BanlistService {
+	return &banlistService{repo: repo}
+}
+
+// ImportBanlist parses a JSON or CSV banlist file and stores it for the given format and effective date.
+func (s *banlistService) ImportBanlist(name, format string, effectiveDate time.Time, fileType string, file io.Reader) (*models.Banlist, error) {
+	format = strings.ToUpper(strings.TrimSpace(format))
+	if format == "" {
+		return nil, fmt.Errorf("%w: format is required", ErrInvalidBanlist)
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s %s", format, effectiveDate.Format("2006-01-02"))
+	}
+
+	var (
+		rows []utils.BanlistFileEntry
+		err  error
+	)
+	switch strings.ToLower(fileType) {
+	case "json":
+		rows, err = utils.ParseBanlistJSON(file)
+	case "csv":
+		rows, err = utils.ParseBanlistCSV(file)
+	default:
+		return nil, fmt.Errorf("%w: unsupported file type %q", ErrInvalidBanlist, fileType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBanlist, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no cards found", ErrInvalidBanlist)
+	}
+
+	banlist := &models.Banlist{
+		Name:          name,
+		Format:        format,
+		EffectiveDate: effectiveDate,
+	}
+
+	seen := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		if row.CardYGOID <= 0 {
+			return nil, fmt.Errorf("%w: invalid card ID for %q", ErrInvalidBanlist, row.Name)
+		}
+		if seen[row.CardYGOID] {
+			return nil, fmt.Errorf("%w: card %d is listed more than once", ErrInvalidBanlist, row.CardYGOID)
+		}
+		seen[row.CardYGOID] = true
+
+		status, err := NormalizeBanStatus(row.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%w: card %d: %v", ErrInvalidBanlist, row.CardYGOID, err)
+		}
+
+		banlist.Entries = append(banlist.Entries, models.BanlistEntry{
+			CardYGOID: row.CardYGOID,
+			Name:      row.Name,
+			Status:    status,
+		})
+	}
+
+	if err := s.repo.Create(banlist); err != nil {
+		return nil, fmt.Errorf("failed to save banlist: %w", err)
+	}
+
+	return banlist, nil
+}
+
+// GetBanlists returns all stored banlists, newest first.
+func (s *banlistService) GetBanlists() ([]models.Banlist, error) {
+	return s.repo.FindAll()
+}
+
+// GetBanlist returns a banlist and its entries by ID.
+func (s *banlistService) GetBanlist(id uint) (*models.Banlist, error) {
+	banlist, err := s.repo.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBanlistNotFound
+	}
+	return banlist, err
+}
+
+// GetActiveBanlist returns the banlist currently in effect for a format, including its entries.
+func (s *banlistService) GetActiveBanlist(format string) (*models.Banlist, error) {
+	active, err := s.repo.FindActive(strings.ToUpper(format), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrBanlistNotFound
+	}
+	return s.GetBanlist(active.ID)
+}
+
+// GetCardLimit returns how many copies of a card a deck of the given format may contain
+// under the banlist currently in effect. Cards not listed are capped by the format's copy limit.
+func (s *banlistService) GetCardLimit(format *models.Format, cardYGOID int) (int, error) {
+	if format.BanlistFormat == "" {
+		return format.MaxCopies, nil
+	}
+
+	active, err := s.repo.FindActive(strings.ToUpper(format.BanlistFormat), time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to load active banlist: %w", err)
+	}
+	if active == nil {
+		return format.MaxCopies, nil
+	}
+
+	entry, err := s.repo.GetEntry(active.ID, cardYGOID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load banlist entry: %w", err)
+	}
+	if entry == nil {
+		return format.MaxCopies, nil
+	}
+	return CopyLimitForStatus(entry.Status, format.MaxCopies), nil
+}
+
+// CheckDeckLegality reports every card in the deck whose total quantity exceeds the limit
+// set by the banlist and the format. The banlist may be nil, in which case only the format's
+// copy limit applies.
+func (s *banlistService) CheckDeckLegality(format *models.Format, banlist *models.Banlist, deckCards []models.DeckCard) *LegalityReport {
+	report := &LegalityReport{
+		FormatID:         format.ID,
+		FormatName:       format.Name,
+		Violations:       []LegalityViolation{},
+		FormatViolations: []FormatViolation{},
+	}
+
+	statuses := make(map[int]string)
+	if banlist != nil {
+		report.BanlistID = banlist.ID
+		report.BanlistName = banlist.Name
+		for _, entry := range banlist.Entries {
+			statuses[entry.CardYGOID] = entry.Status
+		}
+	}
+
+	// A card may appear in several zones; the limit applies to the whole deck.
+	totals := make(map[uint]int)
+	var order []models.Card
+	for _, dc := range deckCards {
+		if _, ok := totals[dc.CardID]; !ok {
+			order = append(order, dc.Card)
+		}
+		totals[dc.CardID] += dc.Quantity
+	}
+
+	for _, card := range order {
+		status, ok := statuses[card.CardYGOID]
+		if !ok {
+			status = models.BanStatusUnlimited
+		}
+		limit := CopyLimitForStatus(status, format.MaxCopies)
+		if totals[card.ID] > limit {
+			report.Violations = append(report.Violations, LegalityViolation{
+				CardID:    card.ID,
+				CardYGOID: card.CardYGOID,
+				Name:      card.Name,
+				Status:    status,
+				Quantity:  totals[card.ID],
+				Limit:     limit,
+			})
+		}
+	}
+
+	report.Legal = len(report.Violations) == 0
+	return report
+}
+
+// NormalizeBanStatus maps the usual spellings of a banlist status to one of the models.BanStatus constants.
+func NormalizeBanStatus(status string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(status))
+	normalized = strings.NewReplacer("_", "-", " ", "-").Replace(normalized)
+
+	switch normalized {
+	case "forbidden", "banned", "0":
+		return models.BanStatusForbidden, nil
+	case "limited", "1":
+		return models.BanStatusLimited, nil
+	case "semi-limited", "semilimited", "2":
+		return models.BanStatusSemiLimited, nil
+	case "unlimited", "3":
+		return models.BanStatusUnlimited, nil
+	default:
+		return "", fmt.Errorf("unknown banlist status %q", status)
+	}
+}
+
+// CopyLimitForStatus returns the number of copies allowed for a banlist status,
+// never exceeding the copy limit of the format.
+func CopyLimitForStatus(status string, maxCopies int) int {
+	switch status {
+	case models.BanStatusForbidden:
+		return 0
+	case models.BanStatusLimited:
+		return min(1, maxCopies)
+	case models.BanStatusSemiLimited:
+		return min(2, maxCopies)
+	default:
+		return maxCopies
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func Test_banlistService_ImportBanlist(t *testing.T) {
+	db := utils.SetupTestDB(&models.Banlist{}, &models.BanlistEntry{})
+	service := NewBanlistService(repository.NewBanlistRepositoryWithDB(db))
+	date := time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("imports a CSV banlist with header", func(t *testing.T) {
+		file := "id,name,status\n14558127,Ash Blossom & Joyous Spring,Semi-Limited\n55144522,Pot of Greed,Forbidden\n"
+		banlist, err := service.ImportBanlist("", "tcg", date, "csv", strings.NewReader(file))
+		require.NoError(t, err)
+
+		assert.Equal(t, "TCG", banlist.Format)
+		assert.Equal(t, "TCG 2025-04-07", banlist.Name)
+		require.Len(t, banlist.Entries, 2)
+		assert.Equal(t, models.BanStatusSemiLimited, banlist.Entries[0].Status)
+		assert.Equal(t, models.BanStatusForbidden, banlist.Entries[1].Status)
+	})
+
+	t.Run("imports a JSON banlist", func(t *testing.T) {
+		file := `[{"id": 83764718, "name": "Monster Reborn", "status": "limited"}]`
+		banlist, err := service.ImportBanlist("OCG April", "OCG", date, "json", strings.NewReader(file))
+		require.NoError(t, err)
+		require.Len(t, banlist.Entries, 1)
+		assert.Equal(t, models.BanStatusLimited, banlist.Entries[0].Status)
+	})
+
+	t.Run("rejects unknown statuses", func(t *testing.T) {
+		file := "55144522,Pot of Greed,Sometimes\n"
+		_, err := service.ImportBanlist("", "TCG", date, "csv", strings.NewReader(file))
+		assert.ErrorIs(t, err, ErrInvalidBanlist)
+	})
+}
+
+func Test_banlistService_GetCardLimit(t *testing.T) {
+	db := utils.SetupTestDB(&models.Banlist{}, &models.BanlistEntry{})
+	service := NewBanlistService(repository.NewBanlistRepositoryWithDB(db))
+
+	old := &models.Banlist{
+		Name: "Old", Format: "TCG", EffectiveDate: time.Now().AddDate(-1, 0, 0),
+		Entries: []models.BanlistEntry{{CardYGOID: 55144522, Status: models.BanStatusLimited}},
+	}
+	current := &models.Banlist{
+		Name: "Current", Format: "TCG", EffectiveDate: time.Now().AddDate(0, -1, 0),
+		Entries: []models.BanlistEntry{{CardYGOID: 55144522, Status: models.BanStatusForbidden}},
+	}
+	future := &models.Banlist{
+		Name: "Future", Format: "TCG", EffectiveDate: time.Now().AddDate(0, 1, 0),
+		Entries: []models.BanlistEntry{{CardYGOID: 55144522, Status: models.BanStatusUnlimited}},
+	}
+	utils.SeedTestData(db, old, current, future)
+
+	tcg := &DefaultFormats[0]
+	limit, err := service.GetCardLimit(tcg, 55144522)
+	require.NoError(t, err)
+	assert.Equal(t, 0, limit)
+
+	limit, err = service.GetCardLimit(tcg, 46986414)
+	require.NoError(t, err)
+	assert.Equal(t, 3, limit)
+
+	highlander := &models.Format{Name: "Highlander", BanlistFormat: "TCG", MaxCopies: 1}
+	limit, err = service.GetCardLimit(highlander, 46986414)
+	require.NoError(t, err)
+	assert.Equal(t, 1, limit)
+
+	goat := &models.Format{Name: "Goat", BanlistFormat: "GOAT", MaxCopies: 3}
+	limit, err = service.GetCardLimit(goat, 55144522)
+	require.NoError(t, err)
+	assert.Equal(t, 3, limit)
+}
+
+func Test_banlistService_CheckDeckLegality(t *testing.T) {
+	service := &banlistService{}
+
+	banlist := &models.Banlist{
+		ID: 1, Name: "TCG", Format: "TCG",
+		Entries: []models.BanlistEntry{
+			{CardYGOID: 1, Status: models.BanStatusForbidden},
+			{CardYGOID: 2, Status: models.BanStatusLimited},
+		},
+	}
+	deckCards := []models.DeckCard{
+		{CardID: 10, Quantity: 1, Zone: "main", Card: models.Card{Model: gorm.Model{ID: 10}, CardYGOID: 1, Name: "Forbidden Card"}},
+		{CardID: 20, Quantity: 1, Zone: "main", Card: models.Card{Model: gorm.Model{ID: 20}, CardYGOID: 2, Name: "Limited Card"}},
+		{CardID: 30, Quantity: 3, Zone: "main", Card: models.Card{Model: gorm.Model{ID: 30}, CardYGOID: 3, Name: "Unlimited Card"}},
+	}
+
+	report := service.CheckDeckLegality(&DefaultFormats[0], banlist, deckCards)
+	assert.False(t, report.Legal)
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, "Forbidden Card", report.Violations[0].Name)
+	assert.Equal(t, 0, report.Violations[0].Limit)
+
+	deckCards[1].Quantity = 2
+	report = service.CheckDeckLegality(&DefaultFormats[0], banlist, deckCards[1:])
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, models.BanStatusLimited, report.Violations[0].Status)
+}
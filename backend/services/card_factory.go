@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"log"
+	"strings"
 
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
@@ -29,7 +30,9 @@ func (f *cardFactory) BuildCardFromAPI(apiCard *client.APICard, imageURL string)
 		Desc:      apiCard.Desc,
 		FrameType: apiCard.FrameType,
 		Type:      apiCard.Type,
+		Archetype: apiCard.Archetype,
 		ImageURL:  imageURL,
+		Printings: buildPrintings(apiCard.CardSets),
 	}
 
 	switch {
@@ -92,3 +95,27 @@ func (f *cardFactory) buildPendulum(api *client.APICard) *models.PendulumMonster
 		Scale:     api.Scale,
 	}
 }
+
+// buildPrintings creates the printings of a card from its card_sets, with their sets. The set code of a set
+// is the part of its printings' codes before the dash, e.g. "LOB" for "LOB-001". Repeated printings are
+// listed once.
+func buildPrintings(apiSets []client.APICardSet) []models.CardPrinting {
+	var printings []models.CardPrinting
+	seen := make(map[[2]string]bool, len(apiSets))
+	for _, apiSet := range apiSets {
+		key := [2]string{apiSet.SetCode, apiSet.SetRarity}
+		if apiSet.SetName == "" || apiSet.SetCode == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		code, _, _ := strings.Cut(apiSet.SetCode, "-")
+		printings = append(printings, models.CardPrinting{
+			SetCode:    apiSet.SetCode,
+			Rarity:     apiSet.SetRarity,
+			RarityCode: apiSet.SetRarityCode,
+			CardSet:    &models.CardSet{Name: apiSet.SetName, Code: code},
+		})
+	}
+	return printings
+}
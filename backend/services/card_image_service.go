@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+)
+
+const imageBackfillBatchSize = 100
+
+// ImageBackfillFailure describes a card whose image derivatives could not be created.
+type ImageBackfillFailure struct {
+	CardYGOID int    `json:"card_ygo_id"`
+	Name      string `json:"name"`
+	Error     string `json:"error"`
+}
+
+// ImageBackfillReport summarizes a backfill run.
+type ImageBackfillReport struct {
+	Processed int                    `json:"processed"`
+	Updated   int                    `json:"updated"`
+	Failed    []ImageBackfillFailure `json:"failed"`
+}
+
+// CardImageService defines maintenance operations on stored card images.
+type CardImageService interface {
+	BackfillDerivatives(ctx context.Context, limit int) (*ImageBackfillReport, error)
+}
+
+type cardImageService struct {
+	repo   repository.CardRepository
+	images storage.ImageStore
+}
+
+func NewCardImageService(repo repository.CardRepository, images storage.ImageStore) CardImageService {
+	return &cardImageService{repo: repo, images: images}
+}
+
+// BackfillDerivatives creates the thumbnail and cropped artwork of cards that do not have them yet, processing
+// at most limit cards, or all of them when limit is 0.
+// Derivatives are made from the stored image. Cards whose image was never stored, such as those added by a
+// catalog sync, get their image downloaded and stored first. Failed cards are reported and skipped.
+func (s *cardImageService) BackfillDerivatives(ctx context.Context, limit int) (*ImageBackfillReport, error) {
+	report := &ImageBackfillReport{Failed: []ImageBackfillFailure{}}
+
+	var afterID uint
+	for limit == 0 || report.Processed < limit {
+		batchSize := imageBackfillBatchSize
+		if limit > 0 && limit-report.Processed < batchSize {
+			batchSize = limit - report.Processed
+		}
+
+		cards, err := s.repo.GetWithoutDerivatives(afterID, batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cards: %w", err)
+		}
+		if len(cards) == 0 {
+			break
+		}
+
+		for i := range cards {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+
+			card := &cards[i]
+			afterID = card.ID
+			report.Processed++
+
+			if err := s.backfillCard(ctx, card); err != nil {
+				report.Failed = append(report.Failed, ImageBackfillFailure{CardYGOID: card.CardYGOID, Name: card.Name, Error: err.Error()})
+				continue
+			}
+			report.Updated++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *cardImageService) backfillCard(ctx context.Context, card *models.Card) error {
+	imageURL := card.ImageURL
+
+	data, err := s.images.Get(ctx, storage.CardImageKey(card.CardYGOID))
+	switch {
+	case err == nil:
+		thumbnailURL, artURL, err := storage.StoreCardDerivatives(ctx, s.images, card.CardYGOID, card.FrameType, data)
+		if err != nil {
+			return err
+		}
+		return s.repo.UpdateImages(card.ID, imageURL, thumbnailURL, artURL)
+
+	case errors.Is(err, storage.ErrImageNotFound):
+		if !strings.HasPrefix(imageURL, "http://") && !strings.HasPrefix(imageURL, "https://") {
+			return fmt.Errorf("image is not stored and %q cannot be downloaded", imageURL)
+		}
+		urls, err := storage.StoreCardImages(ctx, s.images, card.CardYGOID, card.FrameType, imageURL)
+		if err != nil {
+			return err
+		}
+		return s.repo.UpdateImages(card.ID, urls.Image, urls.Thumbnail, urls.Art)
+
+	default:
+		return err
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cardImageService_BackfillDerivatives(t *testing.T) {
+	db := utils.SetupTestDB(&models.Card{})
+
+	var cardImage bytes.Buffer
+	require.NoError(t, jpeg.Encode(&cardImage, image.NewRGBA(image.Rect(0, 0, 421, 614)), nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(cardImage.Bytes())
+	}))
+	defer server.Close()
+
+	images, err := storage.NewLocalImageStore(t.TempDir(), "")
+	require.NoError(t, err)
+	storedURL, err := images.Put(context.Background(), storage.CardImageKey(46986414), cardImage.Bytes(), "image/jpeg")
+	require.NoError(t, err)
+
+	stored := models.Card{CardYGOID: 46986414, Name: "Dark Magician", FrameType: "normal", ImageURL: storedURL}
+	synced := models.Card{CardYGOID: 83764718, Name: "Monster Reborn", FrameType: "spell", ImageURL: server.URL + "/83764718.jpg"}
+	lost := models.Card{CardYGOID: 1, Name: "Lost Card", FrameType: "normal", ImageURL: "/images/cards/1.jpg"}
+	done := models.Card{CardYGOID: 2, Name: "Done Card", FrameType: "normal", ImageURL: "/images/cards/2.jpg",
+		ThumbnailURL: "/images/cards/small/2.jpg", ArtURL: "/images/cards/art/2.jpg"}
+	utils.SeedTestData(db, &stored, &synced, &lost, &done)
+
+	service := NewCardImageService(repository.NewCardRepositoryWithDB(db), images)
+
+	report, err := service.BackfillDerivatives(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Processed)
+	assert.Equal(t, 2, report.Updated)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, 1, report.Failed[0].CardYGOID)
+
+	var magician models.Card
+	require.NoError(t, db.First(&magician, stored.ID).Error)
+	assert.Equal(t, storedURL, magician.ImageURL)
+	assert.Equal(t, "/images/cards/small/46986414.jpg", magician.ThumbnailURL)
+	assert.Equal(t, "/images/cards/art/46986414.jpg", magician.ArtURL)
+
+	var reborn models.Card
+	require.NoError(t, db.First(&reborn, synced.ID).Error)
+	assert.Equal(t, "/images/cards/83764718.jpg", reborn.ImageURL)
+	assert.Equal(t, "/images/cards/small/83764718.jpg", reborn.ThumbnailURL)
+
+	report, err = service.BackfillDerivatives(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Processed)
+	assert.Equal(t, 0, report.Updated)
+}
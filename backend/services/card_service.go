@@ -1,34 +1,69 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"golang.org/x/sync/singleflight"
 )
 
+// maxCardSuggestions is the number of card names suggested when a name matches no card.
+const maxCardSuggestions = 5
+
+// similarCardCandidates is the number of stored cards compared with a name that matches no card exactly.
+const similarCardCandidates = 10
+
+// closestCardMargin is how many more edits than the closest stored card the next closest one must be
+// from a misspelt name for the name to resolve to the closest card.
+const closestCardMargin = 2
+
 // CardService defines the operations available to manage cards.
 // Implementations should be responsible for obtaining, filtering and persisting cards.
 type CardService interface {
 	GetCardByID(id uint) (*models.Card, error)
-	GetCardByYGOID(id int) (*models.Card, error)
-	GetCardByName(name string) (*models.Card, error)
-	GetCards(limit, offset int) ([]*models.Card, error)
+	GetCardByYGOID(ctx context.Context, id int) (*models.Card, error)
+	LookupCardByYGOID(ctx context.Context, id int) (*models.Card, error)
+	GetCardByName(ctx context.Context, name string) (*models.Card, error)
+	LookupCardByName(ctx context.Context, name string) (*models.Card, error)
+	SuggestCardNames(name string) ([]string, error)
+	GetCards(page repository.Page) ([]*models.Card, string, error)
 	CountAllCards() (int64, error)
-	GetFilteredCards(name, cardType, frameType string, limit, offset int) ([]*models.Card, error)
-	CountFilteredCards(name, cardType, frameType string) (int64, error)
+	GetFilteredCards(ctx context.Context, query repository.CardQuery, page repository.Page) ([]*models.Card, string, error)
+	FindStoredCards(query repository.CardQuery, page repository.Page) ([]*models.Card, string, error)
+	CountFilteredCards(query repository.CardQuery) (int64, error)
+	GetArchetypes(name string) ([]Archetype, error)
+}
+
+// Archetype is a card archetype, such as "Blue-Eyes", and how many cards belong to it.
+type Archetype struct {
+	Name      string `json:"name"`
+	CardCount int64  `json:"card_count"`
 }
 
 type cardService struct {
-	repo    repository.CardRepository
-	factory CardFactory
+	repo      repository.CardRepository
+	factory   CardFactory
+	ygoClient client.YGOClient
+
+	// inflight coalesces concurrent fetches of the same missing card.
+	inflight   singleflight.Group
+	imageQueue ImageQueueService
 }
 
-func NewCardService(repo repository.CardRepository, factory CardFactory) CardService {
-	return &cardService{repo, factory}
+func NewCardService(repo repository.CardRepository, factory CardFactory, ygoClient client.YGOClient, imageQueue ImageQueueService) CardService {
+	return &cardService{
+		repo:       repo,
+		factory:    factory,
+		ygoClient:  ygoClient,
+		imageQueue: imageQueue,
+	}
 }
 
 // GetCardByID retrieves a card from the database using its internal database ID.
@@ -39,116 +74,204 @@ func (s *cardService) GetCardByID(id uint) (*models.Card, error) {
 
 // GetCardByYGOID retrieves a card by its YGOProDeck ID.
 // If the card does not exist in the local database, it attempts to fetch it from the external API,
-// uploads the image to S3, builds the card, saves it, and returns the resulting model.
-func (s *cardService) GetCardByYGOID(id int) (*models.Card, error) {
-	// Paso 1: buscar en base de datos
+// builds the card, saves it, queues the download of its image, and returns the resulting model.
+// Concurrent calls for the same missing card share a single fetch.
+func (s *cardService) GetCardByYGOID(ctx context.Context, id int) (*models.Card, error) {
 	card, err := s.repo.GetByYGOProID(id)
 	if err == nil && card != nil {
 		return card, nil
 	}
 
-	// Paso 2: fetch desde API externa
-	apiCard, err := client.FetchCardByIDOrName(id, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch card from API: %w", err)
-	}
+	return s.coalesce(ctx, passcodeKey(id), func(ctx context.Context) (*models.Card, error) {
+		// Another request may have saved the card while this one was waiting to start.
+		if card, err := s.repo.GetByYGOProID(id); err == nil && card != nil {
+			return card, nil
+		}
 
-	if apiCard == nil || apiCard.ImageURL == "" {
-		return nil, fmt.Errorf("invalid API response: missing card or image")
+		apiCard, err := s.ygoClient.FetchCardByIDOrName(ctx, id, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch card from API: %w", err)
+		}
+		return s.saveAPICard(apiCard)
+	})
+}
+
+// LookupCardByYGOID resolves a card by its YGOProDeck ID like GetCardByYGOID, but cards missing
+// from the local database are built from the external API without uploading their image or saving them.
+// The returned card has no database ID in that case.
+func (s *cardService) LookupCardByYGOID(ctx context.Context, id int) (*models.Card, error) {
+	card, err := s.repo.GetByYGOProID(id)
+	if err == nil && card != nil {
+		return card, nil
 	}
 
-	imageURL, err := utils.UploadCardImageToS3(apiCard.ID, apiCard.ImageURL)
+	apiCard, err := s.ygoClient.FetchCardByIDOrName(ctx, id, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload image to S3: %w", err)
+		return nil, fmt.Errorf("failed to fetch card from API: %w", err)
 	}
 
-	card = s.factory.BuildCardFromAPI(apiCard, imageURL)
+	return s.factory.BuildCardFromAPI(apiCard, apiCard.ImageURL), nil
+}
 
-	if err := s.repo.Create(card); err != nil {
-		return nil, fmt.Errorf("failed to save card to database: %w", err)
+// IsUnknownCard reports whether a card lookup failed because the external API has no such card,
+// rather than because the API or the database could not be reached.
+func IsUnknownCard(err error) bool {
+	return errors.Is(err, client.ErrCardNotFound) || errors.Is(err, client.ErrBadRequestFromAPI)
+}
+
+// GetCardByName retrieves a card by its name.
+// If not found in the database, a stored card whose name is clearly the one meant is returned, see closestCard.
+// Otherwise it tries to fetch it from the external API, builds the card, saves it, queues the download
+// of its image, and returns it.
+// Concurrent calls for the same name, ignoring case and punctuation, share a single fetch.
+func (s *cardService) GetCardByName(ctx context.Context, name string) (*models.Card, error) {
+	card, err := s.repo.GetByName(name)
+	if err == nil && card != nil {
+		return card, nil
 	}
 
-	return card, nil
+	if card := s.closestCard(name); card != nil {
+		return card, nil
+	}
+
+	return s.coalesce(ctx, "name:"+utils.NormalizeCardName(name), func(ctx context.Context) (*models.Card, error) {
+		apiCard, err := s.ygoClient.FetchCardByIDOrName(ctx, 0, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch card from API: %w", err)
+		}
+
+		// The name may be a different spelling of a card that is already stored or being fetched.
+		return s.coalesce(ctx, passcodeKey(apiCard.ID), func(context.Context) (*models.Card, error) {
+			if card, err := s.repo.GetByYGOProID(apiCard.ID); err == nil && card != nil {
+				return card, nil
+			}
+			return s.saveAPICard(apiCard)
+		})
+	})
 }
 
-// GetCardByName retrieves a card by its name.
-// If not found in the database, it tries to fetch it from the external API,
-// uploads the image to S3, builds the card, saves it, and returns it.
-func (s *cardService) GetCardByName(name string) (*models.Card, error) {
+// LookupCardByName resolves a card by its exact name like GetCardByName, but cards missing from the
+// local database are built from the external API without uploading their image or saving them.
+// The returned card has no database ID in that case.
+func (s *cardService) LookupCardByName(ctx context.Context, name string) (*models.Card, error) {
 	card, err := s.repo.GetByName(name)
 	if err == nil && card != nil {
 		return card, nil
 	}
 
-	apiCard, err := client.FetchCardByIDOrName(0, name)
+	apiCard, err := s.ygoClient.FetchCardByIDOrName(ctx, 0, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch card from API: %w", err)
 	}
 
-	if apiCard == nil || apiCard.ImageURL == "" {
-		return nil, fmt.Errorf("invalid API response: missing card or image")
+	// The name may be a different spelling of a card that is already stored.
+	if card, err := s.repo.GetByYGOProID(apiCard.ID); err == nil && card != nil {
+		return card, nil
 	}
+	return s.factory.BuildCardFromAPI(apiCard, apiCard.ImageURL), nil
+}
 
-	imageURL, err := utils.UploadCardImageToS3(apiCard.ID, apiCard.ImageURL)
+// closestCard returns the stored card whose name is the given one ignoring case and punctuation, or a close
+// misspelling of it: within one edit per ten characters, at least one, and closestCardMargin edits closer
+// than any other stored card. Names farther off may be those of cards that are not stored yet, such as
+// "Elemental HERO Neos" when only "Elemental HERO Nova" is stored.
+func (s *cardService) closestCard(name string) *models.Card {
+	candidates, err := s.repo.GetSimilarByName(name, similarCardCandidates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload image to S3: %w", err)
+		log.Printf("error matching card name %q: %v", name, err)
+		return nil
 	}
 
-	card = s.factory.BuildCardFromAPI(apiCard, imageURL)
-
-	if err := s.repo.Create(card); err != nil {
-		return nil, fmt.Errorf("failed to save card to database: %w", err)
+	target := utils.NormalizeCardName(name)
+	var closest *models.Card
+	best, second := -1, -1
+	for i := range candidates {
+		distance := utils.EditDistance(target, utils.NormalizeCardName(candidates[i].Name))
+		switch {
+		case best < 0 || distance < best:
+			closest, best, second = &candidates[i], distance, best
+		case second < 0 || distance < second:
+			second = distance
+		}
 	}
 
-	return card, nil
+	if closest == nil || best > max(1, len(target)/10) {
+		return nil
+	}
+	if best > 0 && second >= 0 && second-best < closestCardMargin {
+		return nil
+	}
+	return closest
 }
 
-// GetCards retrieves all cards from the database using pagination.
-// If the total number of cards in the database is below a defined threshold,
-// it attempts to fetch a set of random cards from the external API and store them locally.
-func (s *cardService) GetCards(limit, offset int) ([]*models.Card, error) {
-	const minCardThreshold = 50
-	const cardsToFetch = 20
-
-	total, err := s.repo.CountAll()
+// SuggestCardNames returns the names of up to five stored cards similar to the given name, closest first,
+// to suggest when a name matches no card.
+func (s *cardService) SuggestCardNames(name string) ([]string, error) {
+	cards, err := s.repo.GetSimilarByName(name, maxCardSuggestions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count cards: %w", err)
+		return nil, fmt.Errorf("failed to find similar card names: %w", err)
 	}
 
-	if total < minCardThreshold {
-		randomCards, err := client.FetchRandomCards(cardsToFetch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch random cards: %w", err)
-		}
+	names := make([]string, 0, len(cards))
+	for _, card := range cards {
+		names = append(names, card.Name)
+	}
+	return names, nil
+}
 
-		for _, apiCard := range randomCards {
-			if len(apiCard.CardImages) == 0 || apiCard.CardImages[0].ImageURL == "" {
-				continue
-			}
+// saveAPICard saves a card fetched from the API with its remote image URL, and queues the download of the
+// image into the image store. The card keeps the remote URL until the download is done.
+func (s *cardService) saveAPICard(apiCard *client.APICard) (*models.Card, error) {
+	if apiCard == nil || apiCard.ImageURL == "" {
+		return nil, fmt.Errorf("invalid API response: missing card or image")
+	}
 
-			imageURL, err := utils.UploadCardImageToS3(apiCard.ID, apiCard.CardImages[0].ImageURL)
-			if err != nil {
-				log.Printf("error uploading image for card %s: %v", apiCard.Name, err)
-				continue
-			}
+	card := s.factory.BuildCardFromAPI(apiCard, apiCard.ImageURL)
 
-			card := s.factory.BuildCardFromAPI(&apiCard, imageURL)
-			if err := s.repo.Create(card); err != nil {
-				log.Printf("error saving card %s: %v", card.Name, err)
-			}
-		}
+	if err := s.repo.Create(card); err != nil {
+		return nil, fmt.Errorf("failed to save card to database: %w", err)
 	}
 
-	cards, err := s.repo.GetAll(limit, offset)
-	if err != nil {
-		return nil, err
+	// The card is usable without a stored image, and the backfill job picks up cards whose image was never queued.
+	if err := s.imageQueue.Enqueue(card, apiCard.ImageURL); err != nil {
+		log.Printf("error queueing image of card %s: %v", card.Name, err)
 	}
 
-	result := make([]*models.Card, 0, len(cards))
-	for i := range cards {
-		result = append(result, &cards[i])
+	return card, nil
+}
+
+// coalesce runs fetch once for all concurrent callers using the same key. Other callers may be waiting for
+// the fetch, so it is not cancelled with the context of the caller that started it; each caller stops
+// waiting when its own context is done instead. Each caller gets its own copy of the resulting card.
+func (s *cardService) coalesce(ctx context.Context, key string, fetch func(context.Context) (*models.Card, error)) (*models.Card, error) {
+	results := s.inflight.DoChan(key, func() (interface{}, error) {
+		return fetch(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		card := *result.Val.(*models.Card)
+		return &card, nil
 	}
-	return result, nil
+}
+
+func passcodeKey(id int) string {
+	return "id:" + strconv.Itoa(id)
+}
+
+// GetCards retrieves a page of cards from the database, and the cursor of the next page.
+// The catalog is filled by the catalog sync, see CatalogService.
+func (s *cardService) GetCards(page repository.Page) ([]*models.Card, string, error) {
+	cards, nextCursor, err := s.repo.GetAll(page)
+	if err != nil {
+		return nil, "", err
+	}
+	return cardPointers(cards), nextCursor, nil
 }
 
 // CountAllCards returns the total number of cards stored in the database.
@@ -156,63 +279,89 @@ func (s *cardService) CountAllCards() (int64, error) {
 	return s.repo.CountAll()
 }
 
-// GetFilteredCards returns cards that match the provided name, type, and frameType filters.
-// If no cards are found in the local database and a name is provided,
-// it attempts to fetch matching cards from the external API and stores them locally.
-func (s *cardService) GetFilteredCards(name, cardType, frameType string, limit, offset int) ([]*models.Card, error) {
-	cards, err := s.repo.GetFiltered(name, cardType, frameType, limit, offset)
+// GetFilteredCards returns cards that match the provided search query.
+// If no cards are found in the local database for a search by name alone,
+// it attempts to fetch matching cards from the external API, stores them locally, and returns them.
+// Searches with other filters never fall back to the API, so cards they exclude are not saved.
+// The cursor of the next page is returned with the cards, and is empty on the last page.
+func (s *cardService) GetFilteredCards(ctx context.Context, query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+	cards, nextCursor, err := s.repo.GetFiltered(query, page)
 	if err != nil {
-		return nil, fmt.Errorf("failed to filter cards from database: %w", err)
-	}
-
-	if len(cards) > 0 {
-		result := make([]*models.Card, 0, len(cards))
-		for i := range cards {
-			result = append(result, &cards[i])
-		}
-		return result, nil
+		return nil, "", fmt.Errorf("failed to filter cards from database: %w", err)
 	}
 
-	if name != "" {
-		apiCards, err := client.FetchCardsByName(name)
+	// Only a first page can be empty because the cards were never fetched.
+	if len(cards) == 0 && query.NameOnly() && page.Cursor == "" && page.Offset == 0 {
+		apiCards, err := s.ygoClient.FetchCardsByName(ctx, query.Name)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch cards from external API: %w", err)
+			return nil, "", fmt.Errorf("failed to fetch cards from external API: %w", err)
 		}
 
-		var fetched []*models.Card
 		for _, apiCard := range apiCards {
 			if len(apiCard.CardImages) == 0 || apiCard.CardImages[0].ImageURL == "" {
 				continue
 			}
 
-			imageURL, err := utils.UploadCardImageToS3(apiCard.ID, apiCard.CardImages[0].ImageURL)
+			apiCard.ImageURL = apiCard.CardImages[0].ImageURL
+			_, err := s.coalesce(ctx, passcodeKey(apiCard.ID), func(context.Context) (*models.Card, error) {
+				if card, err := s.repo.GetByYGOProID(apiCard.ID); err == nil && card != nil {
+					return card, nil
+				}
+				return s.saveAPICard(&apiCard)
+			})
 			if err != nil {
-				log.Printf("error uploading image for card %s: %v", apiCard.Name, err)
-				continue
-			}
-
-			card := s.factory.BuildCardFromAPI(&apiCard, imageURL)
-
-			if err := s.repo.Create(card); err != nil {
-				log.Printf("error saving card %s: %v", card.Name, err)
-				continue
+				log.Printf("error saving card %s: %v", apiCard.Name, err)
 			}
+		}
 
-			fetched = append(fetched, card)
+		cards, nextCursor, err = s.repo.GetFiltered(query, page)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to filter cards from database: %w", err)
 		}
+	}
+
+	return cardPointers(cards), nextCursor, nil
+}
 
-		return fetched, nil
+// FindStoredCards returns the stored cards that match the provided search query, like GetFilteredCards
+// but without ever fetching and saving cards from the external API.
+func (s *cardService) FindStoredCards(query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+	cards, nextCursor, err := s.repo.GetFiltered(query, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to filter cards from database: %w", err)
 	}
+	return cardPointers(cards), nextCursor, nil
+}
 
-	return []*models.Card{}, nil
+func cardPointers(cards []models.Card) []*models.Card {
+	result := make([]*models.Card, 0, len(cards))
+	for i := range cards {
+		result = append(result, &cards[i])
+	}
+	return result
 }
 
 // CountFilteredCards returns the number of cards in the database that match the provided filters.
 // Returns 0 and an error if the database query fails.
-func (s *cardService) CountFilteredCards(name, cardType, frameType string) (int64, error) {
-	count, err := s.repo.CountFiltered(name, cardType, frameType)
+func (s *cardService) CountFilteredCards(query repository.CardQuery) (int64, error) {
+	count, err := s.repo.CountFiltered(query)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count filtered cards: %w", err)
 	}
 	return count, nil
 }
+
+// GetArchetypes returns the archetypes of the stored cards whose name contains the given one, sorted by name,
+// or every archetype when name is empty.
+func (s *cardService) GetArchetypes(name string) ([]Archetype, error) {
+	counts, err := s.repo.GetArchetypes(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archetypes: %w", err)
+	}
+
+	archetypes := make([]Archetype, 0, len(counts))
+	for _, count := range counts {
+		archetypes = append(archetypes, Archetype{Name: count.Archetype, CardCount: count.Cards})
+	}
+	return archetypes, nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cardService_LookupCardByYGOID(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cardinfo.php", r.URL.Path)
+		if r.URL.Query().Get("id") != "83764718" && r.URL.Query().Get("name") != "Monster Reborn" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"id": 83764718, "name": "Monster Reborn", "type": "Spell Card", "frameType": "spell",
+			"card_images": [{"image_url": "https://example.com/83764718.jpg"}]}]}`))
+	}))
+	defer server.Close()
+
+	service := NewCardService(
+		repository.NewCardRepositoryWithDB(db),
+		NewCardFactory(),
+		client.NewYGOClient(client.Config{BaseURL: server.URL}),
+		nil,
+	)
+
+	card, err := service.LookupCardByYGOID(context.Background(), 83764718)
+	require.NoError(t, err)
+	assert.Equal(t, "Monster Reborn", card.Name)
+	assert.Equal(t, uint(0), card.ID)
+	require.NotNil(t, card.SpellTrapCard)
+
+	_, err = service.LookupCardByYGOID(context.Background(), 1)
+	assert.ErrorIs(t, err, client.ErrBadRequestFromAPI)
+
+	card, err = service.LookupCardByName(context.Background(), "Monster Reborn")
+	require.NoError(t, err)
+	assert.Equal(t, 83764718, card.CardYGOID)
+	assert.Equal(t, uint(0), card.ID)
+
+	var stored int64
+	db.Model(&models.Card{}).Count(&stored)
+	assert.Zero(t, stored)
+}
+
+func Test_cardService_GetCardByName_Fuzzy(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	utils.SeedTestData(db,
+		&models.Card{CardYGOID: 46986414, Name: "Dark Magician", FrameType: "normal"},
+		&models.Card{CardYGOID: 38033121, Name: "Dark Magician Girl", FrameType: "effect"},
+		&models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon", FrameType: "normal"},
+		&models.Card{CardYGOID: 23995346, Name: "Blue-Eyes Ultimate Dragon", FrameType: "fusion"},
+		&models.Card{CardYGOID: 12580477, Name: "Raigeki", FrameType: "spell"},
+		&models.Card{CardYGOID: 80344569, Name: "Elemental HERO Nova", FrameType: "effect"},
+	)
+
+	var apiCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	service := NewCardService(
+		repository.NewCardRepositoryWithDB(db),
+		NewCardFactory(),
+		client.NewYGOClient(client.Config{BaseURL: server.URL}),
+		nil,
+	)
+
+	card, err := service.GetCardByName(context.Background(), "Dark Magican")
+	require.NoError(t, err)
+	assert.Equal(t, "Dark Magician", card.Name)
+
+	card, err = service.GetCardByName(context.Background(), "blue eyes white dragon")
+	require.NoError(t, err)
+	assert.Equal(t, "Blue-Eyes White Dragon", card.Name)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&apiCalls))
+
+	// Names farther from a stored card may be other cards, and are looked up in the API.
+	_, err = service.GetCardByName(context.Background(), "Elemental HERO Neos")
+	assert.ErrorIs(t, err, client.ErrBadRequestFromAPI)
+	assert.True(t, IsUnknownCard(err))
+	_, err = service.GetCardByName(context.Background(), "Blue-Eyes Dragon")
+	assert.ErrorIs(t, err, client.ErrBadRequestFromAPI)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&apiCalls))
+
+	suggestions, err := service.SuggestCardNames("Blue-Eyes Dragon")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Blue-Eyes White Dragon", "Blue-Eyes Ultimate Dragon"}, suggestions)
+
+	suggestions, err = service.SuggestCardNames("Magician")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Dark Magician", "Dark Magician Girl"}, suggestions)
+
+	suggestions, err = service.SuggestCardNames("Exodia")
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+
+	// The index sees cards added after it was loaded.
+	utils.SeedTestData(db, &models.Card{CardYGOID: 33396948, Name: "Exodia the Forbidden One", FrameType: "effect"})
+	suggestions, err = service.SuggestCardNames("Exodia")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Exodia the Forbidden One"}, suggestions)
+}
+
+func Test_cardService_GetCardByYGOID_CoalescesConcurrentFetches(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.ImageJob{},
+	)
+	// Every connection to an in-memory SQLite database opens a new, empty database.
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	var apiCalls, downloads int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/83764718.jpg" {
+			atomic.AddInt32(&downloads, 1)
+			w.Header().Set("Content-Type", "image/jpeg")
+			_ = jpeg.Encode(w, image.NewRGBA(image.Rect(0, 0, 421, 614)), nil)
+			return
+		}
+		atomic.AddInt32(&apiCalls, 1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"data": [{"id": 83764718, "name": "Monster Reborn", "type": "Spell Card", "frameType": "spell",
+			"card_images": [{"image_url": "` + server.URL + `/83764718.jpg"}]}]}`))
+	}))
+	defer server.Close()
+
+	images, err := storage.NewLocalImageStore(t.TempDir(), "")
+	require.NoError(t, err)
+
+	cardRepo := repository.NewCardRepositoryWithDB(db)
+	queue := NewImageQueueService(repository.NewImageJobRepositoryWithDB(db), cardRepo, images, ImageQueueConfig{}).(*imageQueueService)
+	service := NewCardService(cardRepo, NewCardFactory(), client.NewYGOClient(client.Config{BaseURL: server.URL}), queue)
+
+	var wg sync.WaitGroup
+	cards := make([]*models.Card, 10)
+	errs := make([]error, len(cards))
+	for i := range cards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cards[i], errs[i] = service.GetCardByYGOID(context.Background(), 83764718)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range cards {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "Monster Reborn", cards[i].Name)
+		assert.NotZero(t, cards[i].ID)
+		assert.Equal(t, server.URL+"/83764718.jpg", cards[i].ImageURL)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apiCalls))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&downloads))
+
+	var count int64
+	require.NoError(t, db.Model(&models.Card{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// The image is downloaded by the queue.
+	processed, err := queue.processNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+
+	card, err := service.GetCardByID(cards[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/images/cards/83764718.jpg", card.ImageURL)
+	assert.Equal(t, "/images/cards/small/83764718.jpg", card.ThumbnailURL)
+	assert.Equal(t, "/images/cards/art/83764718.jpg", card.ArtURL)
+}
+
+func Test_cardService_GetFilteredCards_FallsBackOnlyOnName(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.ImageJob{},
+	)
+
+	var apiCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		assert.Equal(t, "reborn", r.URL.Query().Get("fname"))
+		_, _ = w.Write([]byte(`{"data": [{"id": 83764718, "name": "Monster Reborn", "type": "Spell Card", "frameType": "spell",
+			"card_images": [{"image_url": "https://example.com/83764718.jpg"}]}]}`))
+	}))
+	defer server.Close()
+
+	images, err := storage.NewLocalImageStore(t.TempDir(), "")
+	require.NoError(t, err)
+
+	cardRepo := repository.NewCardRepositoryWithDB(db)
+	queue := NewImageQueueService(repository.NewImageJobRepositoryWithDB(db), cardRepo, images, ImageQueueConfig{})
+	service := NewCardService(cardRepo, NewCardFactory(), client.NewYGOClient(client.Config{BaseURL: server.URL}), queue)
+
+	// Filters the API results would not match do not save them.
+	atkMin := 1000
+	cards, _, err := service.GetFilteredCards(context.Background(), repository.CardQuery{Name: "reborn", AtkMin: &atkMin}, repository.Page{Limit: 20})
+	require.NoError(t, err)
+	assert.Empty(t, cards)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&apiCalls))
+
+	var stored int64
+	require.NoError(t, db.Model(&models.Card{}).Count(&stored).Error)
+	assert.Zero(t, stored)
+
+	cards, _, err = service.GetFilteredCards(context.Background(), repository.CardQuery{Name: "reborn"}, repository.Page{Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, "Monster Reborn", cards[0].Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&apiCalls))
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+)
+
+var ErrInvalidCatalog = errors.New("invalid card catalog")
+
+// CatalogChange identifies a card added, changed or removed by a catalog sync.
+type CatalogChange struct {
+	CardYGOID int    `json:"card_ygo_id"`
+	Name      string `json:"name"`
+}
+
+// CatalogSyncReport describes the outcome of a catalog sync, or what it would be for a dry run.
+// Removed cards are only deleted when the sync prunes the catalog.
+type CatalogSyncReport struct {
+	DryRun    bool            `json:"dry_run"`
+	Pruned    bool            `json:"pruned"`
+	Total     int             `json:"total"`
+	Unchanged int             `json:"unchanged"`
+	Added     []CatalogChange `json:"added"`
+	Changed   []CatalogChange `json:"changed"`
+	Removed   []CatalogChange `json:"removed"`
+}
+
+// CatalogService keeps the local card catalog in sync with a full YGOProDeck cardinfo.php dump.
+type CatalogService interface {
+	SyncFromURL(ctx context.Context, url string, prune, dryRun bool) (*CatalogSyncReport, error)
+	SyncFromFile(path string, prune, dryRun bool) (*CatalogSyncReport, error)
+	SyncFromReader(r io.Reader, prune, dryRun bool) (*CatalogSyncReport, error)
+}
+
+type catalogService struct {
+	repo      repository.CatalogRepository
+	factory   CardFactory
+	ygoClient client.YGOClient
+}
+
+// NewCatalogService creates a new instance of catalogService.
+func NewCatalogService(repo repository.CatalogRepository, factory CardFactory, ygoClient client.YGOClient) CatalogService {
+	return &catalogService{repo: repo, factory: factory, ygoClient: ygoClient}
+}
+
+// CatalogURL returns the cardinfo.php URL the catalog is synced from, set with CARD_CATALOG_URL.
+func CatalogURL() string {
+	if url := os.Getenv("CARD_CATALOG_URL"); url != "" {
+		return url
+	}
+	return client.CardInfoURL
+}
+
+// SyncFromURL downloads the full card database from url and syncs the catalog with it.
+func (s *catalogService) SyncFromURL(ctx context.Context, url string, prune, dryRun bool) (*CatalogSyncReport, error) {
+	apiCards, err := s.ygoClient.FetchCardCatalog(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return s.sync(apiCards, prune, dryRun)
+}
+
+// SyncFromFile syncs the catalog with a cardinfo.php dump saved on disk.
+func (s *catalogService) SyncFromFile(path string, prune, dryRun bool) (*CatalogSyncReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open card catalog: %w", err)
+	}
+	defer file.Close()
+
+	return s.SyncFromReader(file, prune, dryRun)
+}
+
+// SyncFromReader syncs the catalog with a cardinfo.php dump.
+func (s *catalogService) SyncFromReader(r io.Reader, prune, dryRun bool) (*CatalogSyncReport, error) {
+	apiCards, err := client.DecodeCardCatalog(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	return s.sync(apiCards, prune, dryRun)
+}
+
+// sync compares the dump with the stored cards by passcode. New cards are added with the image URL of the
+// dump, changed cards keep their stored image, and cards missing from the dump are reported as removed and
+// only deleted when prune is set. Removed cards that reappear in the dump are restored as changed. An empty
+// dump is rejected so that a failed download cannot empty the catalog.
+func (s *catalogService) sync(apiCards []client.APICard, prune, dryRun bool) (*CatalogSyncReport, error) {
+	if len(apiCards) == 0 {
+		return nil, fmt.Errorf("%w: no cards found", ErrInvalidCatalog)
+	}
+
+	stored, err := s.repo.GetCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load card catalog: %w", err)
+	}
+	existing := make(map[int]*models.Card, len(stored))
+	for i := range stored {
+		existing[stored[i].CardYGOID] = &stored[i]
+	}
+
+	report := &CatalogSyncReport{
+		DryRun:  dryRun,
+		Pruned:  prune,
+		Added:   []CatalogChange{},
+		Changed: []CatalogChange{},
+		Removed: []CatalogChange{},
+	}
+
+	var added, changed []*models.Card
+	seen := make(map[int]bool, len(apiCards))
+	for i := range apiCards {
+		apiCard := &apiCards[i]
+		if apiCard.ID == 0 || seen[apiCard.ID] {
+			continue
+		}
+		seen[apiCard.ID] = true
+		report.Total++
+
+		current, ok := existing[apiCard.ID]
+		if !ok {
+			added = append(added, s.factory.BuildCardFromAPI(apiCard, apiCard.ImageURL))
+			report.Added = append(report.Added, CatalogChange{CardYGOID: apiCard.ID, Name: apiCard.Name})
+			continue
+		}
+
+		card := s.factory.BuildCardFromAPI(apiCard, current.ImageURL)
+		if !current.DeletedAt.Valid && catalogFingerprint(card) == catalogFingerprint(current) {
+			report.Unchanged++
+			continue
+		}
+		card.ID = current.ID
+		changed = append(changed, card)
+		report.Changed = append(report.Changed, CatalogChange{CardYGOID: apiCard.ID, Name: apiCard.Name})
+	}
+
+	var removed []uint
+	for i := range stored {
+		if seen[stored[i].CardYGOID] || stored[i].DeletedAt.Valid {
+			continue
+		}
+		removed = append(removed, stored[i].ID)
+		report.Removed = append(report.Removed, CatalogChange{CardYGOID: stored[i].CardYGOID, Name: stored[i].Name})
+	}
+	if !prune {
+		removed = nil
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err := s.repo.ApplySync(added, changed, removed); err != nil {
+		return nil, fmt.Errorf("failed to save card catalog: %w", err)
+	}
+	return report, nil
+}
+
+// catalogFingerprint describes the catalog data of a card, ignoring database identifiers and the image.
+func catalogFingerprint(card *models.Card) string {
+	fingerprint := fmt.Sprintf("%s|%s|%s|%s|%s", card.Name, card.Desc, card.FrameType, card.Type, card.Archetype)
+	switch {
+	case card.MonsterCard != nil:
+		m := *card.MonsterCard
+		m.CardID = 0
+		fingerprint += fmt.Sprintf("|monster%+v", m)
+	case card.SpellTrapCard != nil:
+		st := *card.SpellTrapCard
+		st.CardID = 0
+		fingerprint += fmt.Sprintf("|spelltrap%+v", st)
+	case card.LinkMonsterCard != nil:
+		// Markers are stored as JSON, whose formatting the database may change.
+		l := *card.LinkMonsterCard
+		l.CardID = 0
+		var markers []string
+		if err := json.Unmarshal([]byte(l.LinkMarkers), &markers); err == nil {
+			l.LinkMarkers = strings.Join(markers, ",")
+		}
+		fingerprint += fmt.Sprintf("|link%+v", l)
+	case card.PendulumMonsterCard != nil:
+		p := *card.PendulumMonsterCard
+		p.CardID = 0
+		fingerprint += fmt.Sprintf("|pendulum%+v", p)
+	}
+
+	printings := make([]string, 0, len(card.Printings))
+	for _, printing := range card.Printings {
+		set := ""
+		if printing.CardSet != nil {
+			set = printing.CardSet.Name
+		}
+		printings = append(printings, fmt.Sprintf("%s/%s/%s/%s", printing.SetCode, printing.Rarity, printing.RarityCode, set))
+	}
+	sort.Strings(printings)
+	return fingerprint + "|printings" + strings.Join(printings, ",")
+}
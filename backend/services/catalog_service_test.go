@@ -0,0 +1,134 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const catalogDump = `{"data": [
+	{"id": 14558127, "name": "Ash Blossom & Joyous Spring", "type": "Tuner Monster", "frameType": "effect", "desc": "Negate.",
+	 "atk": 0, "def": 1800, "level": 3, "attribute": "FIRE", "race": "Zombie", "card_images": [{"image_url": "https://example.com/14558127.jpg"}]},
+	{"id": 1861629, "name": "Decode Talker", "type": "Link Monster", "frameType": "link", "desc": "Link.",
+	 "atk": 2300, "linkval": 3, "linkmarkers": ["Top", "Bottom-Left", "Bottom-Right"], "attribute": "DARK", "race": "Cyberse",
+	 "card_images": [{"image_url": "https://example.com/1861629.jpg"}]},
+	{"id": 83764718, "name": "Monster Reborn", "type": "Spell Card", "frameType": "spell", "desc": "Revive.", "race": "Normal",
+	 "card_images": [{"image_url": "https://example.com/83764718.jpg"}]}
+]}`
+
+func Test_catalogService_Sync(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+
+	stale := models.Card{CardYGOID: 14558127, Name: "Ash Blossom", Type: "Tuner Monster", FrameType: "effect", ImageURL: "https://s3/ash.jpg",
+		MonsterCard: &models.MonsterCard{Def: 1800, Level: 3, Attribute: "FIRE", Race: "Zombie"}}
+	gone := models.Card{CardYGOID: 11111111, Name: "Retired Card", Type: "Normal Monster", FrameType: "normal"}
+	utils.SeedTestData(db, &stale, &gone)
+
+	service := NewCatalogService(repository.NewCatalogRepositoryWithDB(db), NewCardFactory(), nil)
+
+	t.Run("dry run reports changes without saving", func(t *testing.T) {
+		report, err := service.SyncFromReader(strings.NewReader(catalogDump), true, true)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, report.Total)
+		assert.Len(t, report.Added, 2)
+		assert.Equal(t, []CatalogChange{{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring"}}, report.Changed)
+		assert.Equal(t, []CatalogChange{{CardYGOID: 11111111, Name: "Retired Card"}}, report.Removed)
+
+		var count int64
+		db.Model(&models.Card{}).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("upserts cards and keeps stored images", func(t *testing.T) {
+		_, err := service.SyncFromReader(strings.NewReader(catalogDump), true, false)
+		require.NoError(t, err)
+
+		var ash models.Card
+		require.NoError(t, db.Preload("MonsterCard").First(&ash, "card_ygo_id = ?", 14558127).Error)
+		assert.Equal(t, "Ash Blossom & Joyous Spring", ash.Name)
+		assert.Equal(t, "https://s3/ash.jpg", ash.ImageURL)
+		require.NotNil(t, ash.MonsterCard)
+
+		var link models.LinkMonsterCard
+		require.NoError(t, db.Joins("JOIN cards ON cards.id = link_monster_cards.card_id").First(&link, "cards.card_ygo_id = ?", 1861629).Error)
+		assert.Equal(t, 3, link.LinkValue)
+
+		var count int64
+		db.Model(&models.Card{}).Where("card_ygo_id = ?", 11111111).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("second sync finds nothing to do", func(t *testing.T) {
+		report, err := service.SyncFromReader(strings.NewReader(catalogDump), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Unchanged)
+		assert.Empty(t, report.Added)
+		assert.Empty(t, report.Changed)
+		assert.Empty(t, report.Removed)
+	})
+
+	t.Run("rejects an empty dump", func(t *testing.T) {
+		_, err := service.SyncFromReader(strings.NewReader(`{"data": []}`), true, false)
+		assert.ErrorIs(t, err, ErrInvalidCatalog)
+	})
+}
+
+func Test_catalogService_SyncPrintings(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.User{}, &models.UserCard{},
+	)
+	service := NewCatalogService(repository.NewCatalogRepositoryWithDB(db), NewCardFactory(), nil)
+
+	dump := func(sets string) string {
+		return `{"data": [{"id": 89631139, "name": "Blue-Eyes White Dragon", "type": "Normal Monster", "frameType": "normal",
+			"atk": 3000, "def": 2500, "level": 8, "attribute": "LIGHT", "race": "Dragon", "card_sets": [` + sets + `]}]}`
+	}
+	const lob = `{"set_name": "Legend of Blue Eyes White Dragon", "set_code": "LOB-001", "set_rarity": "Ultra Rare", "set_rarity_code": "(UR)"}`
+	const sdk = `{"set_name": "Starter Deck: Kaiba", "set_code": "SDK-001", "set_rarity": "Common", "set_rarity_code": "(C)"}`
+	const lc01 = `{"set_name": "Legendary Collection Kaiba Mega Pack", "set_code": "LCKC-EN001", "set_rarity": "Ultra Rare", "set_rarity_code": "(UR)"}`
+
+	_, err := service.SyncFromReader(strings.NewReader(dump(lob+","+sdk+","+sdk)), false, false)
+	require.NoError(t, err)
+
+	var printings []models.CardPrinting
+	require.NoError(t, db.Preload("CardSet").Order("set_code").Find(&printings).Error)
+	require.Len(t, printings, 2)
+	assert.Equal(t, "LOB-001", printings[0].SetCode)
+	assert.Equal(t, "Ultra Rare", printings[0].Rarity)
+	assert.Equal(t, "(UR)", printings[0].RarityCode)
+	require.NotNil(t, printings[0].CardSet)
+	assert.Equal(t, "Legend of Blue Eyes White Dragon", printings[0].CardSet.Name)
+	assert.Equal(t, "LOB", printings[0].CardSet.Code)
+	assert.Equal(t, "SDK", printings[1].CardSet.Code)
+
+	// A synced card with the same printings is unchanged.
+	report, err := service.SyncFromReader(strings.NewReader(dump(sdk+","+lob)), false, true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Changed)
+
+	// Printings dropped from the catalog are deleted unless a collection holds them.
+	user := models.User{Username: "collector", Email: "collector@example.com"}
+	utils.SeedTestData(db, &user)
+	utils.SeedTestData(db, &models.UserCard{UserID: user.ID, CardID: printings[1].CardID, PrintingID: printings[1].ID, Quantity: 1})
+
+	report, err = service.SyncFromReader(strings.NewReader(dump(lc01)), false, false)
+	require.NoError(t, err)
+	assert.Len(t, report.Changed, 1)
+
+	var codes []string
+	require.NoError(t, db.Model(&models.CardPrinting{}).Order("set_code").Pluck("set_code", &codes).Error)
+	assert.Equal(t, []string{"LCKC-EN001", "SDK-001"}, codes)
+
+	var sets int64
+	require.NoError(t, db.Model(&models.CardSet{}).Count(&sets).Error)
+	assert.Equal(t, int64(3), sets)
+}
@@ -3,17 +3,46 @@ package services
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"gorm.io/gorm"
 )
 
+// ErrCardReserved is returned when removing copies would leave fewer than physical decks hold.
+var ErrCardReserved = repository.ErrCardReserved
+var ErrPrintingNotFound = errors.New("printing not found for this card")
+var ErrInvalidCopyAttributes = errors.New("invalid copy attributes")
+
+// copyAttributeValues are the accepted values of each copy attribute, besides the empty, unspecified value.
+var copyAttributeValues = map[string][]string{
+	"condition": {
+		models.ConditionMint, models.ConditionNearMint, models.ConditionLightlyPlayed,
+		models.ConditionModeratelyPlayed, models.ConditionHeavilyPlayed, models.ConditionDamaged,
+	},
+	"edition":  {models.EditionFirst, models.EditionUnlimited, models.EditionLimited},
+	"language": models.CopyLanguages,
+	"finish":   {models.FinishNonFoil, models.FinishFoil},
+}
+
+// CollectionGroup sums up the copies in a collection sharing a value of an attribute.
+type CollectionGroup struct {
+	Value    string `json:"value"`
+	Cards    int64  `json:"cards"`
+	Quantity int64  `json:"quantity"`
+}
+
 type CollectionService interface {
-	GetUserCollection(userID uint) ([]models.UserCard, error)
+	GetUserCollection(userID uint, filter repository.CollectionFilter) ([]models.UserCard, error)
+	GetUserCollectionPage(userID uint, filter repository.CollectionFilter, page repository.Page) ([]models.UserCard, string, error)
+	GroupUserCollection(userID uint, filter repository.CollectionFilter, group string) ([]CollectionGroup, error)
 	GetUserCard(userID, cardID uint) (*models.UserCard, error)
-	AddCardToCollection(userID uint, cardID uint, quantity int) error
-	DecreaseCardQuantity(userID, cardID uint, quantityToRemove int) error
+	GetUserCardPrintings(userID, cardID uint) ([]models.UserCard, error)
+	AddCardToCollection(userID, cardID, printingID uint, attrs models.CopyAttributes, quantity int) error
+	DecreaseCardQuantity(userID, cardID, printingID uint, attrs models.CopyAttributes, quantityToRemove int) error
+	GetCardReservations(userID, cardID uint) ([]models.DeckCard, error)
+	GetAvailableQuantity(userID, cardID uint) (int, error)
 }
 
 type collectionService struct {
@@ -24,37 +53,107 @@ func NewCollectionService(repo repository.CollectionRepository) CollectionServic
 	return &collectionService{repo: repo}
 }
 
-func (s *collectionService) GetUserCollection(userID uint) ([]models.UserCard, error) {
-	collection, err := s.repo.GetUserCollection(userID)
+// GetUserCollection returns the entries of the user's collection matching the filter.
+func (s *collectionService) GetUserCollection(userID uint, filter repository.CollectionFilter) ([]models.UserCard, error) {
+	if err := checkCopyAttributes(filter.CopyAttributes); err != nil {
+		return nil, err
+	}
+
+	collection, err := s.repo.GetUserCollection(userID, filter)
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch collection for user %d: %w", userID, err)
 	}
 	return collection, nil
 }
 
+// GetUserCollectionPage returns a page of the entries of the user's collection matching the filter, and the
+// cursor of the next page.
+func (s *collectionService) GetUserCollectionPage(userID uint, filter repository.CollectionFilter, page repository.Page) ([]models.UserCard, string, error) {
+	if err := checkCopyAttributes(filter.CopyAttributes); err != nil {
+		return nil, "", err
+	}
+
+	collection, nextCursor, err := s.repo.GetUserCollectionPage(userID, filter, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not fetch collection for user %d: %w", userID, err)
+	}
+	return collection, nextCursor, nil
+}
+
+// GroupUserCollection sums up the copies in the user's collection matching the filter by condition, edition,
+// language, finish, rarity or set.
+func (s *collectionService) GroupUserCollection(userID uint, filter repository.CollectionFilter, group string) ([]CollectionGroup, error) {
+	if err := checkCopyAttributes(filter.CopyAttributes); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.GroupUserCollection(userID, filter, group)
+	if err != nil {
+		return nil, fmt.Errorf("could not group collection for user %d: %w", userID, err)
+	}
+
+	groups := make([]CollectionGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, CollectionGroup{Value: row.Value, Cards: row.Cards, Quantity: row.Quantity})
+	}
+	return groups, nil
+}
+
+// GetUserCard returns the copies of the card the user owns, across all its printings.
 func (s *collectionService) GetUserCard(userID, cardID uint) (*models.UserCard, error) {
 	return s.repo.GetUserCard(userID, cardID)
 }
 
-func (s *collectionService) AddCardToCollection(userID uint, cardID uint, quantity int) error {
+// GetUserCardPrintings returns the user's copies of the card, one entry per printing.
+func (s *collectionService) GetUserCardPrintings(userID, cardID uint) ([]models.UserCard, error) {
+	userCards, err := s.repo.GetUserCardPrintings(userID, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch printings of card %d in user %d's collection: %w", cardID, userID, err)
+	}
+	return userCards, nil
+}
+
+// AddCardToCollection adds copies of a printing of the card with the given attributes to the user's
+// collection, or of its unspecified printing when printingID is zero. The printing must be one of the card's.
+func (s *collectionService) AddCardToCollection(userID, cardID, printingID uint, attrs models.CopyAttributes, quantity int) error {
 	if quantity <= 0 {
 		return fmt.Errorf("quantity must be greater than zero")
 	}
+	if err := checkCopyAttributes(attrs); err != nil {
+		return err
+	}
+	if err := s.checkPrinting(cardID, printingID); err != nil {
+		return err
+	}
 
-	err := s.repo.AddCardToCollection(userID, cardID, quantity)
+	err := s.repo.AddCardToCollection(userID, cardID, printingID, attrs, quantity)
 	if err != nil {
 		return fmt.Errorf("failed to add card %d to user %d's collection: %w", cardID, userID, err)
 	}
 	return nil
 }
 
-func (s *collectionService) DecreaseCardQuantity(userID, cardID uint, quantityToRemove int) error {
+// DecreaseCardQuantity removes copies of a printing of the card from the user's collection, or of any of its
+// printings, the unspecified one first, when printingID is zero. Only copies with the given attributes are
+// removed, empty attributes matching any. Copies reserved by physical decks cannot be removed.
+func (s *collectionService) DecreaseCardQuantity(userID, cardID, printingID uint, attrs models.CopyAttributes, quantityToRemove int) error {
 	if quantityToRemove <= 0 {
 		return fmt.Errorf("quantity to remove must be greater than zero")
 	}
+	if err := checkCopyAttributes(attrs); err != nil {
+		return err
+	}
+	if err := s.checkPrinting(cardID, printingID); err != nil {
+		return err
+	}
 
-	err := s.repo.DecreaseCardQuantity(userID, cardID, quantityToRemove)
+	// Copies held by physical decks cannot be removed from the collection, which the repository checks while
+	// holding the copies.
+	err := s.repo.DecreaseCardQuantity(userID, cardID, printingID, attrs, quantityToRemove)
 	if err != nil {
+		if errors.Is(err, ErrCardReserved) {
+			return ErrCardReserved
+		}
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("card %d not found in user %d's collection", cardID, userID)
 		}
@@ -62,3 +161,60 @@ func (s *collectionService) DecreaseCardQuantity(userID, cardID uint, quantityTo
 	}
 	return nil
 }
+
+// checkCopyAttributes returns ErrInvalidCopyAttributes if an attribute is neither empty nor an accepted value.
+func checkCopyAttributes(attrs models.CopyAttributes) error {
+	for name, value := range map[string]string{
+		"condition": attrs.Condition,
+		"edition":   attrs.Edition,
+		"language":  attrs.Language,
+		"finish":    attrs.Finish,
+	} {
+		if value != "" && !slices.Contains(copyAttributeValues[name], value) {
+			return fmt.Errorf("%w: unknown %s %q", ErrInvalidCopyAttributes, name, value)
+		}
+	}
+	return nil
+}
+
+// checkPrinting returns ErrPrintingNotFound unless printingID is zero or a printing of the card.
+func (s *collectionService) checkPrinting(cardID, printingID uint) error {
+	if printingID == 0 {
+		return nil
+	}
+
+	printing, err := s.repo.GetPrinting(printingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPrintingNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch printing %d: %w", printingID, err)
+	}
+	if printing.CardID != cardID {
+		return ErrPrintingNotFound
+	}
+	return nil
+}
+
+// GetCardReservations lists the physical decks holding copies of the card, with the copies each one holds.
+func (s *collectionService) GetCardReservations(userID, cardID uint) ([]models.DeckCard, error) {
+	reservations, err := s.repo.GetReservations(userID, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reservations of card %d: %w", cardID, err)
+	}
+	return reservations, nil
+}
+
+// GetAvailableQuantity returns the copies of the card the user owns that no physical deck holds.
+func (s *collectionService) GetAvailableQuantity(userID, cardID uint) (int, error) {
+	owned, err := s.repo.GetOwnedQuantity(userID, cardID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count copies of card %d: %w", cardID, err)
+	}
+
+	reserved, err := s.repo.GetReservedQuantity(userID, cardID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reserved copies of card %d: %w", cardID, err)
+	}
+	return max(owned-reserved, 0), nil
+}
@@ -8,31 +8,32 @@ import (
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func Test_collectionService_GetUserCollection(t *testing.T) {
 	db := utils.SetupTestDB(
 		&models.User{}, &models.Card{}, &models.UserCard{},
 		&models.MonsterCard{}, &models.SpellTrapCard{},
-		&models.LinkMonsterCard{}, &models.PendulumMonsterCard{},
+		&models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
 	)
 
 	user := &models.User{Username: "testuser", Email: "test@example.com", Password: "securepass"}
 	card := &models.Card{CardYGOID: 11111, Name: "Blue-Eyes White Dragon", Type: "Monster"}
 	utils.SeedTestData(db, user, card)
 
-	userCard := &models.UserCard{
+	userCard := inUnspecifiedPrinting(db, &models.UserCard{
 		UserID:   user.ID,
 		CardID:   card.ID,
 		Quantity: 2,
-	}
+	})
 	utils.SeedTestData(db, userCard)
 
 	repo := repository.NewCollectionRepositoryWithDB(db)
 	service := &collectionService{repo: repo}
 
 	t.Run("returns user collection correctly", func(t *testing.T) {
-		got, err := service.GetUserCollection(user.ID)
+		got, err := service.GetUserCollection(user.ID, repository.CollectionFilter{})
 		require.NoError(t, err)
 		require.Len(t, got, 1)
 
@@ -47,7 +48,7 @@ func Test_collectionService_GetUserCard(t *testing.T) {
 	db := utils.SetupTestDB(
 		&models.User{}, &models.Card{}, &models.UserCard{},
 		&models.MonsterCard{}, &models.SpellTrapCard{},
-		&models.LinkMonsterCard{}, &models.PendulumMonsterCard{},
+		&models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
 	)
 
 	user := models.User{Username: "testuser", Email: "test@example.com", Password: "pass"}
@@ -55,8 +56,8 @@ func Test_collectionService_GetUserCard(t *testing.T) {
 
 	utils.SeedTestData(db, &user, &card)
 
-	userCard := models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 2}
-	utils.SeedTestData(db, &userCard)
+	userCard := inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 2})
+	utils.SeedTestData(db, userCard)
 
 	repo := repository.NewCollectionRepositoryWithDB(db)
 	service := &collectionService{repo: repo}
@@ -164,7 +165,7 @@ func Test_collectionService_AddCardToCollection(t *testing.T) {
 				quantity: 3,
 			},
 			setup: func() {
-				existing := &models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 2}
+				existing := inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 2})
 				utils.SeedTestData(db, existing)
 			},
 			wantQty: 5, // 2 + 3
@@ -178,7 +179,7 @@ func Test_collectionService_AddCardToCollection(t *testing.T) {
 
 			tt.setup()
 
-			err := service.AddCardToCollection(tt.args.userID, tt.args.cardID, tt.args.quantity)
+			err := service.AddCardToCollection(tt.args.userID, tt.args.cardID, 0, models.CopyAttributes{}, tt.args.quantity)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("AddCardToCollection() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -195,14 +196,14 @@ func Test_collectionService_AddCardToCollection(t *testing.T) {
 }
 
 func Test_collectionService_DecreaseCardQuantity(t *testing.T) {
-	db := utils.SetupTestDB(&models.User{}, &models.Card{}, &models.UserCard{})
+	db := utils.SetupTestDB(&models.User{}, &models.Card{}, &models.UserCard{}, &models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{})
 
 	user := models.User{Email: "test@example.com"}
 	card := models.Card{Name: "Decrease Card"}
 	utils.SeedTestData(db, &user, &card)
 
-	userCard := models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 5}
-	db.Create(&userCard)
+	userCard := inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 5})
+	db.Create(userCard)
 
 	repo := repository.NewCollectionRepositoryWithDB(db)
 	s := &collectionService{repo: repo}
@@ -246,10 +247,257 @@ func Test_collectionService_DecreaseCardQuantity(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := s.DecreaseCardQuantity(tt.args.userID, tt.args.cardID, tt.args.quantityToRemove)
+			err := s.DecreaseCardQuantity(tt.args.userID, tt.args.cardID, 0, models.CopyAttributes{}, tt.args.quantityToRemove)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DecreaseCardQuantity() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_collectionService_Printings(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.CardSet{}, &models.CardPrinting{}, &models.UserCard{}, &models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{},
+	)
+
+	user := models.User{Username: "binder", Email: "binder@example.com"}
+	card := models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon"}
+	other := models.Card{CardYGOID: 46986414, Name: "Dark Magician"}
+	utils.SeedTestData(db, &user, &card, &other)
+
+	lob := models.CardPrinting{CardID: card.ID, SetCode: "LOB-001", Rarity: "Ultra Rare", CardSet: &models.CardSet{Name: "Legend of Blue Eyes White Dragon", Code: "LOB"}}
+	sdk := models.CardPrinting{CardID: card.ID, SetCode: "SDK-001", Rarity: "Common"}
+	sye := models.CardPrinting{CardID: other.ID, SetCode: "SYE-001", Rarity: "Ultra Rare"}
+	utils.SeedTestData(db, &lob, &sdk, &sye)
+
+	s := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, models.CopyAttributes{}, 1))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, sdk.ID, models.CopyAttributes{}, 2))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, 0, models.CopyAttributes{}, 2))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, models.CopyAttributes{}, 1))
+	assert.ErrorIs(t, s.AddCardToCollection(user.ID, card.ID, sye.ID, models.CopyAttributes{}, 1), ErrPrintingNotFound)
+	assert.ErrorIs(t, s.AddCardToCollection(user.ID, card.ID, 999, models.CopyAttributes{}, 1), ErrPrintingNotFound)
+
+	total, err := s.GetUserCard(user.ID, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total.Quantity)
+	assert.Equal(t, "Blue-Eyes White Dragon", total.Card.Name)
+
+	entries, err := s.GetUserCardPrintings(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.True(t, entries[0].Printing.Unspecified())
+	assert.Equal(t, 2, entries[0].Quantity)
+	assert.Equal(t, "LOB-001", entries[1].Printing.SetCode)
+	assert.Equal(t, 2, entries[1].Quantity)
+	require.NotNil(t, entries[1].Printing.CardSet)
+	assert.Equal(t, "LOB", entries[1].Printing.CardSet.Code)
+	assert.Equal(t, "SDK-001", entries[2].Printing.SetCode)
+
+	// Pages hold one entry per printing, so a card can span pages.
+	var paged []uint
+	page := repository.Page{Limit: 2}
+	for {
+		entries, next, err := s.GetUserCollectionPage(user.ID, repository.CollectionFilter{}, page)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			paged = append(paged, entry.PrintingID)
+		}
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+	assert.Len(t, paged, 3)
+	assert.ElementsMatch(t, []uint{entries[0].PrintingID, lob.ID, sdk.ID}, paged)
+
+	// Without a printing, copies of unknown printing are removed first.
+	require.NoError(t, s.DecreaseCardQuantity(user.ID, card.ID, 0, models.CopyAttributes{}, 3))
+	entries, err = s.GetUserCardPrintings(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "LOB-001", entries[0].Printing.SetCode)
+	assert.Equal(t, 1, entries[0].Quantity)
+
+	require.NoError(t, s.DecreaseCardQuantity(user.ID, card.ID, sdk.ID, models.CopyAttributes{}, 5))
+	assert.ErrorIs(t, s.DecreaseCardQuantity(user.ID, card.ID, sye.ID, models.CopyAttributes{}, 1), ErrPrintingNotFound)
+
+	collection, err := s.GetUserCollection(user.ID, repository.CollectionFilter{})
+	require.NoError(t, err)
+	require.Len(t, collection, 1)
+	assert.Equal(t, lob.ID, collection[0].PrintingID)
+	assert.Equal(t, 1, collection[0].Quantity)
+}
+
+func Test_collectionService_CopyAttributes(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.CardSet{}, &models.CardPrinting{}, &models.UserCard{}, &models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{},
+	)
+
+	user := models.User{Username: "grader", Email: "grader@example.com"}
+	card := models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon"}
+	other := models.Card{CardYGOID: 46986414, Name: "Dark Magician"}
+	utils.SeedTestData(db, &user, &card, &other)
+
+	lob := models.CardPrinting{CardID: card.ID, SetCode: "LOB-001", Rarity: "Ultra Rare", CardSet: &models.CardSet{Name: "Legend of Blue Eyes White Dragon", Code: "LOB"}}
+	utils.SeedTestData(db, &lob)
+
+	s := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+
+	mintFirst := models.CopyAttributes{Condition: models.ConditionMint, Edition: models.EditionFirst, Language: "en", Finish: models.FinishFoil}
+	played := models.CopyAttributes{Condition: models.ConditionHeavilyPlayed, Language: "ja"}
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, mintFirst, 1))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, played, 2))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, models.CopyAttributes{}, 1))
+	require.NoError(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, mintFirst, 1))
+	require.NoError(t, s.AddCardToCollection(user.ID, other.ID, 0, played, 3))
+	assert.ErrorIs(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, models.CopyAttributes{Condition: "pristine"}, 1), ErrInvalidCopyAttributes)
+	assert.ErrorIs(t, s.AddCardToCollection(user.ID, card.ID, lob.ID, models.CopyAttributes{Language: "xx"}, 1), ErrInvalidCopyAttributes)
+
+	// Copies of a printing with different attributes are separate entries.
+	entries, err := s.GetUserCardPrintings(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	total, err := s.GetUserCard(user.ID, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total.Quantity)
+
+	collection, err := s.GetUserCollection(user.ID, repository.CollectionFilter{CopyAttributes: models.CopyAttributes{Language: "ja"}})
+	require.NoError(t, err)
+	require.Len(t, collection, 2)
+	for _, entry := range collection {
+		assert.Equal(t, models.ConditionHeavilyPlayed, entry.Condition)
+	}
+	_, err = s.GetUserCollection(user.ID, repository.CollectionFilter{CopyAttributes: models.CopyAttributes{Finish: "matte"}})
+	assert.ErrorIs(t, err, ErrInvalidCopyAttributes)
+
+	groups, err := s.GroupUserCollection(user.ID, repository.CollectionFilter{}, repository.CollectionGroupCondition)
+	require.NoError(t, err)
+	assert.Equal(t, []CollectionGroup{
+		{Value: "", Cards: 1, Quantity: 1},
+		{Value: models.ConditionHeavilyPlayed, Cards: 2, Quantity: 5},
+		{Value: models.ConditionMint, Cards: 1, Quantity: 2},
+	}, groups)
+
+	groups, err = s.GroupUserCollection(user.ID, repository.CollectionFilter{CopyAttributes: models.CopyAttributes{Language: "ja"}}, repository.CollectionGroupSet)
+	require.NoError(t, err)
+	assert.Equal(t, []CollectionGroup{
+		{Value: "", Cards: 1, Quantity: 3},
+		{Value: "Legend of Blue Eyes White Dragon", Cards: 1, Quantity: 2},
+	}, groups)
+
+	_, err = s.GroupUserCollection(user.ID, repository.CollectionFilter{}, "colour")
+	assert.ErrorIs(t, err, repository.ErrInvalidGroup)
+
+	// A page holds every entry of its last printing.
+	page, next, err := s.GetUserCollectionPage(user.ID, repository.CollectionFilter{}, repository.Page{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, models.CopyAttributes{}, page[0].CopyAttributes)
+	page, next, err = s.GetUserCollectionPage(user.ID, repository.CollectionFilter{}, repository.Page{Limit: 1, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, other.ID, page[0].CardID)
+	assert.Empty(t, next)
+
+	// Decreasing by attributes only takes matching copies, the unspecified ones first.
+	require.NoError(t, s.DecreaseCardQuantity(user.ID, card.ID, 0, models.CopyAttributes{Condition: models.ConditionMint}, 1))
+	require.NoError(t, s.DecreaseCardQuantity(user.ID, card.ID, lob.ID, models.CopyAttributes{}, 2))
+	entries, err = s.GetUserCardPrintings(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, played, entries[0].CopyAttributes)
+	assert.Equal(t, 1, entries[0].Quantity)
+	assert.Equal(t, mintFirst, entries[1].CopyAttributes)
+	assert.Equal(t, 1, entries[1].Quantity)
+}
+
+func Test_collectionService_ReservedCopies(t *testing.T) {
+	db := utils.SetupTestDB(&models.User{}, &models.Card{}, &models.UserCard{}, &models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{})
+
+	user := models.User{Username: "owner", Email: "owner@example.com"}
+	card := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring"}
+	utils.SeedTestData(db, &user, &card)
+
+	physical := models.Deck{Name: "Tournament", UserID: user.ID, Physical: true}
+	online := models.Deck{Name: "Online", UserID: user.ID}
+	utils.SeedTestData(db, &physical, &online)
+	utils.SeedTestData(db,
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: card.ID, Quantity: 3}),
+		&models.DeckCard{DeckID: physical.ID, CardID: card.ID, Zone: models.ZoneMain, Quantity: 2},
+		&models.DeckCard{DeckID: online.ID, CardID: card.ID, Zone: models.ZoneMain, Quantity: 3},
+	)
+
+	s := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+
+	available, err := s.GetAvailableQuantity(user.ID, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, available)
+
+	reservations, err := s.GetCardReservations(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 1)
+	assert.Equal(t, "Tournament", reservations[0].Deck.Name)
+
+	assert.ErrorIs(t, s.DecreaseCardQuantity(user.ID, card.ID, 0, models.CopyAttributes{}, 2), ErrCardReserved)
+	require.NoError(t, s.DecreaseCardQuantity(user.ID, card.ID, 0, models.CopyAttributes{}, 1))
+}
+
+func Test_collectionService_GetUserCollectionPage(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.UserCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{},
+		&models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+
+	user := &models.User{Username: "pager", Email: "pager@example.com", Password: "securepass"}
+	other := &models.User{Username: "other", Email: "other@example.com", Password: "securepass"}
+	magician := &models.Card{CardYGOID: 1, Name: "Dark Magician", MonsterCard: &models.MonsterCard{Atk: 2500}}
+	skull := &models.Card{CardYGOID: 2, Name: "Summoned Skull", MonsterCard: &models.MonsterCard{Atk: 2500}}
+	blueEyes := &models.Card{CardYGOID: 3, Name: "Blue-Eyes White Dragon", MonsterCard: &models.MonsterCard{Atk: 3000}}
+	utils.SeedTestData(db, user, other, magician, skull, blueEyes)
+	utils.SeedTestData(db,
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: magician.ID, Quantity: 1}),
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: skull.ID, Quantity: 2}),
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: blueEyes.ID, Quantity: 3}),
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: other.ID, CardID: magician.ID, Quantity: 1}),
+	)
+
+	service := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+
+	readAll := func(page repository.Page) []string {
+		var names []string
+		for {
+			userCards, next, err := service.GetUserCollectionPage(user.ID, repository.CollectionFilter{}, page)
+			require.NoError(t, err)
+			for _, userCard := range userCards {
+				names = append(names, userCard.Card.Name)
+			}
+			if next == "" {
+				return names
+			}
+			page = repository.Page{Limit: page.Limit, Cursor: next}
+		}
+	}
+
+	assert.Equal(t, []string{"Blue-Eyes White Dragon", "Dark Magician", "Summoned Skull"}, readAll(repository.Page{Limit: 1}))
+	assert.Equal(t, []string{"Dark Magician", "Summoned Skull", "Blue-Eyes White Dragon"},
+		readAll(repository.Page{Sort: repository.CardSortAtk, Limit: 2}))
+
+	_, _, err := service.GetUserCollectionPage(user.ID, repository.CollectionFilter{}, repository.Page{Sort: repository.CardSortAdded})
+	assert.ErrorIs(t, err, repository.ErrInvalidSort)
+}
+
+// inUnspecifiedPrinting files copies seeded without a printing under the unspecified printing of their card,
+// as the collection repository does when adding them.
+func inUnspecifiedPrinting(db *gorm.DB, userCard *models.UserCard) *models.UserCard {
+	printing := models.CardPrinting{CardID: userCard.CardID}
+	if err := db.Where("card_id = ? AND set_code = '' AND rarity = ''", userCard.CardID).FirstOrCreate(&printing).Error; err != nil {
+		panic("failed to seed unspecified printing")
+	}
+	userCard.PrintingID = printing.ID
+	return userCard
+}
@@ -13,48 +13,64 @@ var (
 	ErrCardCopyLimitExceeded = errors.New("too many copies of this card in the deck")
 	ErrDeckLimitReached      = errors.New("deck size limit reached")
 	ErrExtraDeckLimitReached = errors.New("extra deck size limit reached")
-)
-
-const (
-	MainDeckMaxSize  = 60
-	ExtraDeckMaxSize = 20
-	MaxCopiesPerCard = 3
+	ErrSideDeckLimitReached  = errors.New("side deck size limit reached")
+	ErrInvalidZone           = errors.New("card cannot be placed in this zone")
+	ErrZoneRequired          = errors.New("card is in several zones, a zone must be given")
 )
 
 // DeckCardService defines operations related to managing cards within a deck.
 type DeckCardService interface {
-	AddCardToDeck(userID, deckID uint, card *models.Card, quantity int) error
-	RemoveCardFromDeck(userID, deckID, cardID uint, quantity int) error
-	ValidateDeckCardCount(deckID uint, zone string, newCards int) error
-	ValidateCardCopyLimit(deckID, cardID uint, quantityToAdd int) error
+	AddCardToDeck(userID, deckID uint, card *models.Card, quantity int, zone string) error
+	RemoveCardFromDeck(userID, deckID, cardID uint, quantity int, zone string) error
+	MoveCardBetweenZones(deckID uint, card *models.Card, fromZone, toZone string, quantity int) error
+	ValidateCards(deckID uint, entries []models.DeckCard) ([]string, error)
+	AddCardsToDeck(deckID uint, entries []models.DeckCard) ([]string, error)
+	ValidateZone(card *models.Card, zone string) error
+	ValidateDeckCardCount(format *models.Format, deckID uint, zone string, newCards int) error
+	ValidateCardCopyLimit(format *models.Format, deckID uint, card *models.Card, quantityToAdd int) error
 }
 
 type deckCardService struct {
-	repo repository.DeckCardRepository
+	repo           repository.DeckCardRepository
+	formatService  FormatService
+	banlistService BanlistService
 }
 
 // NewDeckCardService creates a new instance of deckCardService.
-func NewDeckCardService(repo repository.DeckCardRepository) DeckCardService {
+func NewDeckCardService(repo repository.DeckCardRepository, formatService FormatService, banlistService BanlistService) DeckCardService {
 	return &deckCardService{
-		repo: repo,
+		repo:           repo,
+		formatService:  formatService,
+		banlistService: banlistService,
 	}
 }
 
-// AddCardToDeck adds a given quantity of a card to the deck,
-// validating deck size and card copy constraints.
-func (s *deckCardService) AddCardToDeck(userID, deckID uint, card *models.Card, quantity int) error {
+// AddCardToDeck adds a given quantity of a card to a zone of the deck,
+// validating deck size, card copy and banlist constraints of the deck's format.
+// An empty zone places the card in the main or extra deck depending on its type.
+func (s *deckCardService) AddCardToDeck(userID, deckID uint, card *models.Card, quantity int, zone string) error {
 	if quantity <= 0 {
 		return fmt.Errorf("invalid quantity: must be greater than 0")
 	} else if card == nil {
 		return fmt.Errorf("invalid card: cannot be nil")
 	}
 
-	zone := GetZoneFromCard(card)
+	format, err := s.formatService.GetDeckFormat(deckID)
+	if err != nil {
+		return fmt.Errorf("failed to load deck format: %w", err)
+	}
+
+	if zone == "" {
+		zone = GetZoneFromCard(card)
+	}
+	if err := s.ValidateZone(card, zone); err != nil {
+		return err
+	}
 
-	if err := s.ValidateDeckCardCount(deckID, zone, quantity); err != nil {
+	if err := s.ValidateDeckCardCount(format, deckID, zone, quantity); err != nil {
 		return fmt.Errorf("deck size validation failed: %w", err)
 	}
-	if err := s.ValidateCardCopyLimit(deckID, card.ID, quantity); err != nil {
+	if err := s.ValidateCardCopyLimit(format, deckID, card, quantity); err != nil {
 		return fmt.Errorf("copy limit validation failed: %w", err)
 	}
 
@@ -65,13 +81,31 @@ func (s *deckCardService) AddCardToDeck(userID, deckID uint, card *models.Card,
 	return nil
 }
 
-// RemoveCardFromDeck removes a quantity of a card from a deck.
-// Deletes the card if the resulting quantity is zero or less.
-func (s *deckCardService) RemoveCardFromDeck(userID, deckID, cardID uint, quantity int) error {
-	entry, err := s.repo.GetDeckCard(deckID, cardID)
+// RemoveCardFromDeck removes a quantity of a card from a zone of the deck.
+// Deletes the card from the zone if the resulting quantity is zero or less.
+// The zone may be empty when the card is only in one zone.
+func (s *deckCardService) RemoveCardFromDeck(userID, deckID, cardID uint, quantity int, zone string) error {
+	if zone == "" {
+		entries, err := s.repo.GetDeckCardZones(deckID, cardID)
+		if err != nil {
+			return fmt.Errorf("failed to load deck card: %w", err)
+		}
+		if len(entries) > 1 {
+			return ErrZoneRequired
+		}
+		if len(entries) == 0 {
+			return ErrCardNotFound
+		}
+		zone = entries[0].Zone
+	}
+
+	entry, err := s.repo.GetDeckCard(deckID, cardID, zone)
 	if err != nil {
 		return fmt.Errorf("card not found in deck: %w", err)
 	}
+	if entry == nil {
+		return ErrCardNotFound
+	}
 
 	if quantity >= entry.Quantity {
 		if err := s.repo.DeleteDeckCard(entry); err != nil {
@@ -87,41 +121,248 @@ func (s *deckCardService) RemoveCardFromDeck(userID, deckID, cardID uint, quanti
 	return nil
 }
 
+// MoveCardBetweenZones moves copies of a card from one zone of the deck to another,
+// validating that the card fits in the target zone. Copy limits are unaffected by a move.
+func (s *deckCardService) MoveCardBetweenZones(deckID uint, card *models.Card, fromZone, toZone string, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity: must be greater than 0")
+	}
+	if fromZone == toZone {
+		return fmt.Errorf("%w: source and target zones are the same", ErrInvalidZone)
+	}
+	if err := s.ValidateZone(card, toZone); err != nil {
+		return err
+	}
+
+	entry, err := s.repo.GetDeckCard(deckID, card.ID, fromZone)
+	if err != nil {
+		return fmt.Errorf("failed to load deck card: %w", err)
+	}
+	if entry == nil || entry.Quantity < quantity {
+		return ErrCardNotFound
+	}
+
+	format, err := s.formatService.GetDeckFormat(deckID)
+	if err != nil {
+		return fmt.Errorf("failed to load deck format: %w", err)
+	}
+	if err := s.ValidateDeckCardCount(format, deckID, toZone, quantity); err != nil {
+		return fmt.Errorf("deck size validation failed: %w", err)
+	}
+
+	if err := s.repo.MoveDeckCard(deckID, card.ID, fromZone, toZone, quantity); err != nil {
+		return fmt.Errorf("failed to move card: %w", err)
+	}
+	return nil
+}
+
+// ValidateCards checks whether a batch of cards can be added to a deck at once, on top of the
+// cards it already holds. Instead of stopping at the first broken rule it returns one message
+// per violation; the error is only set when the checks themselves could not run.
+// A deckID of 0 validates the cards as the content of a new, empty deck of the default format.
+func (s *deckCardService) ValidateCards(deckID uint, entries []models.DeckCard) ([]string, error) {
+	rules, err := s.batchRules(deckID, entries)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkBatch(rules, deckID, entries)
+}
+
+// AddCardsToDeck stores a batch of cards in a deck atomically. The batch is validated like
+// ValidateCards within the same transaction, so the deck cannot change between the check and
+// the write; when a rule is broken nothing is stored, and the violations are returned with
+// ErrImportRejected.
+func (s *deckCardService) AddCardsToDeck(deckID uint, entries []models.DeckCard) ([]string, error) {
+	rules, err := s.batchRules(deckID, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	err = s.repo.AddCardsToDeck(deckID, entries, func(tx repository.DeckCardRepository) error {
+		checker := &deckCardService{repo: tx, formatService: s.formatService, banlistService: s.banlistService}
+
+		var err error
+		violations, err = checker.checkBatch(rules, deckID, entries)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			return ErrImportRejected
+		}
+		return nil
+	})
+	if errors.Is(err, ErrImportRejected) {
+		return violations, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add cards to deck: %w", err)
+	}
+	return nil, nil
+}
+
+// batchRules holds the format of a deck and the copy limit of every card of a batch, which do not
+// depend on the cards the deck holds.
+type batchRules struct {
+	format *models.Format
+	limits map[int]int
+}
+
+// batchRules loads the rules a batch of cards is checked against.
+func (s *deckCardService) batchRules(deckID uint, entries []models.DeckCard) (*batchRules, error) {
+	format, err := s.formatService.GetDeckFormat(deckID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deck format: %w", err)
+	}
+
+	rules := &batchRules{format: format, limits: make(map[int]int)}
+	for _, entry := range entries {
+		if _, ok := rules.limits[entry.Card.CardYGOID]; ok {
+			continue
+		}
+		limit, err := s.banlistService.GetCardLimit(format, entry.Card.CardYGOID)
+		if err != nil {
+			return nil, err
+		}
+		rules.limits[entry.Card.CardYGOID] = limit
+	}
+	return rules, nil
+}
+
+// checkBatch checks a batch of cards against the given rules and the cards the deck holds,
+// returning one message per violation.
+func (s *deckCardService) checkBatch(rules *batchRules, deckID uint, entries []models.DeckCard) ([]string, error) {
+	violations := []string{}
+	zoneTotals := make(map[string]int)
+	copies := make(map[int]int)
+	cards := make(map[int]*models.Card)
+	var order []int
+
+	for i := range entries {
+		entry := &entries[i]
+		if err := s.ValidateZone(&entry.Card, entry.Zone); err != nil {
+			violations = append(violations, err.Error())
+			continue
+		}
+
+		zoneTotals[entry.Zone] += entry.Quantity
+		if _, ok := cards[entry.Card.CardYGOID]; !ok {
+			cards[entry.Card.CardYGOID] = &entry.Card
+			order = append(order, entry.Card.CardYGOID)
+		}
+		copies[entry.Card.CardYGOID] += entry.Quantity
+	}
+
+	for _, zone := range []string{models.ZoneMain, models.ZoneExtra, models.ZoneSide} {
+		if zoneTotals[zone] == 0 {
+			continue
+		}
+		if err := s.ValidateDeckCardCount(rules.format, deckID, zone, zoneTotals[zone]); err != nil {
+			if !isDeckRuleViolation(err) {
+				return nil, err
+			}
+			violations = append(violations, fmt.Sprintf("%v (%d cards added to the %s deck)", err, zoneTotals[zone], zone))
+		}
+	}
+
+	for _, ygoID := range order {
+		card := cards[ygoID]
+		if err := s.checkCopyLimit(rules.limits[ygoID], deckID, card, copies[ygoID]); err != nil {
+			if !isDeckRuleViolation(err) {
+				return nil, err
+			}
+			violations = append(violations, fmt.Sprintf("%s: %v", card.Name, err))
+		}
+	}
+
+	return violations, nil
+}
+
+// isDeckRuleViolation reports whether an error comes from a deck construction rule
+// rather than from a failure to check it.
+func isDeckRuleViolation(err error) bool {
+	return errors.Is(err, ErrCardCopyLimitExceeded) ||
+		errors.Is(err, ErrCardForbidden) ||
+		errors.Is(err, ErrDeckLimitReached) ||
+		errors.Is(err, ErrExtraDeckLimitReached) ||
+		errors.Is(err, ErrSideDeckLimitReached) ||
+		errors.Is(err, ErrInvalidZone)
+}
+
 // GetZoneFromCard determines whether a card belongs to the "main" or "extra" zone
 // based on its frame type.
 func GetZoneFromCard(card *models.Card) string {
 	switch card.FrameType {
-	case "link", "xyz", "fusion", "synchro":
-		return "extra"
+	case "link", "xyz", "fusion", "synchro", "xyz_pendulum", "fusion_pendulum", "synchro_pendulum":
+		return models.ZoneExtra
+	default:
+		return models.ZoneMain
+	}
+}
+
+// ValidateZone checks that a card can be placed in a zone. Any card may go to the side deck,
+// while the main and extra decks only accept cards of their own kind.
+func (s *deckCardService) ValidateZone(card *models.Card, zone string) error {
+	switch zone {
+	case models.ZoneSide:
+		return nil
+	case models.ZoneMain, models.ZoneExtra:
+		if GetZoneFromCard(card) != zone {
+			return fmt.Errorf("%w: %s cannot be placed in the %s deck", ErrInvalidZone, card.Name, zone)
+		}
+		return nil
 	default:
-		return "main"
+		return fmt.Errorf("%w: unknown zone %q", ErrInvalidZone, zone)
 	}
 }
 
 // ValidateDeckCardCount checks if adding a number of cards to the given zone
-// would exceed the allowed deck size.
-func (s *deckCardService) ValidateDeckCardCount(deckID uint, zone string, newCards int) error {
+// would exceed the deck size allowed by the format.
+func (s *deckCardService) ValidateDeckCardCount(format *models.Format, deckID uint, zone string, newCards int) error {
 	total, err := s.repo.GetTotalCardsInZone(deckID, zone)
 	if err != nil {
 		return err
 	}
-	if zone == "extra" && total+newCards > 15 {
-		return ErrExtraDeckLimitReached
-	}
-	if zone == "main" && total+newCards > 60 {
-		return ErrDeckLimitReached
+
+	switch zone {
+	case models.ZoneExtra:
+		if total+newCards > format.ExtraMax {
+			return ErrExtraDeckLimitReached
+		}
+	case models.ZoneSide:
+		if total+newCards > format.SideMax {
+			return ErrSideDeckLimitReached
+		}
+	default:
+		if total+newCards > format.MainMax {
+			return ErrDeckLimitReached
+		}
 	}
 	return nil
 }
 
-// ValidateCardCopyLimit ensures that adding new copies of a card
-// does not exceed the maximum allowed per deck.
-func (s *deckCardService) ValidateCardCopyLimit(deckID, cardID uint, quantityToAdd int) error {
-	existingQty, err := s.repo.GetCardQuantityInDeck(deckID, cardID)
+// ValidateCardCopyLimit ensures that adding new copies of a card does not exceed
+// the maximum allowed by the format and its active banlist, counting every zone of the deck.
+func (s *deckCardService) ValidateCardCopyLimit(format *models.Format, deckID uint, card *models.Card, quantityToAdd int) error {
+	limit, err := s.banlistService.GetCardLimit(format, card.CardYGOID)
+	if err != nil {
+		return err
+	}
+	return s.checkCopyLimit(limit, deckID, card, quantityToAdd)
+}
+
+// checkCopyLimit ensures that the deck holds no more copies of a card than the given limit once
+// the new copies are added.
+func (s *deckCardService) checkCopyLimit(limit int, deckID uint, card *models.Card, quantityToAdd int) error {
+	if limit == 0 {
+		return ErrCardForbidden
+	}
+
+	existingQty, err := s.repo.GetCardQuantityInDeck(deckID, card.ID)
 	if err != nil {
 		return err
 	}
-	if existingQty+quantityToAdd > 3 {
+	if existingQty+quantityToAdd > limit {
 		return ErrCardCopyLimitExceeded
 	}
 	return nil
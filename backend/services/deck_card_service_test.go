@@ -0,0 +1,98 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupDeckCardService(t *testing.T) (*gorm.DB, DeckCardService, *models.Deck) {
+	t.Helper()
+
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+
+	user := models.User{Username: "duelist", Email: "duelist@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	deck := models.Deck{Name: "Side Deck Test", UserID: user.ID}
+	utils.SeedTestData(db, &deck)
+
+	service := NewDeckCardService(
+		repository.NewDeckCardRepositoryWithDB(db),
+		NewFormatService(repository.NewFormatRepositoryWithDB(db)),
+		NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+	)
+	return db, service, &deck
+}
+
+func Test_deckCardService_AddCardToDeck_Zones(t *testing.T) {
+	db, service, deck := setupDeckCardService(t)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	link := models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}
+	utils.SeedTestData(db, &ash, &link)
+
+	require.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &ash, 2, ""))
+	require.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &link, 1, models.ZoneSide))
+
+	t.Run("copy limit is counted across zones", func(t *testing.T) {
+		require.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &ash, 1, models.ZoneSide))
+		err := service.AddCardToDeck(deck.ID, deck.ID, &ash, 1, models.ZoneSide)
+		assert.ErrorIs(t, err, ErrCardCopyLimitExceeded)
+	})
+
+	t.Run("extra deck cards cannot go to the main deck", func(t *testing.T) {
+		err := service.AddCardToDeck(deck.ID, deck.ID, &link, 1, models.ZoneMain)
+		assert.ErrorIs(t, err, ErrInvalidZone)
+	})
+
+	t.Run("side deck is capped by the format", func(t *testing.T) {
+		// The side deck already holds Decode Talker and one Ash Blossom.
+		for i := 0; i < 4; i++ {
+			filler := models.Card{CardYGOID: 100 + i, Name: "Filler", FrameType: "spell"}
+			utils.SeedTestData(db, &filler)
+			require.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &filler, 3, models.ZoneSide))
+		}
+
+		last := models.Card{CardYGOID: 200, Name: "One Too Many", FrameType: "trap"}
+		utils.SeedTestData(db, &last)
+		err := service.AddCardToDeck(deck.ID, deck.ID, &last, 2, models.ZoneSide)
+		assert.ErrorIs(t, err, ErrSideDeckLimitReached)
+		assert.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &last, 1, models.ZoneSide))
+	})
+}
+
+func Test_deckCardService_MoveCardBetweenZones(t *testing.T) {
+	db, service, deck := setupDeckCardService(t)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	utils.SeedTestData(db, &ash)
+	require.NoError(t, service.AddCardToDeck(deck.ID, deck.ID, &ash, 3, models.ZoneMain))
+
+	require.NoError(t, service.MoveCardBetweenZones(deck.ID, &ash, models.ZoneMain, models.ZoneSide, 2))
+
+	var entries []models.DeckCard
+	require.NoError(t, db.Where("deck_id = ?", deck.ID).Order("zone").Find(&entries).Error)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.ZoneMain, entries[0].Zone)
+	assert.Equal(t, 1, entries[0].Quantity)
+	assert.Equal(t, models.ZoneSide, entries[1].Zone)
+	assert.Equal(t, 2, entries[1].Quantity)
+
+	err := service.MoveCardBetweenZones(deck.ID, &ash, models.ZoneMain, models.ZoneSide, 2)
+	assert.ErrorIs(t, err, ErrCardNotFound)
+
+	err = service.MoveCardBetweenZones(deck.ID, &ash, models.ZoneSide, models.ZoneExtra, 1)
+	assert.ErrorIs(t, err, ErrInvalidZone)
+
+	err = service.RemoveCardFromDeck(deck.ID, deck.ID, ash.ID, 1, "")
+	assert.ErrorIs(t, err, ErrZoneRequired)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// DeckExporter renders a deck and its cards in a downloadable format.
+type DeckExporter interface {
+	Export(deck *models.Deck, cards []models.DeckCard) ([]byte, error)
+	ContentType() string
+	Extension() string
+}
+
+// DeckExport is a rendered deck, ready to be sent as a file.
+type DeckExport struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+var deckExporters = map[string]DeckExporter{
+	"ydk":  ydkExporter{},
+	"text": textExporter{},
+	"csv":  csvExporter{},
+	"json": jsonExporter{},
+	"pdf":  pdfExporter{},
+}
+
+// GetDeckExporter returns the exporter registered for the given format.
+func GetDeckExporter(format string) (DeckExporter, error) {
+	exporter, ok := deckExporters[strings.ToLower(format)]
+	if !ok {
+		return nil, ErrUnsupportedExportFormat
+	}
+	return exporter, nil
+}
+
+// DeckFileName turns a deck name into a safe file name with the given extension.
+func DeckFileName(name, extension string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '-', r == '_':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r):
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("deck")
+	}
+	return sb.String() + "." + extension
+}
+
+// sortedDeckCards returns the cards ordered by zone (main, extra, side) and then by name.
+func sortedDeckCards(cards []models.DeckCard) []models.DeckCard {
+	order := map[string]int{models.ZoneMain: 0, models.ZoneExtra: 1, models.ZoneSide: 2}
+	sorted := append([]models.DeckCard(nil), cards...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if order[sorted[i].Zone] != order[sorted[j].Zone] {
+			return order[sorted[i].Zone] < order[sorted[j].Zone]
+		}
+		return sorted[i].Card.Name < sorted[j].Card.Name
+	})
+	return sorted
+}
+
+// deckCardsByZone splits the cards of a deck by zone, keeping their order.
+func deckCardsByZone(cards []models.DeckCard) map[string][]models.DeckCard {
+	zones := make(map[string][]models.DeckCard)
+	for _, c := range cards {
+		zones[c.Zone] = append(zones[c.Zone], c)
+	}
+	return zones
+}
+
+func countCopies(cards []models.DeckCard) int {
+	total := 0
+	for _, c := range cards {
+		total += c.Quantity
+	}
+	return total
+}
+
+// ydkExporter writes the .ydk format used by EDOPro and YGOPro.
+type ydkExporter struct{}
+
+func (ydkExporter) Export(_ *models.Deck, cards []models.DeckCard) ([]byte, error) {
+	return []byte(formatYDK(sectionsFromCards(cards))), nil
+}
+
+func (ydkExporter) ContentType() string { return "text/plain" }
+func (ydkExporter) Extension() string   { return "ydk" }
+
+// textExporter writes a human-readable list that ImportDeckFromText can read back.
+type textExporter struct{}
+
+func (textExporter) Export(deck *models.Deck, cards []models.DeckCard) ([]byte, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// %s\n", deck.Name)
+
+	zones := deckCardsByZone(sortedDeckCards(cards))
+	for _, section := range []struct{ zone, title string }{
+		{models.ZoneMain, "Main Deck"},
+		{models.ZoneExtra, "Extra Deck"},
+		{models.ZoneSide, "Side Deck"},
+	} {
+		entries := zones[section.zone]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n%s (%d)\n", section.title, countCopies(entries))
+		for _, c := range entries {
+			fmt.Fprintf(&sb, "%dx %s\n", c.Quantity, c.Card.Name)
+		}
+	}
+
+	return []byte(sb.String()), nil
+}
+
+func (textExporter) ContentType() string { return "text/plain; charset=utf-8" }
+func (textExporter) Extension() string   { return "txt" }
+
+// csvExporter writes one row per card and zone: name, passcode, quantity and zone.
+type csvExporter struct{}
+
+func (csvExporter) Export(_ *models.Deck, cards []models.DeckCard) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"name", "passcode", "quantity", "zone"}); err != nil {
+		return nil, err
+	}
+	for _, c := range sortedDeckCards(cards) {
+		row := []string{c.Card.Name, strconv.Itoa(c.Card.CardYGOID), strconv.Itoa(c.Quantity), c.Zone}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (csvExporter) ContentType() string { return "text/csv" }
+func (csvExporter) Extension() string   { return "csv" }
+
+// jsonExporter writes the deck with the full data of every card.
+type jsonExporter struct{}
+
+type exportedDeckCard struct {
+	Quantity int         `json:"quantity"`
+	Card     models.Card `json:"card"`
+}
+
+type exportedDeck struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Main        []exportedDeckCard `json:"main"`
+	Extra       []exportedDeckCard `json:"extra"`
+	Side        []exportedDeckCard `json:"side"`
+}
+
+func (jsonExporter) Export(deck *models.Deck, cards []models.DeckCard) ([]byte, error) {
+	out := exportedDeck{
+		Name:        deck.Name,
+		Description: deck.Description,
+		Main:        []exportedDeckCard{},
+		Extra:       []exportedDeckCard{},
+		Side:        []exportedDeckCard{},
+	}
+	for _, c := range sortedDeckCards(cards) {
+		entry := exportedDeckCard{Quantity: c.Quantity, Card: c.Card}
+		switch c.Zone {
+		case models.ZoneMain:
+			out.Main = append(out.Main, entry)
+		case models.ZoneExtra:
+			out.Extra = append(out.Extra, entry)
+		case models.ZoneSide:
+			out.Side = append(out.Side, entry)
+		}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+func (jsonExporter) ContentType() string { return "application/json" }
+func (jsonExporter) Extension() string   { return "json" }
+
+// pdfExporter fills a Konami tournament deck registration sheet: the main deck split into monster,
+// spell and trap columns, followed by the side and extra decks, each with its totals.
+type pdfExporter struct{}
+
+const (
+	pdfMargin    = 40.0
+	pdfRowHeight = 14.0
+	pdfFontSize  = 8.0
+)
+
+func (pdfExporter) Export(deck *models.Deck, cards []models.DeckCard) ([]byte, error) {
+	doc := utils.NewPDFDocument()
+
+	doc.Text(pdfMargin, 50, 16, true, "KONAMI Deck Registration Sheet")
+	y := 80.0
+	for _, field := range []string{"Name:", "CARD GAME ID:", "Event:", "Date:"} {
+		doc.Text(pdfMargin, y, 10, false, field)
+		doc.Line(pdfMargin+85, y+2, utils.PDFPageWidth/2, y+2)
+		y += 18
+	}
+	doc.Text(utils.PDFPageWidth/2+20, 80, 10, false, "Deck: "+deck.Name)
+
+	var monsters, spells, traps []models.DeckCard
+	zones := deckCardsByZone(sortedDeckCards(cards))
+	for _, c := range zones[models.ZoneMain] {
+		switch c.Card.FrameType {
+		case "spell":
+			spells = append(spells, c)
+		case "trap":
+			traps = append(traps, c)
+		default:
+			monsters = append(monsters, c)
+		}
+	}
+
+	columnWidth := (utils.PDFPageWidth - 2*pdfMargin) / 3
+	top := pdfCursor{y: y + 10}
+	bottom := top
+	for i, column := range []struct {
+		title string
+		cards []models.DeckCard
+	}{
+		{"Monster Cards", monsters},
+		{"Spell Cards", spells},
+		{"Trap Cards", traps},
+	} {
+		end := drawPDFCardColumn(doc, pdfMargin+float64(i)*columnWidth, top, columnWidth, 20, column.title, column.cards)
+		if end.page > bottom.page || (end.page == bottom.page && end.y > bottom.y) {
+			bottom = end
+		}
+	}
+	bottom = pdfBreak(doc, bottom, 16)
+	doc.Text(pdfMargin, bottom.y+16, 10, true, fmt.Sprintf("Main Deck Total: %d", countCopies(zones[models.ZoneMain])))
+
+	top = pdfCursor{page: bottom.page, y: bottom.y + 36}
+	halfWidth := (utils.PDFPageWidth - 2*pdfMargin) / 2
+	drawPDFCardColumn(doc, pdfMargin, top, halfWidth, 15, "Side Deck", zones[models.ZoneSide])
+	drawPDFCardColumn(doc, pdfMargin+halfWidth, top, halfWidth, 15, "Extra Deck", zones[models.ZoneExtra])
+
+	return doc.Bytes(), nil
+}
+
+func (pdfExporter) ContentType() string { return "application/pdf" }
+func (pdfExporter) Extension() string   { return "pdf" }
+
+// pdfCursor is a position on a page of a PDF document.
+type pdfCursor struct {
+	page int
+	y    float64
+}
+
+// pdfBreak returns the cursor at which a block of the given height can be drawn: the given one, or the
+// top of the next page, added if needed, when the block would run into the bottom margin. Following
+// drawing calls apply to the page of the returned cursor.
+func pdfBreak(doc *utils.PDFDocument, at pdfCursor, height float64) pdfCursor {
+	if at.y+height > utils.PDFPageHeight-pdfMargin {
+		at = pdfCursor{page: at.page + 1, y: pdfMargin}
+		if at.page == doc.PageCount() {
+			doc.AddPage()
+		}
+	}
+	doc.SetPage(at.page)
+	return at
+}
+
+// drawPDFCardColumn draws a titled table of quantity and name rows, with at least minRows rows and a
+// total at the bottom. Rows that do not fit on the page continue on the next one, under the title
+// again, while empty rows are only drawn where they fit. It returns the cursor below the table.
+func drawPDFCardColumn(doc *utils.PDFDocument, x float64, at pdfCursor, width float64, minRows int, title string, cards []models.DeckCard) pdfCursor {
+	at = pdfBreak(doc, at, 2*pdfRowHeight)
+	doc.Text(x+4, at.y+10, 9, true, title)
+	at.y += pdfRowHeight
+
+	rows := max(minRows, len(cards))
+	maxChars := int((width - 30) / (pdfFontSize * 0.5))
+	for i := 0; i < rows; i++ {
+		if at.y+pdfRowHeight > utils.PDFPageHeight-pdfMargin {
+			if i >= len(cards) {
+				break
+			}
+			at = pdfBreak(doc, at, pdfRowHeight)
+			doc.Text(x+4, at.y+10, 9, true, title+" (continued)")
+			at.y += pdfRowHeight
+		}
+
+		doc.Rect(x, at.y, 22, pdfRowHeight)
+		doc.Rect(x+22, at.y, width-22, pdfRowHeight)
+		if i < len(cards) {
+			name := []rune(cards[i].Card.Name)
+			if len(name) > maxChars {
+				name = append(name[:maxChars-1], '.')
+			}
+			doc.Text(x+8, at.y+10, pdfFontSize, false, strconv.Itoa(cards[i].Quantity))
+			doc.Text(x+26, at.y+10, pdfFontSize, false, string(name))
+		}
+		at.y += pdfRowHeight
+	}
+
+	at = pdfBreak(doc, at, pdfRowHeight)
+	doc.Text(x+4, at.y+11, 8, true, fmt.Sprintf("Total %s: %d", title, countCopies(cards)))
+	at.y += pdfRowHeight
+	return at
+}
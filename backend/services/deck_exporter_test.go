@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestDeck() (*models.Deck, []models.DeckCard) {
+	deck := &models.Deck{Name: "Snake-Eye / Fire King", Description: "Locals"}
+	cards := []models.DeckCard{
+		{Zone: models.ZoneSide, Quantity: 2, Card: models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}},
+		{Zone: models.ZoneMain, Quantity: 3, Card: models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}},
+		{Zone: models.ZoneMain, Quantity: 1, Card: models.Card{CardYGOID: 83764718, Name: "Monster Reborn", FrameType: "spell"}},
+		{Zone: models.ZoneExtra, Quantity: 1, Card: models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}},
+	}
+	return deck, cards
+}
+
+func TestDeckFileName(t *testing.T) {
+	assert.Equal(t, "Snake-Eye__Fire_King.pdf", DeckFileName("Snake-Eye / Fire King", "pdf"))
+	assert.Equal(t, "deck.ydk", DeckFileName("  ", "ydk"))
+}
+
+func TestGetDeckExporter(t *testing.T) {
+	_, err := GetDeckExporter("docx")
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+}
+
+func TestDeckExporters(t *testing.T) {
+	deck, cards := exportTestDeck()
+
+	t.Run("text can be imported back", func(t *testing.T) {
+		out, err := textExporter{}.Export(deck, cards)
+		require.NoError(t, err)
+
+		lines, err := utils.ParseDecklist(bytes.NewReader(out))
+		require.NoError(t, err)
+		require.Len(t, lines, 4)
+		assert.Equal(t, utils.DecklistLine{Number: 4, Text: "3x Ash Blossom & Joyous Spring", Name: "Ash Blossom & Joyous Spring", Quantity: 3, Section: "main"}, lines[0])
+		assert.Equal(t, "side", lines[3].Section)
+	})
+
+	t.Run("csv has one row per card and zone", func(t *testing.T) {
+		out, err := csvExporter{}.Export(deck, cards)
+		require.NoError(t, err)
+
+		rows := strings.Split(strings.TrimSpace(string(out)), "\n")
+		require.Len(t, rows, 5)
+		assert.Equal(t, "name,passcode,quantity,zone", rows[0])
+		assert.Equal(t, "Ash Blossom & Joyous Spring,14558127,3,main", rows[1])
+	})
+
+	t.Run("json groups cards by zone", func(t *testing.T) {
+		out, err := jsonExporter{}.Export(deck, cards)
+		require.NoError(t, err)
+
+		var decoded exportedDeck
+		require.NoError(t, json.Unmarshal(out, &decoded))
+		assert.Len(t, decoded.Main, 2)
+		assert.Len(t, decoded.Extra, 1)
+		assert.Len(t, decoded.Side, 1)
+	})
+
+	t.Run("pdf is a complete document", func(t *testing.T) {
+		out, err := pdfExporter{}.Export(deck, cards)
+		require.NoError(t, err)
+
+		assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4")))
+		assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+		assert.Contains(t, string(out), "(Monster Reborn)")
+	})
+
+	t.Run("pdf continues long decklists on new pages", func(t *testing.T) {
+		var long []models.DeckCard
+		for i := 1; i <= 60; i++ {
+			card := models.Card{CardYGOID: 1000 + i, Name: fmt.Sprintf("Monster %02d", i), FrameType: "effect"}
+			long = append(long, models.DeckCard{Card: card, Zone: models.ZoneMain, Quantity: 1})
+		}
+		out, err := pdfExporter{}.Export(deck, long)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(out), "/Count 2 ")
+		assert.Contains(t, string(out), "(Monster 60)")
+		assert.Contains(t, string(out), "(Monster Cards \\(continued\\))")
+		assert.Contains(t, string(out), "(Main Deck Total: 60)")
+
+		// Nothing is drawn in the bottom margin.
+		rects := regexp.MustCompile(`[\d.]+ ([\d.]+) [\d.]+ [\d.]+ re S`).FindAllStringSubmatch(string(out), -1)
+		require.NotEmpty(t, rects)
+		for _, m := range rects {
+			bottom, err := strconv.ParseFloat(m[1], 64)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, bottom, pdfMargin)
+		}
+	})
+}
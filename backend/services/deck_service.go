@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"mime/multipart"
+	"io"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -16,66 +18,174 @@ import (
 var ErrDeckAlreadyExists = errors.New("deck with the same name already exists")
 var ErrMaximumNumberOfDecks = errors.New("maximum number of decks reached")
 var ErrDeckNotFound = errors.New("deck not found")
+var ErrImportRejected = errors.New("deck import rejected")
+var ErrInvalidDeckData = errors.New("invalid deck data")
+var ErrNotEnoughCopies = errors.New("not enough unreserved copies in the collection")
+
+// MaxDecksPerUser is the number of decks a user can own.
+const MaxDecksPerUser = 10
+
+// ImportedCard is a card resolved from an imported deck file, with the zone it goes to.
+type ImportedCard struct {
+	CardID    uint   `json:"card_id"`
+	CardYGOID int    `json:"card_ygo_id"`
+	Name      string `json:"name"`
+	Zone      string `json:"zone"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ImportLineIssue is a line of a text decklist that could not be matched to a single card.
+type ImportLineIssue struct {
+	Line       int      `json:"line"`
+	Text       string   `json:"text"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// ImportReport describes the outcome of a deck import, or what it would be for a dry run.
+type ImportReport struct {
+	DryRun           bool              `json:"dry_run"`
+	Cards            []ImportedCard    `json:"cards"`
+	UnknownPasscodes []string          `json:"unknown_passcodes"`
+	UnresolvedLines  []ImportLineIssue `json:"unresolved_lines"`
+	AmbiguousLines   []ImportLineIssue `json:"ambiguous_lines"`
+	Violations       []string          `json:"violations"`
+	Warnings         []string          `json:"warnings"`
+}
+
+// newImportReport returns an empty report, with empty lists rather than null ones in its JSON form.
+func newImportReport(dryRun bool) *ImportReport {
+	return &ImportReport{
+		DryRun:           dryRun,
+		Cards:            []ImportedCard{},
+		UnknownPasscodes: []string{},
+		UnresolvedLines:  []ImportLineIssue{},
+		AmbiguousLines:   []ImportLineIssue{},
+		Violations:       []string{},
+		Warnings:         []string{},
+	}
+}
+
+// rejected reports whether the import cannot be applied as a whole.
+func (r *ImportReport) rejected() bool {
+	return len(r.UnknownPasscodes) > 0 || len(r.UnresolvedLines) > 0 || len(r.AmbiguousLines) > 0 || len(r.Violations) > 0
+}
+
+// maxNameCandidates is the number of candidate names reported for an ambiguous decklist line.
+const maxNameCandidates = 5
+
+// MissingCard is a deck card the user does not have enough copies of.
+type MissingCard struct {
+	CardID        uint   `json:"card_id"`
+	CardYGOID     int    `json:"card_ygo_id"`
+	Name          string `json:"name"`
+	Required      int    `json:"required"`
+	Owned         int    `json:"owned"`
+	UsedElsewhere int    `json:"used_elsewhere"`
+	Missing       int    `json:"missing"`
+}
+
+// BuildabilityReport compares a deck with the user's collection.
+type BuildabilityReport struct {
+	DeckID           uint          `json:"deck_id"`
+	Buildable        bool          `json:"buildable"`
+	CountsOtherDecks bool          `json:"counts_other_decks"`
+	TotalMissing     int           `json:"total_missing"`
+	MissingCards     []MissingCard `json:"missing_cards"`
+}
 
 // DeckService defines operations related to creating, managing and importing/exporting decks.
 type DeckService interface {
-	CreateDeck(userID uint, name, description string) (*models.Deck, error)
+	CreateDeck(userID uint, name, description string, formatID uint) (*models.Deck, error)
 	GetDecksByUserID(userID uint) ([]models.Deck, error)
+	GetDecksPageByUserID(userID uint, page repository.Page) ([]models.Deck, string, error)
 	DeleteDeck(deckID uint, userID uint) error
 	GetCardsByDeck(userID, deckID uint) ([]models.DeckCard, error)
 	ExportDeckAsYDK(userID, deckID uint) (string, error)
-	ImportDeckFromYDK(userID, deckID uint, file multipart.File) error
-	AddCardToDeck(userID, cardID, deckID uint, quantity int) error
-	RemoveCardFromDeck(userID, deckID, cardID uint, quantity int) error
+	ImportDeckFromYDK(ctx context.Context, userID, deckID uint, file io.Reader, dryRun bool) (*ImportReport, error)
+	ImportNewDeckFromYDK(ctx context.Context, userID uint, name, fileName string, file io.Reader) (*models.Deck, *ImportReport, error)
+	ExportDeckAsYDKE(userID, deckID uint) (string, error)
+	ExportDeck(userID, deckID uint, format string) (*DeckExport, error)
+	ImportDeckFromYDKE(ctx context.Context, userID, deckID uint, url string, dryRun bool) (*ImportReport, error)
+	ImportDeckFromText(ctx context.Context, userID, deckID uint, file io.Reader, dryRun bool) (*ImportReport, error)
+	AddCardToDeck(userID, cardID, deckID uint, quantity int, zone string) ([]string, error)
+	RemoveCardFromDeck(userID, deckID, cardID uint, quantity int, zone string) error
+	MoveCardBetweenZones(userID, deckID, cardID uint, fromZone, toZone string, quantity int) error
+	SetDeckFormat(userID, deckID, formatID uint) (*models.Deck, error)
+	GetMissingCards(userID, deckID uint, countOtherDecks bool) (*BuildabilityReport, error)
+	SetDeckPhysical(userID, deckID uint, physical, warnOnShortage bool) (*models.Deck, *BuildabilityReport, error)
 }
 
 type deckService struct {
-	repo            repository.DeckRepository
-	cardService     CardService
-	deckCardService DeckCardService
+	repo              repository.DeckRepository
+	cardService       CardService
+	deckCardService   DeckCardService
+	formatService     FormatService
+	collectionService CollectionService
 }
 
 // NewDeckService creates a new instance of deckService.
-func NewDeckService(repo repository.DeckRepository, cardService CardService, deckCardService DeckCardService) DeckService {
-	return &deckService{repo, cardService, deckCardService}
+func NewDeckService(repo repository.DeckRepository, cardService CardService, deckCardService DeckCardService, formatService FormatService, collectionService CollectionService) DeckService {
+	return &deckService{repo, cardService, deckCardService, formatService, collectionService}
 }
 
 // CreateDeck creates a new deck for the specified user, checking name uniqueness and deck count limit.
-func (s *deckService) CreateDeck(userID uint, name, description string) (*models.Deck, error) {
-	count, err := s.repo.CountByUserID(userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check existing decks: %w", err)
-	}
-	if count >= 10 {
-		return nil, ErrMaximumNumberOfDecks
-	}
-
-	exists, err := s.repo.ExistsByName(userID, name)
-	if err != nil {
-		return nil, err
-	}
-	if exists {
-		return nil, ErrDeckAlreadyExists
-	}
-
+// A formatID of 0 leaves the deck on the default format.
+func (s *deckService) CreateDeck(userID uint, name, description string, formatID uint) (*models.Deck, error) {
 	deck := &models.Deck{
 		UserID:      userID,
 		Name:        name,
 		Description: description,
 	}
 
-	if err := s.repo.Create(deck); err != nil {
+	if formatID != 0 {
+		format, err := s.formatService.GetFormat(userID, formatID)
+		if err != nil {
+			return nil, err
+		}
+		deck.FormatID = &format.ID
+		deck.Format = format
+	}
+
+	if err := s.repo.CreateWithCards(deck, nil, checkNewDeck(userID, name)); err != nil {
 		return nil, err
 	}
 
 	return deck, nil
 }
 
+// checkNewDeck returns the check that the user can create another deck with the given name. It runs in
+// the transaction that creates the deck, so concurrent creations cannot both pass it.
+func checkNewDeck(userID uint, name string) func(repository.DeckRepository) error {
+	return func(repo repository.DeckRepository) error {
+		count, err := repo.CountByUserID(userID)
+		if err != nil {
+			return fmt.Errorf("failed to check existing decks: %w", err)
+		}
+		if count >= MaxDecksPerUser {
+			return ErrMaximumNumberOfDecks
+		}
+
+		exists, err := repo.ExistsByName(userID, name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrDeckAlreadyExists
+		}
+		return nil
+	}
+}
+
 // GetDecksByUserID returns all decks belonging to a given user.
 func (s *deckService) GetDecksByUserID(userID uint) ([]models.Deck, error) {
 	return s.repo.FindByUserID(userID)
 }
 
+// GetDecksPageByUserID returns a page of a user's decks and the cursor of the next page.
+func (s *deckService) GetDecksPageByUserID(userID uint, page repository.Page) ([]models.Deck, string, error) {
+	return s.repo.FindPageByUserID(userID, page)
+}
+
 // DeleteDeck deletes a deck by ID and user ID, returning an error if not found.
 func (s *deckService) DeleteDeck(deckID uint, userID uint) error {
 	err := s.repo.DeleteByIDAndUserID(deckID, userID)
@@ -92,91 +202,695 @@ func (s *deckService) GetCardsByDeck(userID, deckID uint) ([]models.DeckCard, er
 
 // ExportDeckAsYDK exports the deck to YDK format (used by Yu-Gi-Oh! simulators).
 func (s *deckService) ExportDeckAsYDK(userID, deckID uint) (string, error) {
+	sections, err := s.deckSections(userID, deckID)
+	if err != nil {
+		return "", err
+	}
+
+	return formatYDK(sections), nil
+}
+
+// ExportDeck renders the deck with the exporter registered for the given format, naming the file after the deck.
+func (s *deckService) ExportDeck(userID, deckID uint, format string) (*DeckExport, error) {
+	exporter, err := GetDeckExporter(format)
+	if err != nil {
+		return nil, err
+	}
+
 	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
 	if err != nil {
-		return "", fmt.Errorf("deck not found or unauthorized: %w", err)
+		return nil, ErrDeckNotFound
 	}
 
 	deckCards, err := s.repo.FindDeckCards(deck.ID, userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to load cards: %w", err)
+		return nil, fmt.Errorf("failed to load cards: %w", err)
 	}
 
-	var mainLines, extraLines, sideLines []string
+	content, err := exporter.Export(deck, deckCards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export deck: %w", err)
+	}
+
+	return &DeckExport{
+		FileName:    DeckFileName(deck.Name, exporter.Extension()),
+		ContentType: exporter.ContentType(),
+		Content:     content,
+	}, nil
+}
+
+// ExportDeckAsYDKE returns the deck as a ydke:// URL that can be pasted into other deck builders.
+func (s *deckService) ExportDeckAsYDKE(userID, deckID uint) (string, error) {
+	sections, err := s.deckSections(userID, deckID)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.FormatYDKE(sections)
+}
+
+// deckSections lists the passcodes of every card in the deck by zone, one entry per copy.
+func (s *deckService) deckSections(userID, deckID uint) (*utils.YDKDeck, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, ErrDeckNotFound
+	}
+
+	deckCards, err := s.repo.FindDeckCards(deck.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cards: %w", err)
+	}
+
+	return sectionsFromCards(deckCards), nil
+}
+
+// sectionsFromCards lists the passcodes of the given cards by zone, one entry per copy.
+func sectionsFromCards(deckCards []models.DeckCard) *utils.YDKDeck {
+	var sections utils.YDKDeck
 	for _, c := range deckCards {
 		for i := 0; i < c.Quantity; i++ {
 			line := fmt.Sprintf("%d", c.Card.CardYGOID)
 			switch c.Zone {
-			case "main":
-				mainLines = append(mainLines, line)
-			case "extra":
-				extraLines = append(extraLines, line)
-			case "side":
-				sideLines = append(sideLines, line)
+			case models.ZoneMain:
+				sections.Main = append(sections.Main, line)
+			case models.ZoneExtra:
+				sections.Extra = append(sections.Extra, line)
+			case models.ZoneSide:
+				sections.Side = append(sections.Side, line)
 			}
 		}
 	}
 
-	result := "#main\n" + strings.Join(mainLines, "\n") + "\n#extra\n" + strings.Join(extraLines, "\n") + "\n#side\n" + strings.Join(sideLines, "\n")
+	return &sections
+}
+
+// formatYDK writes the sections of a deck in the .ydk format.
+func formatYDK(sections *utils.YDKDeck) string {
+	return "#main\n" + strings.Join(sections.Main, "\n") + "\n#extra\n" + strings.Join(sections.Extra, "\n") + "\n#side\n" + strings.Join(sections.Side, "\n")
+}
+
+// ImportDeckFromYDK imports a .ydk file into the specified deck, keeping every card in the zone
+// matching the section it is listed in. The import is all-or-nothing: cards are only written, in a
+// single transaction, when every passcode is known and no deck rule is broken. With dryRun set,
+// nothing is written and the report describes what the import would do.
+func (s *deckService) ImportDeckFromYDK(ctx context.Context, userID, deckID uint, file io.Reader, dryRun bool) (*ImportReport, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, ErrDeckNotFound
+	}
+
+	parsed, err := utils.ParseYDK(file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing YDK file: %w", err)
+	}
 
-	return result, nil
+	entries, report, err := s.prepareImport(ctx, deckID, parsed, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return s.importIntoDeck(userID, deck, entries, report)
 }
 
-// ImportDeckFromYDK imports a deck from a .ydk file, adding cards to the specified deck.
-func (s *deckService) ImportDeckFromYDK(userID, deckID uint, file multipart.File) error {
-	mainIDs, extraIDs, sideIDs, err := utils.ParseYDK(file)
+// ImportDeckFromYDKE imports a ydke:// URL into the specified deck, with the same all-or-nothing
+// behaviour as ImportDeckFromYDK.
+func (s *deckService) ImportDeckFromYDKE(ctx context.Context, userID, deckID uint, url string, dryRun bool) (*ImportReport, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
 	if err != nil {
-		return fmt.Errorf("error parsing YDK file: %w", err)
+		return nil, ErrDeckNotFound
 	}
 
-	process := func(ids []string) error {
-		for _, idStr := range ids {
-			cardYGOID, err := strconv.Atoi(idStr)
-			if err != nil {
-				return fmt.Errorf("invalid card ID %s: %w", idStr, err)
+	parsed, err := utils.ParseYDKE(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeckData, err)
+	}
+
+	entries, report, err := s.prepareImport(ctx, deckID, parsed, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return s.importIntoDeck(userID, deck, entries, report)
+}
+
+// ImportDeckFromText imports a plain-text decklist such as "3x Ash Blossom & Joyous Spring" into the
+// specified deck. Card names are matched exactly first and then approximately; lines that match no
+// card or several cards are reported, and reject the import like unknown passcodes do.
+func (s *deckService) ImportDeckFromText(ctx context.Context, userID, deckID uint, file io.Reader, dryRun bool) (*ImportReport, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, ErrDeckNotFound
+	}
+
+	lines, err := utils.ParseDecklist(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeckData, err)
+	}
+
+	report := newImportReport(dryRun)
+	var batch importBatch
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			report.UnresolvedLines = append(report.UnresolvedLines, ImportLineIssue{Line: line.Number, Text: line.Text})
+			continue
+		}
+
+		card, candidates := s.resolveDecklistLine(ctx, &line, dryRun)
+		switch {
+		case card != nil:
+			if utils.NormalizeCardName(card.Name) != utils.NormalizeCardName(line.Name) {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("line %d: matched %q to %q", line.Number, line.Name, card.Name))
 			}
+			zone := line.Section
+			if zone == "" {
+				zone = GetZoneFromCard(card)
+			}
+			batch.add(card, zone, line.Quantity)
+		case len(candidates) > 0:
+			report.AmbiguousLines = append(report.AmbiguousLines, ImportLineIssue{Line: line.Number, Text: line.Text, Candidates: candidates})
+		default:
+			report.UnresolvedLines = append(report.UnresolvedLines, ImportLineIssue{Line: line.Number, Text: line.Text})
+		}
+	}
+
+	if err := s.checkImport(deckID, batch.entries, report); err != nil {
+		return nil, err
+	}
+	return s.importIntoDeck(userID, deck, batch.entries, report)
+}
+
+// resolveDecklistLine finds the card a decklist line refers to. A line such as "7 Colored Fish" is read
+// as a single copy of a card named after the whole line when such a card is stored, or when the name
+// left by reading its leading number as a quantity matches no card; the line is updated accordingly.
+func (s *deckService) resolveDecklistLine(ctx context.Context, line *utils.DecklistLine, dryRun bool) (*models.Card, []string) {
+	if line.WholeName != "" {
+		if card := s.storedCardNamed(line.WholeName); card != nil {
+			line.Name, line.Quantity = line.WholeName, 1
+			return card, nil
+		}
+	}
+
+	card, candidates := s.resolveCardName(ctx, line.Name, dryRun)
+	if card == nil && len(candidates) == 0 && line.WholeName != "" {
+		line.Name, line.Quantity = line.WholeName, 1
+		return s.resolveCardName(ctx, line.Name, dryRun)
+	}
+	return card, candidates
+}
+
+// storedCardNamed returns the stored card with the given name, ignoring case and punctuation, if any.
+func (s *deckService) storedCardNamed(name string) *models.Card {
+	cards, _, err := s.cardService.FindStoredCards(repository.CardQuery{Name: name}, repository.Page{Limit: 50})
+	if err != nil {
+		return nil
+	}
+
+	target := utils.NormalizeCardName(name)
+	for _, card := range cards {
+		if utils.NormalizeCardName(card.Name) == target {
+			return card
+		}
+	}
+	return nil
+}
+
+// resolveCardName finds the card a decklist line refers to. An exact name is looked up through
+// GetCardByName; otherwise the local catalog is searched for names containing the given one, or
+// sharing its longest word, and the closest match by edit distance is picked. When several cards are
+// equally close, they are returned as candidates instead. With dryRun set, cards are only looked up
+// and nothing is saved.
+func (s *deckService) resolveCardName(ctx context.Context, name string, dryRun bool) (*models.Card, []string) {
+	lookup, search := s.cardService.GetCardByName, s.cardService.GetFilteredCards
+	if dryRun {
+		lookup = s.cardService.LookupCardByName
+		search = func(_ context.Context, query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+			return s.cardService.FindStoredCards(query, page)
+		}
+	}
+	if card, err := lookup(ctx, name); err == nil && card != nil {
+		return card, nil
+	}
+
+	candidates, _, err := search(ctx, repository.CardQuery{Name: name}, repository.Page{Limit: 50})
+	if err == nil && len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	containName := err == nil && len(candidates) > 0
+	if !containName {
+		candidates, _, err = search(ctx, repository.CardQuery{Name: longestWord(name)}, repository.Page{Limit: 200})
+		if err != nil || len(candidates) == 0 {
+			return nil, nil
+		}
+	}
+
+	target := utils.NormalizeCardName(name)
+	threshold := max(2, len(target)/4)
+	best := threshold + 1
+	var closest []*models.Card
+	for _, candidate := range candidates {
+		distance := utils.EditDistance(target, utils.NormalizeCardName(candidate.Name))
+		switch {
+		case distance < best:
+			best = distance
+			closest = []*models.Card{candidate}
+		case distance == best:
+			closest = append(closest, candidate)
+		}
+	}
+
+	if len(closest) == 1 {
+		return closest[0], nil
+	}
+	if len(closest) == 0 {
+		if !containName {
+			return nil, nil
+		}
+		// Nothing is close enough, but every candidate contains the name: let the user pick one.
+		closest = candidates
+	}
+
+	var names []string
+	for _, card := range closest {
+		if len(names) == maxNameCandidates {
+			break
+		}
+		names = append(names, card.Name)
+	}
+	return nil, names
+}
 
-			card, err := s.cardService.GetCardByYGOID(cardYGOID)
+// longestWord returns the longest word of a card name, used to widen the search for misspelled names.
+func longestWord(name string) string {
+	var longest string
+	for _, word := range strings.Fields(name) {
+		if len(word) > len(longest) {
+			longest = word
+		}
+	}
+	return longest
+}
+
+// importIntoDeck adds the prepared entries to the deck, unless the import is a dry run or was rejected.
+// For physical decks the copies must be available in the collection, as in AddCardToDeck: shortages
+// reject the import, or are reported as warnings when the deck allows them.
+func (s *deckService) importIntoDeck(userID uint, deck *models.Deck, entries []models.DeckCard, report *ImportReport) (*ImportReport, error) {
+	if deck.Physical {
+		if err := s.checkShortages(userID, deck, entries, report); err != nil {
+			return nil, err
+		}
+	}
+
+	if report.DryRun {
+		return report, nil
+	}
+	if report.rejected() {
+		return report, ErrImportRejected
+	}
+
+	// The deck may have changed since the report was made, so the cards are checked again as they are written.
+	violations, err := s.deckCardService.AddCardsToDeck(deck.ID, entries)
+	if errors.Is(err, ErrImportRejected) {
+		report.Violations = violations
+		return report, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkShortages compares the copies of every card of an import with the copies of the collection that
+// no physical deck holds.
+func (s *deckService) checkShortages(userID uint, deck *models.Deck, entries []models.DeckCard, report *ImportReport) error {
+	// A card can be split across zones, so the copies it needs are added up first.
+	var order []int
+	requested := make(map[int]int)
+	cards := make(map[int]*models.Card)
+	for i := range entries {
+		ygoID := entries[i].Card.CardYGOID
+		if _, ok := requested[ygoID]; !ok {
+			order = append(order, ygoID)
+			cards[ygoID] = &entries[i].Card
+		}
+		requested[ygoID] += entries[i].Quantity
+	}
+
+	for _, ygoID := range order {
+		card := cards[ygoID]
+		available := 0
+		// Cards looked up during a dry run may not be saved yet, and then cannot be in the collection.
+		if card.ID != 0 {
+			var err error
+			available, err = s.collectionService.GetAvailableQuantity(userID, card.ID)
 			if err != nil {
-				return fmt.Errorf("error retrieving card %d: %w", cardYGOID, err)
+				return err
 			}
+		}
+		if requested[ygoID] <= available {
+			continue
+		}
+		if deck.WarnOnShortage {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("only %d unreserved copies of %s in the collection", available, card.Name))
+		} else {
+			report.Violations = append(report.Violations, fmt.Sprintf("%v: %d of %s requested, %d available", ErrNotEnoughCopies, requested[ygoID], card.Name, available))
+		}
+	}
+	return nil
+}
 
-			if err := s.deckCardService.AddCardToDeck(userID, deckID, card, 1); err != nil {
-				return fmt.Errorf("error adding card %d to deck: %w", card.ID, err)
-			}
+// ImportNewDeckFromYDK creates a new deck from a .ydk file. The deck is named after the given name,
+// the "#created by" header of the file or the file name, in that order of preference.
+// Unknown passcodes are skipped and reported as warnings, while broken deck rules reject the import.
+// The deck and its cards are created in a single transaction.
+func (s *deckService) ImportNewDeckFromYDK(ctx context.Context, userID uint, name, fileName string, file io.Reader) (*models.Deck, *ImportReport, error) {
+	parsed, err := utils.ParseYDK(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing YDK file: %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = parsed.CreatedBy
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+	if name == "" || name == "." {
+		name = "Imported deck"
+	}
+
+	// Checked early to avoid resolving the cards of a deck that cannot be created.
+	if err := checkNewDeck(userID, name)(s.repo); err != nil {
+		return nil, nil, err
+	}
+
+	// The deck does not exist yet, so it is validated as an empty deck of the default format.
+	entries, report, err := s.prepareImport(ctx, 0, parsed, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(report.Violations) > 0 {
+		return nil, report, ErrImportRejected
+	}
+	for _, passcode := range report.UnknownPasscodes {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("unknown passcode %s was skipped", passcode))
+	}
+
+	deck := &models.Deck{
+		UserID:      userID,
+		Name:        name,
+		Description: "Imported from " + filepath.Base(fileName),
+	}
+	if err := s.repo.CreateWithCards(deck, entries, checkNewDeck(userID, name)); err != nil {
+		if errors.Is(err, ErrMaximumNumberOfDecks) || errors.Is(err, ErrDeckAlreadyExists) {
+			return nil, nil, err
 		}
-		return nil
+		return nil, nil, fmt.Errorf("failed to create deck: %w", err)
 	}
 
-	if err := process(mainIDs); err != nil {
-		return fmt.Errorf("error processing main cards: %w", err)
+	deck.DeckCards, err = s.repo.FindDeckCards(deck.ID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load imported cards: %w", err)
 	}
-	if err := process(extraIDs); err != nil {
-		return fmt.Errorf("error processing extra cards: %w", err)
+	return deck, report, nil
+}
+
+// prepareImport resolves the passcodes of a parsed deck file into deck entries, grouped by card
+// and zone, and checks them against the rules of the target deck. Cards missing from the local
+// database are only saved when dryRun is false.
+func (s *deckService) prepareImport(ctx context.Context, deckID uint, parsed *utils.YDKDeck, dryRun bool) ([]models.DeckCard, *ImportReport, error) {
+	sections := []struct {
+		zone      string
+		passcodes []string
+	}{
+		{models.ZoneMain, parsed.Main},
+		{models.ZoneExtra, parsed.Extra},
+		{models.ZoneSide, parsed.Side},
 	}
-	if err := process(sideIDs); err != nil {
-		return fmt.Errorf("error processing side cards: %w", err)
+
+	resolve := s.cardService.GetCardByYGOID
+	if dryRun {
+		resolve = s.cardService.LookupCardByYGOID
 	}
 
+	report := newImportReport(dryRun)
+	resolved := make(map[string]*models.Card)
+	unknown := make(map[string]bool)
+	var batch importBatch
+
+	for _, section := range sections {
+		for _, passcode := range section.passcodes {
+			if unknown[passcode] {
+				continue
+			}
+			card, ok := resolved[passcode]
+			if !ok {
+				found, err := resolveYDKPasscode(ctx, resolve, passcode)
+				if err != nil && !IsUnknownCard(err) {
+					return nil, nil, fmt.Errorf("failed to resolve passcode %s: %w", passcode, err)
+				}
+				if found == nil {
+					unknown[passcode] = true
+					report.UnknownPasscodes = append(report.UnknownPasscodes, passcode)
+					continue
+				}
+				card = found
+				resolved[passcode] = card
+			}
+			batch.add(card, section.zone, 1)
+		}
+	}
+
+	if err := s.checkImport(deckID, batch.entries, report); err != nil {
+		return nil, nil, err
+	}
+	return batch.entries, report, nil
+}
+
+// checkImport lists the resolved entries in the report and checks them against the rules of the target deck.
+func (s *deckService) checkImport(deckID uint, entries []models.DeckCard, report *ImportReport) error {
+	for _, entry := range entries {
+		report.Cards = append(report.Cards, ImportedCard{
+			CardID:    entry.CardID,
+			CardYGOID: entry.Card.CardYGOID,
+			Name:      entry.Card.Name,
+			Zone:      entry.Zone,
+			Quantity:  entry.Quantity,
+		})
+	}
+
+	violations, err := s.deckCardService.ValidateCards(deckID, entries)
+	if err != nil {
+		return fmt.Errorf("failed to validate imported cards: %w", err)
+	}
+	report.Violations = violations
 	return nil
 }
 
-// AddCardToDeck adds a card to a deck using the CardService and DeckCardService.
-func (s *deckService) AddCardToDeck(userID, cardID, deckID uint, quantity int) error {
+// importBatch collects the entries of an import, merging copies of the same card in the same zone.
+type importBatch struct {
+	entries []models.DeckCard
+	index   map[string]int
+}
+
+func (b *importBatch) add(card *models.Card, zone string, quantity int) {
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+
+	key := fmt.Sprintf("%s:%d", zone, card.CardYGOID)
+	if i, ok := b.index[key]; ok {
+		b.entries[i].Quantity += quantity
+		return
+	}
+	b.index[key] = len(b.entries)
+	b.entries = append(b.entries, models.DeckCard{CardID: card.ID, Zone: zone, Quantity: quantity, Card: *card})
+}
+
+// resolveYDKPasscode turns a passcode from a .ydk file into a card using the given resolver.
+// A passcode that is not a number resolves to no card.
+func resolveYDKPasscode(ctx context.Context, resolve func(context.Context, int) (*models.Card, error), passcode string) (*models.Card, error) {
+	cardYGOID, err := strconv.Atoi(passcode)
+	if err != nil {
+		return nil, nil
+	}
+	return resolve(ctx, cardYGOID)
+}
+
+// GetMissingCards compares the copies each card of the deck needs, across all its zones, with the copies in
+// the user's collection. With countOtherDecks set, copies used by the user's other decks are not available.
+func (s *deckService) GetMissingCards(userID, deckID uint, countOtherDecks bool) (*BuildabilityReport, error) {
+	if _, err := s.repo.FindByIDAndUserID(deckID, userID); err != nil {
+		return nil, ErrDeckNotFound
+	}
+
+	return s.buildabilityReport(userID, deckID, countOtherDecks, false)
+}
+
+// buildabilityReport compares the deck with the collection, treating the copies held by the user's other
+// decks, or only by their physical decks with physicalOnly set, as unavailable when countOtherDecks is set.
+func (s *deckService) buildabilityReport(userID, deckID uint, countOtherDecks, physicalOnly bool) (*BuildabilityReport, error) {
+	deckCards, err := s.repo.FindDeckCards(deckID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cards: %w", err)
+	}
+
+	collection, err := s.collectionService.GetUserCollection(userID, repository.CollectionFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection: %w", err)
+	}
+	owned := make(map[uint]int, len(collection))
+	for _, uc := range collection {
+		owned[uc.CardID] += uc.Quantity
+	}
+
+	usedElsewhere := map[uint]int{}
+	if countOtherDecks {
+		usedElsewhere, err = s.repo.CountCardCopiesInOtherDecks(userID, deckID, physicalOnly)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count cards in other decks: %w", err)
+		}
+	}
+
+	// A card can be split across zones, so the copies it needs are added up first.
+	var order []uint
+	required := make(map[uint]int)
+	cards := make(map[uint]models.Card)
+	for _, dc := range deckCards {
+		if _, ok := required[dc.CardID]; !ok {
+			order = append(order, dc.CardID)
+			cards[dc.CardID] = dc.Card
+		}
+		required[dc.CardID] += dc.Quantity
+	}
+
+	report := &BuildabilityReport{
+		DeckID:           deckID,
+		CountsOtherDecks: countOtherDecks,
+		MissingCards:     []MissingCard{},
+	}
+	for _, cardID := range order {
+		available := max(owned[cardID]-usedElsewhere[cardID], 0)
+		if available >= required[cardID] {
+			continue
+		}
+
+		missing := required[cardID] - available
+		report.TotalMissing += missing
+		report.MissingCards = append(report.MissingCards, MissingCard{
+			CardID:        cardID,
+			CardYGOID:     cards[cardID].CardYGOID,
+			Name:          cards[cardID].Name,
+			Required:      required[cardID],
+			Owned:         owned[cardID],
+			UsedElsewhere: usedElsewhere[cardID],
+			Missing:       missing,
+		})
+	}
+	report.Buildable = report.TotalMissing == 0
+
+	return report, nil
+}
+
+// SetDeckPhysical marks a deck as physical, reserving the copies it holds from the collection, or releases them.
+// A deck can only become physical when the collection has enough copies that no other physical deck holds,
+// unless warnOnShortage is set; otherwise ErrNotEnoughCopies is returned with the report of missing cards.
+func (s *deckService) SetDeckPhysical(userID, deckID uint, physical, warnOnShortage bool) (*models.Deck, *BuildabilityReport, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, nil, ErrDeckNotFound
+	}
+
+	var report *BuildabilityReport
+	if physical {
+		report, err = s.buildabilityReport(userID, deckID, true, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !report.Buildable && !warnOnShortage {
+			return nil, report, ErrNotEnoughCopies
+		}
+	}
+
+	if err := s.repo.UpdatePhysical(deckID, physical, warnOnShortage); err != nil {
+		return nil, nil, fmt.Errorf("failed to update deck: %w", err)
+	}
+	deck.Physical = physical
+	deck.WarnOnShortage = warnOnShortage
+
+	return deck, report, nil
+}
+
+// AddCardToDeck adds a card to a deck zone using the CardService and DeckCardService.
+// For physical decks the copies must be available in the collection: the card is refused with
+// ErrNotEnoughCopies, or added with a warning when the deck allows shortages.
+func (s *deckService) AddCardToDeck(userID, cardID, deckID uint, quantity int, zone string) ([]string, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, ErrDeckNotFound
+	}
+
 	card, err := s.cardService.GetCardByID(cardID)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve card: %w", err)
+		return nil, fmt.Errorf("failed to retrieve card: %w", err)
+	}
+
+	var warnings []string
+	if deck.Physical {
+		available, err := s.collectionService.GetAvailableQuantity(userID, cardID)
+		if err != nil {
+			return nil, err
+		}
+		if quantity > available {
+			if !deck.WarnOnShortage {
+				return nil, fmt.Errorf("%w: %d of %s requested, %d available", ErrNotEnoughCopies, quantity, card.Name, available)
+			}
+			warnings = append(warnings, fmt.Sprintf("only %d unreserved copies of %s in the collection", available, card.Name))
+		}
 	}
 
-	err = s.deckCardService.AddCardToDeck(userID, deckID, card, quantity)
+	err = s.deckCardService.AddCardToDeck(userID, deckID, card, quantity, zone)
 	if err != nil {
-		return fmt.Errorf("failed to add card to deck: %w", err)
+		return nil, fmt.Errorf("failed to add card to deck: %w", err)
 	}
 
-	return nil
+	return warnings, nil
 }
 
 // RemoveCardFromDeck removes a card from a deck by delegating to DeckCardService.
-func (s *deckService) RemoveCardFromDeck(userID, deckID, cardID uint, quantity int) error {
-	return s.deckCardService.RemoveCardFromDeck(userID, deckID, cardID, quantity)
+func (s *deckService) RemoveCardFromDeck(userID, deckID, cardID uint, quantity int, zone string) error {
+	return s.deckCardService.RemoveCardFromDeck(userID, deckID, cardID, quantity, zone)
+}
+
+// MoveCardBetweenZones moves copies of a card between the main, extra and side zones of a user's deck.
+func (s *deckService) MoveCardBetweenZones(userID, deckID, cardID uint, fromZone, toZone string, quantity int) error {
+	if _, err := s.repo.FindByIDAndUserID(deckID, userID); err != nil {
+		return ErrDeckNotFound
+	}
+
+	card, err := s.cardService.GetCardByID(cardID)
+	if err != nil {
+		return ErrCardNotFound
+	}
+
+	return s.deckCardService.MoveCardBetweenZones(deckID, card, fromZone, toZone, quantity)
+}
+
+// SetDeckFormat assigns a built-in format or one of the user's custom formats to a deck.
+func (s *deckService) SetDeckFormat(userID, deckID, formatID uint) (*models.Deck, error) {
+	deck, err := s.repo.FindByIDAndUserID(deckID, userID)
+	if err != nil {
+		return nil, ErrDeckNotFound
+	}
+
+	format, err := s.formatService.GetFormat(userID, formatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateFormat(deck.ID, &format.ID); err != nil {
+		return nil, fmt.Errorf("failed to update deck format: %w", err)
+	}
+
+	deck.FormatID = &format.ID
+	deck.Format = format
+	return deck, nil
 }
@@ -1,14 +1,20 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/client"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/database"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestCreateDeck(t *testing.T) {
@@ -20,7 +26,7 @@ func TestCreateDeck(t *testing.T) {
 
 	// Instanciamos el repositorio real con la DB de test
 	deckRepo := repository.NewDeckRepository()
-	deckService := NewDeckService(deckRepo, nil, nil) // Solo necesitas el repo aquí
+	deckService := NewDeckService(deckRepo, nil, nil, nil, nil) // Solo necesitas el repo aquí
 
 	type args struct {
 		userID      uint
@@ -81,7 +87,7 @@ func TestCreateDeck(t *testing.T) {
 				}
 			}
 
-			got, err := deckService.CreateDeck(tt.args.userID, tt.args.name, tt.args.description)
+			got, err := deckService.CreateDeck(tt.args.userID, tt.args.name, tt.args.description, 0)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateDeck() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -112,7 +118,7 @@ func TestCreateDeck(t *testing.T) {
 }
 
 func Test_deckService_GetCardsByDeck(t *testing.T) {
-	db := utils.SetupTestDB(&models.User{}, &models.Deck{}, &models.Card{}, &models.LinkMonsterCard{}, &models.MonsterCard{}, &models.PendulumMonsterCard{}, &models.SpellTrapCard{}, &models.DeckCard{})
+	db := utils.SetupTestDB(&models.User{}, &models.Deck{}, &models.Card{}, &models.LinkMonsterCard{}, &models.MonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{}, &models.SpellTrapCard{}, &models.DeckCard{})
 
 	user := models.User{Username: "TestUser", Email: "test@example.com", Password: "securepass"}
 	utils.SeedTestData(db, &user)
@@ -291,3 +297,506 @@ func Test_deckService_GetDecksByUserID(t *testing.T) {
 		})
 	}
 }
+
+func Test_deckService_GetDecksPageByUserID(t *testing.T) {
+	db := utils.SetupTestDB(&models.User{}, &models.Format{}, &models.Deck{}, &models.DeckCard{})
+	user := models.User{Username: "pager", Email: "pager@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	utils.SeedTestData(db,
+		&models.Deck{Name: "Zombies", UserID: user.ID},
+		&models.Deck{Name: "Blue-Eyes", UserID: user.ID},
+		&models.Deck{Name: "Dragonmaid", UserID: user.ID},
+	)
+
+	service := &deckService{repo: repository.NewDeckRepositoryWithDB(db)}
+
+	decks, next, err := service.GetDecksPageByUserID(user.ID, repository.Page{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, decks, 2)
+	assert.Equal(t, "Zombies", decks[0].Name)
+	assert.Equal(t, "Blue-Eyes", decks[1].Name)
+
+	decks, next, err = service.GetDecksPageByUserID(user.ID, repository.Page{Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, decks, 1)
+	assert.Equal(t, "Dragonmaid", decks[0].Name)
+	assert.Empty(t, next)
+
+	decks, _, err = service.GetDecksPageByUserID(user.ID, repository.Page{Sort: repository.DeckSortName, Desc: true})
+	require.NoError(t, err)
+	require.Len(t, decks, 3)
+	assert.Equal(t, "Zombies", decks[0].Name)
+	assert.Equal(t, "Blue-Eyes", decks[2].Name)
+}
+
+// stubCardService resolves cards from the test database only, without calling the external API.
+type stubCardService struct {
+	CardService
+	repo repository.CardRepository
+}
+
+func (s *stubCardService) GetCardByYGOID(_ context.Context, id int) (*models.Card, error) {
+	card, err := s.repo.GetByYGOProID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, client.ErrCardNotFound
+	}
+	return card, err
+}
+
+func (s *stubCardService) GetCardByID(id uint) (*models.Card, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *stubCardService) LookupCardByYGOID(ctx context.Context, id int) (*models.Card, error) {
+	return s.GetCardByYGOID(ctx, id)
+}
+
+func (s *stubCardService) GetCardByName(_ context.Context, name string) (*models.Card, error) {
+	var card models.Card
+	if err := database.DB.First(&card, "LOWER(name) = LOWER(?)", name).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (s *stubCardService) LookupCardByName(ctx context.Context, name string) (*models.Card, error) {
+	return s.GetCardByName(ctx, name)
+}
+
+func (s *stubCardService) GetFilteredCards(_ context.Context, query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+	var cards []*models.Card
+	err := database.DB.Where("name LIKE ?", "%"+query.Name+"%").Limit(page.Limit).Offset(page.Offset).Find(&cards).Error
+	return cards, "", err
+}
+
+func (s *stubCardService) FindStoredCards(query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+	return s.GetFilteredCards(context.Background(), query, page)
+}
+
+// failingCardService fails every lookup, as when the external API cannot be reached.
+type failingCardService struct {
+	CardService
+}
+
+func (s *failingCardService) LookupCardByYGOID(_ context.Context, id int) (*models.Card, error) {
+	return nil, errors.New("request timed out")
+}
+
+func Test_deckService_ImportDeckFromYDK(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "importer", Email: "importer@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	deck := models.Deck{Name: "Imported", UserID: user.ID}
+	utils.SeedTestData(db, &deck)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	link := models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}
+	utils.SeedTestData(db, &ash, &link)
+
+	formatService := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	service := &deckService{
+		repo:        repository.NewDeckRepositoryWithDB(db),
+		cardService: &stubCardService{repo: repository.NewCardRepository()},
+		deckCardService: NewDeckCardService(
+			repository.NewDeckCardRepositoryWithDB(db),
+			formatService,
+			NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+		),
+		formatService: formatService,
+	}
+
+	countEntries := func() int64 {
+		var count int64
+		db.Model(&models.DeckCard{}).Where("deck_id = ?", deck.ID).Count(&count)
+		return count
+	}
+
+	t.Run("dry run reports unknown passcodes without writing", func(t *testing.T) {
+		ydk := "#created by tester\n#main\n14558127\n14558127\n99999999\n#extra\n1861629\n!side\n14558127\n"
+		report, err := service.ImportDeckFromYDK(context.Background(), user.ID, deck.ID, strings.NewReader(ydk), true)
+		require.NoError(t, err)
+
+		assert.True(t, report.DryRun)
+		assert.Equal(t, []string{"99999999"}, report.UnknownPasscodes)
+		assert.Len(t, report.Cards, 3)
+		assert.Empty(t, report.Violations)
+		assert.Equal(t, int64(0), countEntries())
+	})
+
+	t.Run("rejected import writes nothing", func(t *testing.T) {
+		ydk := "#main\n14558127\n14558127\n#extra\n1861629\n!side\n14558127\n14558127\n"
+		report, err := service.ImportDeckFromYDK(context.Background(), user.ID, deck.ID, strings.NewReader(ydk), false)
+		assert.ErrorIs(t, err, ErrImportRejected)
+		require.NotNil(t, report)
+		assert.Len(t, report.Violations, 1)
+		assert.Equal(t, int64(0), countEntries())
+	})
+
+	t.Run("failed lookups are errors, not unknown passcodes", func(t *testing.T) {
+		failing := *service
+		failing.cardService = &failingCardService{}
+		report, err := failing.ImportDeckFromYDK(context.Background(), user.ID, deck.ID, strings.NewReader("#main\n14558127\n"), true)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrImportRejected)
+		assert.Nil(t, report)
+	})
+
+	t.Run("rules are checked again when the cards are written", func(t *testing.T) {
+		entries := []models.DeckCard{{CardID: ash.ID, Zone: models.ZoneMain, Quantity: 4, Card: ash}}
+		report := newImportReport(false)
+		_, err := service.importIntoDeck(user.ID, &deck, entries, report)
+		assert.ErrorIs(t, err, ErrImportRejected)
+		assert.Len(t, report.Violations, 1)
+		assert.Equal(t, int64(0), countEntries())
+	})
+
+	t.Run("keeps the section of every card", func(t *testing.T) {
+		ydk := "#main\n14558127\n14558127\n#extra\n1861629\n!side\n14558127\n"
+		_, err := service.ImportDeckFromYDK(context.Background(), user.ID, deck.ID, strings.NewReader(ydk), false)
+		require.NoError(t, err)
+
+		var side models.DeckCard
+		require.NoError(t, db.First(&side, "deck_id = ? AND card_id = ? AND zone = ?", deck.ID, ash.ID, models.ZoneSide).Error)
+		assert.Equal(t, 1, side.Quantity)
+		assert.Equal(t, int64(3), countEntries())
+	})
+}
+
+func Test_deckService_ImportNewDeckFromYDK(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "newdeck", Email: "newdeck@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	link := models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}
+	utils.SeedTestData(db, &ash, &link)
+
+	formatService := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	service := &deckService{
+		repo:        repository.NewDeckRepositoryWithDB(db),
+		cardService: &stubCardService{repo: repository.NewCardRepository()},
+		deckCardService: NewDeckCardService(
+			repository.NewDeckCardRepositoryWithDB(db),
+			formatService,
+			NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+		),
+		formatService: formatService,
+	}
+
+	t.Run("names the deck after the created by header and warns about unknown passcodes", func(t *testing.T) {
+		ydk := "#created by Branded\n#main\n14558127\n99999999\n#extra\n1861629\n"
+		deck, report, err := service.ImportNewDeckFromYDK(context.Background(), user.ID, "", "branded.ydk", strings.NewReader(ydk))
+		require.NoError(t, err)
+
+		assert.Equal(t, "Branded", deck.Name)
+		assert.Len(t, deck.DeckCards, 2)
+		assert.Len(t, report.Warnings, 1)
+	})
+
+	t.Run("falls back to the file name", func(t *testing.T) {
+		deck, _, err := service.ImportNewDeckFromYDK(context.Background(), user.ID, "", "Tearlaments.ydk", strings.NewReader("#main\n14558127\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "Tearlaments", deck.Name)
+	})
+
+	t.Run("rejects a duplicated name", func(t *testing.T) {
+		_, _, err := service.ImportNewDeckFromYDK(context.Background(), user.ID, "Branded", "other.ydk", strings.NewReader("#main\n14558127\n"))
+		assert.ErrorIs(t, err, ErrDeckAlreadyExists)
+	})
+
+	t.Run("rejected import does not create the deck", func(t *testing.T) {
+		ydk := "#main\n14558127\n14558127\n14558127\n14558127\n"
+		_, report, err := service.ImportNewDeckFromYDK(context.Background(), user.ID, "Too many", "x.ydk", strings.NewReader(ydk))
+		assert.ErrorIs(t, err, ErrImportRejected)
+		require.NotNil(t, report)
+
+		var count int64
+		db.Model(&models.Deck{}).Where("name = ?", "Too many").Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func Test_deckService_YDKERoundTrip(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "ydke", Email: "ydke@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	source := models.Deck{Name: "Source", UserID: user.ID}
+	target := models.Deck{Name: "Target", UserID: user.ID}
+	utils.SeedTestData(db, &source, &target)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	link := models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}
+	utils.SeedTestData(db, &ash, &link)
+	utils.SeedTestData(db,
+		&models.DeckCard{DeckID: source.ID, CardID: ash.ID, Zone: models.ZoneMain, Quantity: 2},
+		&models.DeckCard{DeckID: source.ID, CardID: link.ID, Zone: models.ZoneExtra, Quantity: 1},
+		&models.DeckCard{DeckID: source.ID, CardID: ash.ID, Zone: models.ZoneSide, Quantity: 1},
+	)
+
+	formatService := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	service := &deckService{
+		repo:        repository.NewDeckRepositoryWithDB(db),
+		cardService: &stubCardService{repo: repository.NewCardRepository()},
+		deckCardService: NewDeckCardService(
+			repository.NewDeckCardRepositoryWithDB(db),
+			formatService,
+			NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+		),
+		formatService: formatService,
+	}
+
+	url, err := service.ExportDeckAsYDKE(user.ID, source.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "ydke://"))
+
+	_, err = service.ExportDeckAsYDKE(user.ID+1, source.ID)
+	assert.ErrorIs(t, err, ErrDeckNotFound)
+
+	report, err := service.ImportDeckFromYDKE(context.Background(), user.ID, target.ID, url, false)
+	require.NoError(t, err)
+	assert.Len(t, report.Cards, 3)
+
+	var side models.DeckCard
+	require.NoError(t, db.First(&side, "deck_id = ? AND card_id = ? AND zone = ?", target.ID, ash.ID, models.ZoneSide).Error)
+	assert.Equal(t, 1, side.Quantity)
+
+	_, err = service.ImportDeckFromYDKE(context.Background(), user.ID, target.ID, "ydke://not-base64!!!", true)
+	assert.ErrorIs(t, err, ErrInvalidDeckData)
+}
+
+// lookupOnlyCardService fails the test when a card could be fetched and saved.
+type lookupOnlyCardService struct {
+	stubCardService
+	t *testing.T
+}
+
+func (s *lookupOnlyCardService) GetCardByName(ctx context.Context, name string) (*models.Card, error) {
+	s.t.Errorf("GetCardByName(%q) called during a dry run", name)
+	return s.stubCardService.GetCardByName(ctx, name)
+}
+
+func (s *lookupOnlyCardService) GetFilteredCards(ctx context.Context, query repository.CardQuery, page repository.Page) ([]*models.Card, string, error) {
+	s.t.Errorf("GetFilteredCards(%q) called during a dry run", query.Name)
+	return s.stubCardService.GetFilteredCards(ctx, query, page)
+}
+
+func Test_deckService_ImportDeckFromText(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "texter", Email: "texter@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	deck := models.Deck{Name: "Text", UserID: user.ID}
+	utils.SeedTestData(db, &deck)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	link := models.Card{CardYGOID: 1861629, Name: "Decode Talker", FrameType: "link"}
+	heat := models.Card{CardYGOID: 1861630, Name: "Decode Talker Heatsoul", FrameType: "link"}
+	fish := models.Card{CardYGOID: 23771716, Name: "7 Colored Fish", FrameType: "normal"}
+	utils.SeedTestData(db, &ash, &link, &heat, &fish)
+
+	formatService := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	service := &deckService{
+		repo:        repository.NewDeckRepositoryWithDB(db),
+		cardService: &stubCardService{repo: repository.NewCardRepository()},
+		deckCardService: NewDeckCardService(
+			repository.NewDeckCardRepositoryWithDB(db),
+			formatService,
+			NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+		),
+		formatService: formatService,
+	}
+
+	t.Run("reports ambiguous and unresolved lines", func(t *testing.T) {
+		list := "Main Deck:\n3x Ash Blossom\nSome Unknown Card\nExtra Deck:\n1 Decode\n"
+		report, err := service.ImportDeckFromText(context.Background(), user.ID, deck.ID, strings.NewReader(list), false)
+		assert.ErrorIs(t, err, ErrImportRejected)
+		require.NotNil(t, report)
+
+		require.Len(t, report.AmbiguousLines, 1)
+		assert.ElementsMatch(t, []string{"Decode Talker", "Decode Talker Heatsoul"}, report.AmbiguousLines[0].Candidates)
+		require.Len(t, report.UnresolvedLines, 1)
+		assert.Equal(t, 3, report.UnresolvedLines[0].Line)
+	})
+
+	t.Run("leading numbers can be part of the name", func(t *testing.T) {
+		list := "7 Colored Fish\n2x 7 Colored Fish\n3 Ash Blossom & Joyous Spring\n"
+		report, err := service.ImportDeckFromText(context.Background(), user.ID, deck.ID, strings.NewReader(list), true)
+		require.NoError(t, err)
+		assert.Empty(t, report.Warnings)
+		assert.Equal(t, []ImportedCard{
+			{CardID: fish.ID, CardYGOID: fish.CardYGOID, Name: "7 Colored Fish", Zone: models.ZoneMain, Quantity: 3},
+			{CardID: ash.ID, CardYGOID: ash.CardYGOID, Name: "Ash Blossom & Joyous Spring", Zone: models.ZoneMain, Quantity: 3},
+		}, report.Cards)
+	})
+
+	t.Run("dry run only looks cards up", func(t *testing.T) {
+		dryRun := *service
+		dryRun.cardService = &lookupOnlyCardService{stubCardService: stubCardService{repo: repository.NewCardRepository()}, t: t}
+		list := "Ash Blossom & Joyous Spring\n1x Decode Talkr\n"
+		report, err := dryRun.ImportDeckFromText(context.Background(), user.ID, deck.ID, strings.NewReader(list), true)
+		require.NoError(t, err)
+		assert.Len(t, report.Cards, 2)
+	})
+
+	t.Run("matches names approximately and infers zones", func(t *testing.T) {
+		list := "// my deck\nAsh Blossom and Joyous Spring x2\n1x Decode Talkr\n"
+		report, err := service.ImportDeckFromText(context.Background(), user.ID, deck.ID, strings.NewReader(list), false)
+		require.NoError(t, err)
+		assert.Len(t, report.Warnings, 1)
+
+		var extra models.DeckCard
+		require.NoError(t, db.First(&extra, "deck_id = ? AND card_id = ?", deck.ID, link.ID).Error)
+		assert.Equal(t, models.ZoneExtra, extra.Zone)
+
+		var main models.DeckCard
+		require.NoError(t, db.First(&main, "deck_id = ? AND card_id = ?", deck.ID, ash.ID).Error)
+		assert.Equal(t, 2, main.Quantity)
+	})
+}
+
+func Test_deckService_GetMissingCards(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{}, &models.UserCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "builder", Email: "builder@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	deck := models.Deck{Name: "Main", UserID: user.ID}
+	other := models.Deck{Name: "Other", UserID: user.ID}
+	utils.SeedTestData(db, &deck, &other)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	reborn := models.Card{CardYGOID: 83764718, Name: "Monster Reborn", FrameType: "spell"}
+	utils.SeedTestData(db, &ash, &reborn)
+	utils.SeedTestData(db,
+		&models.DeckCard{DeckID: deck.ID, CardID: ash.ID, Zone: models.ZoneMain, Quantity: 2},
+		&models.DeckCard{DeckID: deck.ID, CardID: ash.ID, Zone: models.ZoneSide, Quantity: 1},
+		&models.DeckCard{DeckID: deck.ID, CardID: reborn.ID, Zone: models.ZoneMain, Quantity: 1},
+		&models.DeckCard{DeckID: other.ID, CardID: reborn.ID, Zone: models.ZoneMain, Quantity: 1},
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: ash.ID, Quantity: 2}),
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: reborn.ID, Quantity: 1}),
+	)
+
+	service := NewDeckService(
+		repository.NewDeckRepositoryWithDB(db), nil, nil, nil,
+		NewCollectionService(repository.NewCollectionRepositoryWithDB(db)),
+	)
+
+	report, err := service.GetMissingCards(user.ID, deck.ID, false)
+	require.NoError(t, err)
+	assert.False(t, report.Buildable)
+	require.Len(t, report.MissingCards, 1)
+	assert.Equal(t, MissingCard{CardID: ash.ID, CardYGOID: ash.CardYGOID, Name: ash.Name, Required: 3, Owned: 2, Missing: 1}, report.MissingCards[0])
+
+	report, err = service.GetMissingCards(user.ID, deck.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.TotalMissing)
+	assert.Len(t, report.MissingCards, 2)
+
+	_, err = service.GetMissingCards(user.ID+1, deck.ID, false)
+	assert.ErrorIs(t, err, ErrDeckNotFound)
+}
+
+func Test_deckService_PhysicalDecks(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Format{}, &models.Deck{}, &models.Card{}, &models.DeckCard{}, &models.UserCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.Banlist{}, &models.BanlistEntry{},
+	)
+	database.DB = db
+
+	user := models.User{Username: "physical", Email: "physical@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+	deck := models.Deck{Name: "Paper", UserID: user.ID}
+	other := models.Deck{Name: "Other paper", UserID: user.ID, Physical: true}
+	utils.SeedTestData(db, &deck, &other)
+
+	ash := models.Card{CardYGOID: 14558127, Name: "Ash Blossom & Joyous Spring", FrameType: "effect"}
+	called := models.Card{CardYGOID: 24224830, Name: "Called by the Grave", FrameType: "spell"}
+	utils.SeedTestData(db, &ash, &called)
+	utils.SeedTestData(db,
+		inUnspecifiedPrinting(db, &models.UserCard{UserID: user.ID, CardID: ash.ID, Quantity: 3}),
+		&models.DeckCard{DeckID: other.ID, CardID: ash.ID, Zone: models.ZoneMain, Quantity: 1},
+		&models.DeckCard{DeckID: deck.ID, CardID: ash.ID, Zone: models.ZoneMain, Quantity: 1},
+	)
+
+	formatService := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	service := NewDeckService(
+		repository.NewDeckRepositoryWithDB(db),
+		&stubCardService{repo: repository.NewCardRepository()},
+		NewDeckCardService(
+			repository.NewDeckCardRepositoryWithDB(db),
+			formatService,
+			NewBanlistService(repository.NewBanlistRepositoryWithDB(db)),
+		),
+		formatService,
+		NewCollectionService(repository.NewCollectionRepositoryWithDB(db)),
+	)
+
+	_, _, err := service.SetDeckPhysical(user.ID, deck.ID, true, false)
+	require.NoError(t, err)
+
+	t.Run("refuses cards without unreserved copies", func(t *testing.T) {
+		_, err := service.AddCardToDeck(user.ID, ash.ID, deck.ID, 2, models.ZoneMain)
+		assert.ErrorIs(t, err, ErrNotEnoughCopies)
+
+		warnings, err := service.AddCardToDeck(user.ID, ash.ID, deck.ID, 1, models.ZoneMain)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("warns when the deck allows shortages", func(t *testing.T) {
+		_, _, err := service.SetDeckPhysical(user.ID, deck.ID, true, true)
+		require.NoError(t, err)
+
+		warnings, err := service.AddCardToDeck(user.ID, ash.ID, deck.ID, 1, models.ZoneSide)
+		require.NoError(t, err)
+		assert.Len(t, warnings, 1)
+
+		_, err = service.AddCardToDeck(user.ID, ash.ID, other.ID, 1, models.ZoneSide)
+		assert.ErrorIs(t, err, ErrNotEnoughCopies)
+	})
+
+	t.Run("imports need the same copies", func(t *testing.T) {
+		report, err := service.ImportDeckFromText(context.Background(), user.ID, other.ID, strings.NewReader("1x Ash Blossom & Joyous Spring\n"), false)
+		assert.ErrorIs(t, err, ErrImportRejected)
+		require.NotNil(t, report)
+		require.Len(t, report.Violations, 1)
+		assert.Contains(t, report.Violations[0], "1 of Ash Blossom & Joyous Spring requested, 0 available")
+
+		report, err = service.ImportDeckFromText(context.Background(), user.ID, deck.ID, strings.NewReader("2x Called by the Grave\n"), true)
+		require.NoError(t, err)
+		assert.Empty(t, report.Violations)
+		assert.Len(t, report.Warnings, 1)
+	})
+
+	t.Run("refuses decks of other users", func(t *testing.T) {
+		_, err := service.AddCardToDeck(user.ID+1, ash.ID, deck.ID, 1, models.ZoneMain)
+		assert.ErrorIs(t, err, ErrDeckNotFound)
+	})
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFormatNotFound = errors.New("format not found")
+	ErrInvalidFormat  = errors.New("invalid format rules")
+)
+
+// CustomFormatCode is the code given to every user-defined format.
+const CustomFormatCode = "CUSTOM"
+
+// DefaultFormats lists the built-in formats created on startup. The first one is the default.
+var DefaultFormats = []models.Format{
+	{Code: models.DefaultFormatCode, Name: "TCG Advanced", BanlistFormat: "TCG", MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, MaxCopies: 3},
+	{Code: "OCG", Name: "OCG", BanlistFormat: "OCG", MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, MaxCopies: 3},
+	{Code: "GOAT", Name: "Goat", BanlistFormat: "GOAT", MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, MaxCopies: 3},
+	{Code: "EDISON", Name: "Edison", BanlistFormat: "EDISON", MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, MaxCopies: 3},
+	{Code: "SPEED", Name: "Speed Duel", BanlistFormat: "SPEED", MainMin: 20, MainMax: 30, ExtraMax: 5, SideMax: 5, MaxCopies: 3},
+}
+
+// FormatViolation describes a deck zone whose size is outside the limits of a format.
+type FormatViolation struct {
+	Zone  string `json:"zone"`
+	Count int    `json:"count"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+}
+
+// FormatService defines operations to manage game formats and their deck construction rules.
+type FormatService interface {
+	EnsureDefaultFormats() error
+	GetFormats(userID uint) ([]models.Format, error)
+	GetFormat(userID, formatID uint) (*models.Format, error)
+	CreateFormat(userID uint, format models.Format) (*models.Format, error)
+	GetDeckFormat(deckID uint) (*models.Format, error)
+	CheckDeckRules(format *models.Format, deckCards []models.DeckCard) []FormatViolation
+}
+
+type formatService struct {
+	repo repository.FormatRepository
+}
+
+// NewFormatService creates a new instance of formatService.
+func NewFormatService(repo repository.FormatRepository) FormatService {
+	return &formatService{repo: repo}
+}
+
+// EnsureDefaultFormats creates the built-in formats that do not exist yet.
+func (s *formatService) EnsureDefaultFormats() error {
+	for _, def := range DefaultFormats {
+		existing, err := s.repo.FindBuiltInByCode(def.Code)
+		if err != nil {
+			return fmt.Errorf("failed to look up format %s: %w", def.Code, err)
+		}
+		if existing != nil {
+			continue
+		}
+
+		format := def
+		if err := s.repo.Create(&format); err != nil {
+			return fmt.Errorf("failed to create format %s: %w", def.Code, err)
+		}
+	}
+	return nil
+}
+
+// GetFormats returns the built-in formats and the custom formats of the user.
+func (s *formatService) GetFormats(userID uint) ([]models.Format, error) {
+	return s.repo.FindAvailable(userID)
+}
+
+// GetFormat returns a format if it is built-in or belongs to the user.
+func (s *formatService) GetFormat(userID, formatID uint) (*models.Format, error) {
+	format, err := s.repo.FindByID(formatID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFormatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if format.UserID != nil && *format.UserID != userID {
+		return nil, ErrFormatNotFound
+	}
+	return format, nil
+}
+
+// CreateFormat stores a custom format with house rules for the user.
+func (s *formatService) CreateFormat(userID uint, format models.Format) (*models.Format, error) {
+	switch {
+	case format.Name == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidFormat)
+	case format.MainMax <= 0 || format.MainMin < 0 || format.MainMin > format.MainMax:
+		return nil, fmt.Errorf("%w: main deck limits must satisfy 0 <= min <= max and max > 0", ErrInvalidFormat)
+	case format.ExtraMax < 0 || format.SideMax < 0:
+		return nil, fmt.Errorf("%w: extra and side deck limits cannot be negative", ErrInvalidFormat)
+	case format.MaxCopies <= 0:
+		return nil, fmt.Errorf("%w: at least one copy per card must be allowed", ErrInvalidFormat)
+	}
+
+	format.ID = 0
+	format.UserID = &userID
+	format.Code = CustomFormatCode
+
+	if err := s.repo.Create(&format); err != nil {
+		return nil, fmt.Errorf("failed to create format: %w", err)
+	}
+	return &format, nil
+}
+
+// GetDeckFormat returns the format assigned to a deck, falling back to the default format.
+func (s *formatService) GetDeckFormat(deckID uint) (*models.Format, error) {
+	format, err := s.repo.FindByDeckID(deckID)
+	if err != nil {
+		return nil, err
+	}
+	if format != nil {
+		return format, nil
+	}
+
+	format, err = s.repo.FindBuiltInByCode(models.DefaultFormatCode)
+	if err != nil {
+		return nil, err
+	}
+	if format == nil {
+		def := DefaultFormats[0]
+		return &def, nil
+	}
+	return format, nil
+}
+
+// CheckDeckRules reports every deck zone whose size is outside the limits of the format.
+func (s *formatService) CheckDeckRules(format *models.Format, deckCards []models.DeckCard) []FormatViolation {
+	totals := make(map[string]int)
+	for _, dc := range deckCards {
+		totals[dc.Zone] += dc.Quantity
+	}
+
+	limits := []FormatViolation{
+		{Zone: models.ZoneMain, Min: format.MainMin, Max: format.MainMax},
+		{Zone: models.ZoneExtra, Max: format.ExtraMax},
+		{Zone: models.ZoneSide, Max: format.SideMax},
+	}
+
+	violations := []FormatViolation{}
+	for _, limit := range limits {
+		limit.Count = totals[limit.Zone]
+		if limit.Count < limit.Min || limit.Count > limit.Max {
+			violations = append(violations, limit)
+		}
+	}
+	return violations
+}
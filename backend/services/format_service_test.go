@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_formatService_GetDeckFormat(t *testing.T) {
+	db := utils.SetupTestDB(&models.User{}, &models.Format{}, &models.Deck{})
+	service := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+	require.NoError(t, service.EnsureDefaultFormats())
+	require.NoError(t, service.EnsureDefaultFormats())
+
+	var count int64
+	db.Model(&models.Format{}).Count(&count)
+	assert.Equal(t, int64(len(DefaultFormats)), count)
+
+	user := models.User{Username: "tester", Email: "tester@example.com", Password: "hashed"}
+	utils.SeedTestData(db, &user)
+
+	speed := models.Format{}
+	require.NoError(t, db.First(&speed, "code = ?", "SPEED").Error)
+
+	plain := models.Deck{Name: "No format", UserID: user.ID}
+	speedDeck := models.Deck{Name: "Speed", UserID: user.ID, FormatID: &speed.ID}
+	utils.SeedTestData(db, &plain, &speedDeck)
+
+	got, err := service.GetDeckFormat(plain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultFormatCode, got.Code)
+
+	got, err = service.GetDeckFormat(speedDeck.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, got.MainMax)
+}
+
+func Test_formatService_CreateFormat(t *testing.T) {
+	db := utils.SetupTestDB(&models.Format{})
+	service := NewFormatService(repository.NewFormatRepositoryWithDB(db))
+
+	format, err := service.CreateFormat(7, models.Format{Name: "Highlander", MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, MaxCopies: 1})
+	require.NoError(t, err)
+	assert.Equal(t, CustomFormatCode, format.Code)
+	require.NotNil(t, format.UserID)
+	assert.Equal(t, uint(7), *format.UserID)
+
+	_, err = service.GetFormat(8, format.ID)
+	assert.ErrorIs(t, err, ErrFormatNotFound)
+
+	_, err = service.CreateFormat(7, models.Format{Name: "Broken", MainMin: 50, MainMax: 40, MaxCopies: 3})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func Test_formatService_CheckDeckRules(t *testing.T) {
+	service := &formatService{}
+	deckCards := []models.DeckCard{
+		{Zone: "main", Quantity: 3},
+		{Zone: "extra", Quantity: 6},
+	}
+
+	violations := service.CheckDeckRules(&DefaultFormats[4], deckCards)
+	require.Len(t, violations, 2)
+	assert.Equal(t, "main", violations[0].Zone)
+	assert.Equal(t, 20, violations[0].Min)
+	assert.Equal(t, "extra", violations[1].Zone)
+	assert.Equal(t, 6, violations[1].Count)
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+	"gorm.io/gorm"
+)
+
+var ErrImageJobNotFound = errors.New("image job not found")
+
+// ImageQueueConfig configures the image download workers. Zero values use the defaults.
+type ImageQueueConfig struct {
+	Workers      int           // default 4
+	MaxAttempts  int           // default 5
+	PollInterval time.Duration // default 5s
+	RetryBackoff time.Duration // default 30s, doubled after every failed attempt
+	Lease        time.Duration // default 2m, a running job not renewed for this long is requeued
+}
+
+// ImageJobStatus describes the image download of a card.
+type ImageJobStatus struct {
+	CardID        uint      `json:"card_id"`
+	CardYGOID     int       `json:"card_ygo_id"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ImageURL      string    `json:"image_url"`
+	ThumbnailURL  string    `json:"thumbnail_url"`
+	ArtURL        string    `json:"art_url"`
+}
+
+// ImageQueueService downloads card images into the image store in the background.
+type ImageQueueService interface {
+	Enqueue(card *models.Card, sourceURL string) error
+	Start(ctx context.Context)
+	GetJobStatus(cardID uint) (*ImageJobStatus, error)
+	GetQueueStats() (map[string]int64, error)
+}
+
+type imageQueueService struct {
+	jobs   repository.ImageJobRepository
+	cards  repository.CardRepository
+	images storage.ImageStore
+	config ImageQueueConfig
+	wake   chan struct{}
+}
+
+func NewImageQueueService(jobs repository.ImageJobRepository, cards repository.CardRepository, images storage.ImageStore, config ImageQueueConfig) ImageQueueService {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 30 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 2 * time.Minute
+	}
+
+	return &imageQueueService{
+		jobs:   jobs,
+		cards:  cards,
+		images: images,
+		config: config,
+		wake:   make(chan struct{}, config.Workers),
+	}
+}
+
+// Enqueue queues the download of a saved card's image from sourceURL.
+func (s *imageQueueService) Enqueue(card *models.Card, sourceURL string) error {
+	job := &models.ImageJob{CardID: card.ID, CardYGOID: card.CardYGOID, SourceURL: sourceURL}
+	if err := s.jobs.Enqueue(job); err != nil {
+		return fmt.Errorf("failed to queue image of card %d: %w", card.CardYGOID, err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start starts the workers and the requeueing of jobs abandoned by stopped backends, which all stop when ctx is done.
+func (s *imageQueueService) Start(ctx context.Context) {
+	go s.requeueStale(ctx)
+	for i := 0; i < s.config.Workers; i++ {
+		go s.work(ctx)
+	}
+}
+
+// requeueStale periodically returns running jobs whose lease expired to the queue. Workers renew the lease of
+// the jobs they run, so these are jobs whose backend stopped, not jobs another instance is still running.
+func (s *imageQueueService) requeueStale(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Lease)
+	defer ticker.Stop()
+
+	for {
+		if requeued, err := s.jobs.RequeueStale(time.Now().Add(-s.config.Lease)); err != nil {
+			log.Printf("Failed to requeue interrupted image jobs: %v", err)
+		} else if requeued > 0 {
+			log.Printf("Requeued %d interrupted image jobs", requeued)
+			for i := int64(0); i < requeued; i++ {
+				select {
+				case s.wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renewLease keeps the lease of a running job until ctx is done.
+func (s *imageQueueService) renewLease(ctx context.Context, jobID uint) {
+	ticker := time.NewTicker(s.config.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.jobs.RenewLease(jobID, time.Now()); err != nil {
+				log.Printf("Failed to renew lease of image job %d: %v", jobID, err)
+			}
+		}
+	}
+}
+
+func (s *imageQueueService) work(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := s.processNext(ctx)
+		if err != nil {
+			log.Printf("Image queue error: %v", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext runs the next due job, and reports whether there was one.
+func (s *imageQueueService) processNext(ctx context.Context) (bool, error) {
+	job, err := s.jobs.ClaimNext(time.Now())
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	leaseCtx, stopLease := context.WithCancel(ctx)
+	go s.renewLease(leaseCtx, job.ID)
+	err = s.ingest(ctx, job)
+	stopLease()
+
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by a shutdown, which does not count as an attempt.
+		return true, s.jobs.MarkFailed(job.ID, job.Attempts, err.Error(), &job.NextAttemptAt)
+	}
+	if err != nil {
+		attempts := job.Attempts + 1
+		var retryAt *time.Time
+		if attempts < s.config.MaxAttempts {
+			next := time.Now().Add(s.config.RetryBackoff << (attempts - 1))
+			retryAt = &next
+		}
+		log.Printf("Image download of card %d failed (attempt %d of %d): %v", job.CardYGOID, attempts, s.config.MaxAttempts, err)
+		return true, s.jobs.MarkFailed(job.ID, attempts, err.Error(), retryAt)
+	}
+
+	return true, s.jobs.MarkDone(job.ID)
+}
+
+// ingest stores the image of the job's card and its derivatives, and points the card to the stored copies.
+func (s *imageQueueService) ingest(ctx context.Context, job *models.ImageJob) error {
+	card, err := s.cards.GetByID(job.CardID)
+	if err != nil {
+		return fmt.Errorf("failed to load card: %w", err)
+	}
+
+	urls, err := storage.StoreCardImages(ctx, s.images, job.CardYGOID, card.FrameType, job.SourceURL)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cards.UpdateImages(card.ID, urls.Image, urls.Thumbnail, urls.Art); err != nil {
+		return fmt.Errorf("failed to update card images: %w", err)
+	}
+	return nil
+}
+
+// GetJobStatus returns the image download status of a card.
+func (s *imageQueueService) GetJobStatus(cardID uint) (*ImageJobStatus, error) {
+	job, err := s.jobs.FindByCardID(cardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrImageJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ImageJobStatus{
+		CardID:        job.CardID,
+		CardYGOID:     job.CardYGOID,
+		Status:        job.Status,
+		Attempts:      job.Attempts,
+		LastError:     job.LastError,
+		NextAttemptAt: job.NextAttemptAt,
+		UpdatedAt:     job.UpdatedAt,
+	}
+	if card, err := s.cards.GetByID(cardID); err == nil {
+		status.ImageURL = card.ImageURL
+		status.ThumbnailURL = card.ThumbnailURL
+		status.ArtURL = card.ArtURL
+	}
+	return status, nil
+}
+
+// GetQueueStats returns the number of jobs in each status.
+func (s *imageQueueService) GetQueueStats() (map[string]int64, error) {
+	return s.jobs.CountByStatus()
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/storage"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_imageQueueService_Retries(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.Card{}, &models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{}, &models.CardSet{}, &models.CardPrinting{},
+		&models.ImageJob{},
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	card := models.Card{CardYGOID: 46986414, Name: "Dark Magician", FrameType: "normal", ImageURL: server.URL + "/46986414.jpg"}
+	utils.SeedTestData(db, &card)
+
+	images, err := storage.NewLocalImageStore(t.TempDir(), "")
+	require.NoError(t, err)
+	jobs := repository.NewImageJobRepositoryWithDB(db)
+	queue := NewImageQueueService(jobs, repository.NewCardRepositoryWithDB(db), images,
+		ImageQueueConfig{MaxAttempts: 2, RetryBackoff: time.Hour}).(*imageQueueService)
+
+	_, err = queue.GetJobStatus(card.ID)
+	assert.ErrorIs(t, err, ErrImageJobNotFound)
+
+	require.NoError(t, queue.Enqueue(&card, card.ImageURL))
+
+	processed, err := queue.processNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	status, err := queue.GetJobStatus(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImageJobPending, status.Status)
+	assert.Equal(t, 1, status.Attempts)
+	assert.Contains(t, status.LastError, "503")
+	assert.True(t, status.NextAttemptAt.After(time.Now().Add(59*time.Minute)))
+
+	// The retry is not due yet.
+	processed, err = queue.processNext(context.Background())
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	require.NoError(t, db.Model(&models.ImageJob{}).Where("card_id = ?", card.ID).Update("next_attempt_at", time.Now()).Error)
+	processed, err = queue.processNext(context.Background())
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	status, err = queue.GetJobStatus(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImageJobFailed, status.Status)
+	assert.Equal(t, 2, status.Attempts)
+	assert.Equal(t, card.ImageURL, status.ImageURL)
+
+	stats, err := queue.GetQueueStats()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"pending": 0, "running": 0, "done": 0, "failed": 1}, stats)
+
+	// Queueing the card again starts over.
+	require.NoError(t, queue.Enqueue(&card, card.ImageURL))
+	status, err = queue.GetJobStatus(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImageJobPending, status.Status)
+	assert.Equal(t, 0, status.Attempts)
+}
+
+func Test_imageJobRepository_RequeueStale(t *testing.T) {
+	db := utils.SetupTestDB(&models.Card{}, &models.ImageJob{})
+	card := models.Card{CardYGOID: 46986414, Name: "Dark Magician"}
+	utils.SeedTestData(db, &card)
+
+	jobs := repository.NewImageJobRepositoryWithDB(db)
+	require.NoError(t, jobs.Enqueue(&models.ImageJob{CardID: card.ID, CardYGOID: card.CardYGOID, SourceURL: "https://example.com/1.jpg"}))
+
+	job, err := jobs.ClaimNext(time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, models.ImageJobRunning, job.Status)
+	jobID := job.ID
+
+	job, err = jobs.ClaimNext(time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	// A job whose lease is still being renewed belongs to a live worker.
+	require.NoError(t, jobs.RenewLease(jobID, time.Now()))
+	requeued, err := jobs.RequeueStale(time.Now().Add(-2 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), requeued)
+	job, err = jobs.ClaimNext(time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	requeued, err = jobs.RequeueStale(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), requeued)
+
+	job, err = jobs.ClaimNext(time.Now())
+	require.NoError(t, err)
+	assert.NotNil(t, job)
+}
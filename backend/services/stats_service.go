@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
 )
 
 type AvgStats struct {
@@ -16,6 +17,7 @@ type Stats struct {
 	SpellCount   int            `json:"spell"`
 	TrapCount    int            `json:"trap"`
 	Attributes   map[string]int `json:"attributes"`
+	Archetypes   map[string]int `json:"archetypes"`
 	AverageStats AvgStats       `json:"average_stats"`
 	TotalCards   int            `json:"total_cards"`
 }
@@ -45,7 +47,7 @@ func (s *statsService) CalculateDeckStats(deckCards []models.DeckCard) Stats {
 }
 
 func (s *statsService) CalculateCollectionStats(userID uint) (Stats, error) {
-	userCards, err := s.collectionService.GetUserCollection(userID)
+	userCards, err := s.collectionService.GetUserCollection(userID, repository.CollectionFilter{})
 	if err != nil {
 		return Stats{}, err
 	}
@@ -82,6 +84,7 @@ func calculateStats(cards []CardWithQuantity) Stats {
 		SpellCount:   countCardTypes(cards, "spell"),
 		TrapCount:    countCardTypes(cards, "trap"),
 		Attributes:   countMonsterAttributes(cards),
+		Archetypes:   countArchetypes(cards),
 		AverageStats: computeAverageStats(cards),
 		TotalCards:   countTotalCards(cards),
 	}
@@ -110,6 +113,17 @@ func countMonsterAttributes(cards []CardWithQuantity) map[string]int {
 	return attributes
 }
 
+// countArchetypes returns the number of copies of each archetype. Cards without an archetype are not counted.
+func countArchetypes(cards []CardWithQuantity) map[string]int {
+	archetypes := make(map[string]int)
+	for _, c := range cards {
+		if c.Card.Archetype != "" {
+			archetypes[c.Card.Archetype] += c.Quantity
+		}
+	}
+	return archetypes
+}
+
 func computeAverageStats(cards []CardWithQuantity) AvgStats {
 	var (
 		totalATK, totalDEF, monsterCount int
@@ -47,6 +47,19 @@ func TestCountMonsterAttributes(t *testing.T) {
 	assert.Equal(t, 1, attrs["LIGHT"])
 }
 
+func TestCountArchetypes(t *testing.T) {
+	cards := []CardWithQuantity{
+		{Card: models.Card{Name: "Blue-Eyes White Dragon", Archetype: "Blue-Eyes"}, Quantity: 3},
+		{Card: models.Card{Name: "Blue-Eyes Alternative White Dragon", Archetype: "Blue-Eyes"}, Quantity: 2},
+		{Card: models.Card{Name: "Dark Magician", Archetype: "Dark Magician"}, Quantity: 1},
+		{Card: models.Card{Name: "Raigeki"}, Quantity: 1},
+	}
+
+	archetypes := countArchetypes(cards)
+
+	assert.Equal(t, map[string]int{"Blue-Eyes": 5, "Dark Magician": 1}, archetypes)
+}
+
 func TestComputeAverageStats(t *testing.T) {
 	cards := []CardWithQuantity{
 		{
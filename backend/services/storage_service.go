@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLocationNotFound = errors.New("storage location not found")
+	ErrInvalidLocation  = errors.New("invalid storage location")
+	ErrInvalidMove      = errors.New("invalid move")
+)
+
+// locationKinds are the accepted kinds of storage locations.
+var locationKinds = []string{models.LocationBinder, models.LocationBox, models.LocationDeckBox, models.LocationOther}
+
+// StorageCount is the number of distinct cards and of copies kept somewhere.
+type StorageCount struct {
+	Cards    int64 `json:"cards"`
+	Quantity int64 `json:"quantity"`
+}
+
+// LocationSummary is a storage location and what it holds.
+type LocationSummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	StorageCount
+}
+
+// StorageInventory lists the storage locations of a user with what they hold, and the copies of the
+// collection not stored in any of them.
+type StorageInventory struct {
+	Locations  []LocationSummary `json:"locations"`
+	Unassigned StorageCount      `json:"unassigned"`
+}
+
+// StorageService defines operations to manage storage locations and where the copies of a collection are kept.
+type StorageService interface {
+	GetInventory(userID uint) (*StorageInventory, error)
+	CreateLocation(userID uint, name, kind string) (*models.StorageLocation, error)
+	DeleteLocation(userID, locationID uint) error
+	GetLocationCards(userID, locationID uint) ([]models.StoredCard, error)
+	GetCardLocations(userID, cardID uint) ([]models.StoredCard, error)
+	MoveCopies(userID uint, move repository.CopyMove) error
+}
+
+type storageService struct {
+	repo repository.StorageRepository
+}
+
+// NewStorageService creates a new instance of storageService.
+func NewStorageService(repo repository.StorageRepository) StorageService {
+	return &storageService{repo: repo}
+}
+
+// GetInventory returns every storage location of the user, sorted by name, with the number of cards and
+// copies each holds, and those of the collection not stored anywhere.
+func (s *storageService) GetInventory(userID uint) (*StorageInventory, error) {
+	locations, err := s.repo.GetLocations(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch storage locations for user %d: %w", userID, err)
+	}
+	counts, err := s.repo.CountStoredCards(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not count stored cards for user %d: %w", userID, err)
+	}
+	unassigned, err := s.repo.CountUnassigned(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not count unassigned cards for user %d: %w", userID, err)
+	}
+
+	byLocation := make(map[uint]StorageCount, len(counts))
+	for _, count := range counts {
+		byLocation[count.LocationID] = StorageCount{Cards: count.Cards, Quantity: count.Quantity}
+	}
+
+	inventory := &StorageInventory{
+		Locations:  make([]LocationSummary, 0, len(locations)),
+		Unassigned: StorageCount{Cards: unassigned.Cards, Quantity: unassigned.Quantity},
+	}
+	for _, location := range locations {
+		inventory.Locations = append(inventory.Locations, LocationSummary{
+			ID:           location.ID,
+			Name:         location.Name,
+			Kind:         location.Kind,
+			StorageCount: byLocation[location.ID],
+		})
+	}
+	return inventory, nil
+}
+
+// CreateLocation stores a new storage location for the user. An empty kind stands for other.
+func (s *storageService) CreateLocation(userID uint, name, kind string) (*models.StorageLocation, error) {
+	name = strings.TrimSpace(name)
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		kind = models.LocationOther
+	}
+
+	switch {
+	case name == "":
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidLocation)
+	case !slices.Contains(locationKinds, kind):
+		return nil, fmt.Errorf("%w: kind must be one of %s", ErrInvalidLocation, strings.Join(locationKinds, ", "))
+	}
+
+	location := models.StorageLocation{UserID: userID, Name: name, Kind: kind}
+	if err := s.repo.CreateLocation(&location); err != nil {
+		return nil, fmt.Errorf("failed to create storage location: %w", err)
+	}
+	return &location, nil
+}
+
+// DeleteLocation deletes a storage location of the user. The copies it held become unassigned.
+func (s *storageService) DeleteLocation(userID, locationID uint) error {
+	if _, err := s.getLocation(userID, locationID); err != nil {
+		return err
+	}
+	return s.repo.DeleteLocation(locationID)
+}
+
+// GetLocationCards returns the copies kept in a storage location of the user, sorted by page and slot.
+func (s *storageService) GetLocationCards(userID, locationID uint) ([]models.StoredCard, error) {
+	if _, err := s.getLocation(userID, locationID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStoredCards(locationID)
+}
+
+// GetCardLocations returns the storage locations holding copies of a card in the user's collection.
+func (s *storageService) GetCardLocations(userID, cardID uint) ([]models.StoredCard, error) {
+	return s.repo.GetCardLocations(userID, cardID)
+}
+
+// MoveCopies moves copies of an entry of the user's collection between storage locations, or into or out of
+// them when a location is zero. The attributes select the entry exactly, empty ones meaning unspecified.
+// Pages and slots are optional, and only apply to locations.
+func (s *storageService) MoveCopies(userID uint, move repository.CopyMove) error {
+	if move.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidMove)
+	}
+	if err := checkCopyAttributes(move.CopyAttributes); err != nil {
+		return err
+	}
+	if move.From == move.To {
+		return fmt.Errorf("%w: the copies are already there", ErrInvalidMove)
+	}
+	for _, position := range []repository.StoragePosition{move.From, move.To} {
+		if position.Page < 0 || position.Slot < 0 {
+			return fmt.Errorf("%w: page and slot cannot be negative", ErrInvalidMove)
+		}
+		if position.LocationID == 0 {
+			if position.Page != 0 || position.Slot != 0 {
+				return fmt.Errorf("%w: unassigned copies have no page or slot", ErrInvalidMove)
+			}
+			continue
+		}
+		if _, err := s.getLocation(userID, position.LocationID); err != nil {
+			return err
+		}
+	}
+
+	move.UserID = userID
+	if err := s.repo.MoveCopies(move); err != nil {
+		return fmt.Errorf("could not move copies of card %d: %w", move.CardID, err)
+	}
+	return nil
+}
+
+// getLocation returns a storage location if it belongs to the user.
+func (s *storageService) getLocation(userID, locationID uint) (*models.StorageLocation, error) {
+	location, err := s.repo.GetLocation(locationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if location.UserID != userID {
+		return nil, ErrLocationNotFound
+	}
+	return location, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_storageService_Locations(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.CardSet{}, &models.CardPrinting{}, &models.UserCard{}, &models.StorageLocation{}, &models.StoredCard{},
+		&models.Format{}, &models.Deck{}, &models.DeckCard{},
+		&models.MonsterCard{}, &models.SpellTrapCard{}, &models.LinkMonsterCard{}, &models.PendulumMonsterCard{},
+	)
+
+	user := models.User{Username: "keeper", Email: "keeper@example.com"}
+	stranger := models.User{Username: "stranger", Email: "stranger@example.com"}
+	card := models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon"}
+	other := models.Card{CardYGOID: 46986414, Name: "Dark Magician"}
+	utils.SeedTestData(db, &user, &stranger, &card, &other)
+
+	collection := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+	s := NewStorageService(repository.NewStorageRepositoryWithDB(db))
+
+	nearMint := models.CopyAttributes{Condition: models.ConditionNearMint}
+	require.NoError(t, collection.AddCardToCollection(user.ID, card.ID, 0, nearMint, 4))
+	require.NoError(t, collection.AddCardToCollection(user.ID, other.ID, 0, models.CopyAttributes{}, 2))
+
+	binder, err := s.CreateLocation(user.ID, " Trade binder ", "Binder")
+	require.NoError(t, err)
+	assert.Equal(t, "Trade binder", binder.Name)
+	assert.Equal(t, models.LocationBinder, binder.Kind)
+	box, err := s.CreateLocation(user.ID, "Bulk box", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.LocationOther, box.Kind)
+	foreign, err := s.CreateLocation(stranger.ID, "Not mine", models.LocationBox)
+	require.NoError(t, err)
+	_, err = s.CreateLocation(user.ID, "Shelf", "shelf")
+	assert.ErrorIs(t, err, ErrInvalidLocation)
+	_, err = s.CreateLocation(user.ID, "  ", models.LocationBox)
+	assert.ErrorIs(t, err, ErrInvalidLocation)
+
+	move := func(cardID uint, attrs models.CopyAttributes, from, to repository.StoragePosition, quantity int) error {
+		return s.MoveCopies(user.ID, repository.CopyMove{CardID: cardID, CopyAttributes: attrs, From: from, To: to, Quantity: quantity})
+	}
+	unassigned := repository.StoragePosition{}
+	page1 := repository.StoragePosition{LocationID: binder.ID, Page: 1, Slot: 3}
+
+	require.NoError(t, move(card.ID, nearMint, unassigned, page1, 3))
+	require.NoError(t, move(card.ID, nearMint, page1, repository.StoragePosition{LocationID: box.ID}, 1))
+	require.NoError(t, move(other.ID, models.CopyAttributes{}, unassigned, repository.StoragePosition{LocationID: box.ID}, 2))
+
+	// Moves fail as a whole when the source holds too few copies.
+	assert.ErrorIs(t, move(card.ID, nearMint, unassigned, page1, 2), repository.ErrNotEnoughCopies)
+	assert.ErrorIs(t, move(card.ID, nearMint, page1, unassigned, 3), repository.ErrNotEnoughCopies)
+	assert.ErrorIs(t, move(card.ID, models.CopyAttributes{}, unassigned, page1, 1), repository.ErrNotEnoughCopies)
+	assert.ErrorIs(t, move(card.ID, nearMint, page1, page1, 1), ErrInvalidMove)
+	assert.ErrorIs(t, move(card.ID, nearMint, repository.StoragePosition{Page: 2}, page1, 1), ErrInvalidMove)
+	assert.ErrorIs(t, move(card.ID, nearMint, unassigned, repository.StoragePosition{LocationID: foreign.ID}, 1), ErrLocationNotFound)
+
+	inventory, err := s.GetInventory(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []LocationSummary{
+		{ID: box.ID, Name: "Bulk box", Kind: models.LocationOther, StorageCount: StorageCount{Cards: 2, Quantity: 3}},
+		{ID: binder.ID, Name: "Trade binder", Kind: models.LocationBinder, StorageCount: StorageCount{Cards: 1, Quantity: 2}},
+	}, inventory.Locations)
+	assert.Equal(t, StorageCount{Cards: 1, Quantity: 1}, inventory.Unassigned)
+
+	stored, err := s.GetLocationCards(user.ID, binder.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 1, stored[0].Page)
+	assert.Equal(t, 3, stored[0].Slot)
+	assert.Equal(t, 2, stored[0].Quantity)
+	assert.Equal(t, "Blue-Eyes White Dragon", stored[0].Card.Name)
+	_, err = s.GetLocationCards(stranger.ID, binder.ID)
+	assert.ErrorIs(t, err, ErrLocationNotFound)
+
+	locations, err := s.GetCardLocations(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, locations, 2)
+	assert.Equal(t, "Trade binder", locations[0].Location.Name)
+
+	entries, err := collection.GetUserCollection(user.ID, repository.CollectionFilter{LocationID: binder.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, card.ID, entries[0].CardID)
+	entries, err = collection.GetUserCollection(user.ID, repository.CollectionFilter{Unassigned: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, card.ID, entries[0].CardID)
+
+	// Removing copies from the collection takes unassigned copies first, then stored ones.
+	require.NoError(t, collection.DecreaseCardQuantity(user.ID, card.ID, 0, models.CopyAttributes{}, 2))
+	locations, err = s.GetCardLocations(user.ID, card.ID)
+	require.NoError(t, err)
+	require.Len(t, locations, 1)
+	assert.Equal(t, binder.ID, locations[0].LocationID)
+	assert.Equal(t, 2, locations[0].Quantity)
+
+	// Deleting a location leaves its copies unassigned.
+	require.NoError(t, s.DeleteLocation(user.ID, box.ID))
+	assert.ErrorIs(t, s.DeleteLocation(user.ID, foreign.ID), ErrLocationNotFound)
+	inventory, err = s.GetInventory(user.ID)
+	require.NoError(t, err)
+	require.Len(t, inventory.Locations, 1)
+	assert.Equal(t, StorageCount{Cards: 1, Quantity: 2}, inventory.Unassigned)
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWishlistEntryNotFound = errors.New("wishlist entry not found")
+	ErrInvalidWishlistEntry  = errors.New("invalid wishlist entry")
+)
+
+// TradeKeepCopies is how many copies of each card users keep out of trades, a playset. Copies reserved by
+// physical decks beyond it are kept too.
+const TradeKeepCopies = 3
+
+// TradeableCard is a card of a collection with copies to spare.
+type TradeableCard struct {
+	Card     models.Card `json:"card"`
+	Owned    int         `json:"owned"`
+	Reserved int         `json:"reserved"`
+	Quantity int         `json:"quantity"` // copies to spare
+}
+
+// TradeMatch is a card one user wishes for and another can spare.
+type TradeMatch struct {
+	UserID          uint   `json:"user_id"` // the other user
+	Username        string `json:"username"`
+	CardID          uint   `json:"card_id"`
+	CardName        string `json:"card_name"`
+	WishlistEntryID uint   `json:"wishlist_entry_id"`
+	Quantity        int    `json:"quantity"` // copies that can change hands
+}
+
+// TradeMatches lists who has cards on a user's wishlist, and who wants the cards the user can spare.
+type TradeMatches struct {
+	TheyHave []TradeMatch `json:"they_have"`
+	TheyWant []TradeMatch `json:"they_want"`
+}
+
+// TradeService defines operations on wishlists and the copies users can trade.
+type TradeService interface {
+	GetWishlist(userID uint) ([]models.WishlistEntry, error)
+	AddToWishlist(userID uint, entry models.WishlistEntry) (*models.WishlistEntry, error)
+	RemoveFromWishlist(userID, entryID uint) error
+	GetTradeable(userID uint) ([]TradeableCard, error)
+	GetMatches(userID uint) (*TradeMatches, error)
+}
+
+type tradeService struct {
+	repo           repository.TradeRepository
+	collectionRepo repository.CollectionRepository
+}
+
+// NewTradeService creates a new instance of tradeService.
+func NewTradeService(repo repository.TradeRepository, collectionRepo repository.CollectionRepository) TradeService {
+	return &tradeService{
+		repo:           repo,
+		collectionRepo: collectionRepo,
+	}
+}
+
+// GetWishlist returns the wishlist of the user.
+func (s *tradeService) GetWishlist(userID uint) ([]models.WishlistEntry, error) {
+	entries, err := s.repo.GetWishlist(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch wishlist for user %d: %w", userID, err)
+	}
+	return entries, nil
+}
+
+// AddToWishlist adds a card to the user's wishlist, or sets the quantity wished for when the same card,
+// printing and condition are already on it. The printing, if any, must be one of the card's.
+func (s *tradeService) AddToWishlist(userID uint, entry models.WishlistEntry) (*models.WishlistEntry, error) {
+	if entry.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidWishlistEntry)
+	}
+	if err := checkCopyAttributes(models.CopyAttributes{Condition: entry.Condition}); err != nil {
+		return nil, err
+	}
+	if entry.PrintingID != nil {
+		printing, err := s.collectionRepo.GetPrinting(*entry.PrintingID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && printing.CardID != entry.CardID) {
+			return nil, ErrPrintingNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entry.ID = 0
+	entry.UserID = userID
+	if err := s.repo.SaveWishlistEntry(&entry); err != nil {
+		return nil, fmt.Errorf("failed to add card %d to wishlist: %w", entry.CardID, err)
+	}
+	return &entry, nil
+}
+
+// RemoveFromWishlist removes an entry from the user's wishlist.
+func (s *tradeService) RemoveFromWishlist(userID, entryID uint) error {
+	entry, err := s.repo.GetWishlistEntry(entryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && entry.UserID != userID) {
+		return ErrWishlistEntryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteWishlistEntry(entryID)
+}
+
+// GetTradeable returns the cards of the user's collection with copies to spare, sorted by name: those
+// beyond TradeKeepCopies and beyond the copies reserved by physical decks.
+func (s *tradeService) GetTradeable(userID uint) ([]TradeableCard, error) {
+	copies, err := s.repo.GetUserCopies(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch collection for user %d: %w", userID, err)
+	}
+	reserved, err := s.repo.GetReservedQuantities(cardIDsOf(copies))
+	if err != nil {
+		return nil, fmt.Errorf("could not count reserved copies for user %d: %w", userID, err)
+	}
+
+	owned := make(map[uint]*TradeableCard)
+	for _, userCard := range copies {
+		card, ok := owned[userCard.CardID]
+		if !ok {
+			card = &TradeableCard{Card: userCard.Card}
+			owned[userCard.CardID] = card
+		}
+		card.Owned += userCard.Quantity
+	}
+	for _, r := range reserved {
+		if card, ok := owned[r.CardID]; ok && r.UserID == userID {
+			card.Reserved = r.Quantity
+		}
+	}
+
+	tradeable := []TradeableCard{}
+	for _, card := range owned {
+		card.Quantity = card.Owned - max(TradeKeepCopies, card.Reserved)
+		if card.Quantity > 0 {
+			tradeable = append(tradeable, *card)
+		}
+	}
+	slices.SortFunc(tradeable, func(a, b TradeableCard) int {
+		return cmp.Or(cmp.Compare(a.Card.Name, b.Card.Name), cmp.Compare(a.Card.ID, b.Card.ID))
+	})
+	return tradeable, nil
+}
+
+// GetMatches finds the other users who can spare copies of the cards on the user's wishlist, and those who
+// wish for the cards the user can spare. Each match is capped by the copies wished for and the spare copies
+// that satisfy the printing and condition of the wish, which are not counted again for another wish of the
+// same user, see allocateWishes. Matches are sorted by username and card name.
+func (s *tradeService) GetMatches(userID uint) (*TradeMatches, error) {
+	wishlist, err := s.repo.GetWishlist(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch wishlist for user %d: %w", userID, err)
+	}
+	theirCopies, err := s.repo.GetCardCopies(wishCardIDs(wishlist), userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch copies of wished cards: %w", err)
+	}
+	myCopies, err := s.repo.GetUserCopies(userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch collection for user %d: %w", userID, err)
+	}
+	myCards := cardIDsOf(myCopies)
+	theirWishes, err := s.repo.GetWishes(myCards, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch wishes for the user's cards: %w", err)
+	}
+	reserved, err := s.repo.GetReservedQuantities(append(wishCardIDs(wishlist), myCards...))
+	if err != nil {
+		return nil, fmt.Errorf("could not count reserved copies: %w", err)
+	}
+
+	theirSpare := spareCopies(theirCopies, reserved)
+	mySpare := spareCopies(myCopies, reserved)
+
+	matches := &TradeMatches{TheyHave: []TradeMatch{}, TheyWant: []TradeMatch{}}
+	for otherID, spare := range theirSpare {
+		given := allocateWishes(wishlist, spare)
+		for _, wish := range wishlist {
+			if quantity := given[wish.ID]; quantity > 0 {
+				matches.TheyHave = append(matches.TheyHave, TradeMatch{
+					UserID:          otherID,
+					CardID:          wish.CardID,
+					CardName:        wish.Card.Name,
+					WishlistEntryID: wish.ID,
+					Quantity:        quantity,
+				})
+			}
+		}
+	}
+	wishesBy := make(map[uint][]models.WishlistEntry)
+	for _, wish := range theirWishes {
+		wishesBy[wish.UserID] = append(wishesBy[wish.UserID], wish)
+	}
+	for otherID, wishes := range wishesBy {
+		given := allocateWishes(wishes, mySpare[userID])
+		for _, wish := range wishes {
+			if quantity := given[wish.ID]; quantity > 0 {
+				matches.TheyWant = append(matches.TheyWant, TradeMatch{
+					UserID:          otherID,
+					CardID:          wish.CardID,
+					CardName:        wish.Card.Name,
+					WishlistEntryID: wish.ID,
+					Quantity:        quantity,
+				})
+			}
+		}
+	}
+
+	if err := s.nameMatches(matches.TheyHave, matches.TheyWant); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// nameMatches sets the username of the other user of every match, and sorts them by username and card name.
+func (s *tradeService) nameMatches(lists ...[]TradeMatch) error {
+	var userIDs []uint
+	for _, list := range lists {
+		for _, match := range list {
+			userIDs = append(userIDs, match.UserID)
+		}
+	}
+	usernames, err := s.repo.GetUsernames(userIDs)
+	if err != nil {
+		return fmt.Errorf("could not fetch usernames: %w", err)
+	}
+
+	for _, list := range lists {
+		for i := range list {
+			list[i].Username = usernames[list[i].UserID]
+		}
+		slices.SortFunc(list, func(a, b TradeMatch) int {
+			return cmp.Or(
+				cmp.Compare(a.Username, b.Username),
+				cmp.Compare(a.CardName, b.CardName),
+				cmp.Compare(a.WishlistEntryID, b.WishlistEntryID),
+			)
+		})
+	}
+	return nil
+}
+
+// spareCopy is a collection entry and how many of its copies its owner can spare.
+type spareCopy struct {
+	userCard models.UserCard
+	quantity int
+}
+
+// spareCopies returns the copies every user holding copies can spare, by user and card, worst condition first.
+// The copies kept, TradeKeepCopies or those reserved by physical decks, are taken from the worst ones.
+func spareCopies(copies []models.UserCard, reserved []repository.ReservedQuantity) map[uint]map[uint][]spareCopy {
+	spare := make(map[uint]map[uint][]spareCopy)
+	for _, userCard := range copies {
+		if spare[userCard.UserID] == nil {
+			spare[userCard.UserID] = make(map[uint][]spareCopy)
+		}
+		spare[userCard.UserID][userCard.CardID] = append(spare[userCard.UserID][userCard.CardID], spareCopy{userCard: userCard, quantity: userCard.Quantity})
+	}
+
+	reservedBy := make(map[[2]uint]int, len(reserved))
+	for _, r := range reserved {
+		reservedBy[[2]uint{r.UserID, r.CardID}] = r.Quantity
+	}
+
+	for userID, cards := range spare {
+		for cardID, entries := range cards {
+			slices.SortStableFunc(entries, func(a, b spareCopy) int {
+				return cmp.Compare(conditionRank(b.userCard.Condition), conditionRank(a.userCard.Condition))
+			})
+			keep := max(TradeKeepCopies, reservedBy[[2]uint{userID, cardID}])
+			for i := range entries {
+				kept := min(keep, entries[i].quantity)
+				entries[i].quantity -= kept
+				keep -= kept
+			}
+		}
+	}
+	return spare
+}
+
+// allocateWishes returns how many copies a user can give for each of the wishes of another user, by wishlist
+// entry, given the user's spare copies by card. A copy is only given for one wish: stricter wishes, for a
+// printing or a better condition, are served first, each with the worst copies that satisfy it.
+func allocateWishes(wishes []models.WishlistEntry, spare map[uint][]spareCopy) map[uint]int {
+	left := make(map[uint][]spareCopy, len(spare))
+	for cardID, entries := range spare {
+		left[cardID] = slices.Clone(entries)
+	}
+
+	wishes = slices.Clone(wishes)
+	slices.SortStableFunc(wishes, func(a, b models.WishlistEntry) int {
+		if (a.PrintingID != nil) != (b.PrintingID != nil) {
+			if a.PrintingID != nil {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(conditionRank(a.Condition), conditionRank(b.Condition))
+	})
+
+	given := make(map[uint]int, len(wishes))
+	for _, wish := range wishes {
+		entries := left[wish.CardID]
+		for i := range entries {
+			wanted := wish.Quantity - given[wish.ID]
+			if wanted <= 0 {
+				break
+			}
+			if satisfiesWish(entries[i].userCard, wish) {
+				quantity := min(wanted, entries[i].quantity)
+				entries[i].quantity -= quantity
+				given[wish.ID] += quantity
+			}
+		}
+	}
+	return given
+}
+
+// satisfiesWish tells whether copies are of the printing a wish asks for, if any, and in its condition or
+// better. Copies of unknown condition only satisfy wishes accepting any condition.
+func satisfiesWish(userCard models.UserCard, wish models.WishlistEntry) bool {
+	if wish.PrintingID != nil && userCard.PrintingID != *wish.PrintingID {
+		return false
+	}
+	return wish.Condition == "" || conditionRank(userCard.Condition) <= conditionRank(wish.Condition)
+}
+
+// conditionRank ranks a condition from the best, mint, to the worst. Unknown conditions rank last.
+func conditionRank(condition string) int {
+	conditions := copyAttributeValues["condition"]
+	if rank := slices.Index(conditions, condition); rank >= 0 {
+		return rank
+	}
+	return len(conditions)
+}
+
+// cardIDsOf returns the IDs of the cards of collection entries, without duplicates.
+func cardIDsOf(copies []models.UserCard) []uint {
+	ids := make([]uint, 0, len(copies))
+	for _, userCard := range copies {
+		ids = append(ids, userCard.CardID)
+	}
+	return uniqueIDs(ids)
+}
+
+// wishCardIDs returns the IDs of the cards of wishlist entries, without duplicates.
+func wishCardIDs(entries []models.WishlistEntry) []uint {
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.CardID)
+	}
+	return uniqueIDs(ids)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/models"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/repository"
+	"github.com/Grajal/SW2-YugiCollectionManager/backend/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tradeService_Matches(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.CardSet{}, &models.CardPrinting{}, &models.UserCard{}, &models.WishlistEntry{},
+		&models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{},
+	)
+
+	me := models.User{Username: "me", Email: "me@example.com"}
+	alice := models.User{Username: "alice", Email: "alice@example.com"}
+	bob := models.User{Username: "bob", Email: "bob@example.com"}
+	blueEyes := models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon"}
+	magician := models.Card{CardYGOID: 46986414, Name: "Dark Magician"}
+	pot := models.Card{CardYGOID: 55144522, Name: "Pot of Greed"}
+	utils.SeedTestData(db, &me, &alice, &bob, &blueEyes, &magician, &pot)
+
+	lob := models.CardPrinting{CardID: blueEyes.ID, SetCode: "LOB-001", Rarity: "Ultra Rare"}
+	mrd := models.CardPrinting{CardID: pot.ID, SetCode: "MRD-065", Rarity: "Rare"}
+	utils.SeedTestData(db, &lob, &mrd)
+
+	collection := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+	s := NewTradeService(repository.NewTradeRepositoryWithDB(db), repository.NewCollectionRepositoryWithDB(db))
+
+	mint := models.CopyAttributes{Condition: models.ConditionMint}
+	require.NoError(t, collection.AddCardToCollection(alice.ID, blueEyes.ID, lob.ID, mint, 4))
+	require.NoError(t, collection.AddCardToCollection(alice.ID, blueEyes.ID, 0, models.CopyAttributes{}, 1))
+	require.NoError(t, collection.AddCardToCollection(bob.ID, blueEyes.ID, 0, models.CopyAttributes{Condition: models.ConditionDamaged}, 5))
+	require.NoError(t, collection.AddCardToCollection(bob.ID, magician.ID, 0, models.CopyAttributes{}, 5))
+	require.NoError(t, collection.AddCardToCollection(me.ID, pot.ID, 0, models.CopyAttributes{}, 6))
+
+	// Copies reserved by physical decks beyond a playset are not spare.
+	deck := models.Deck{Name: "Spellcasters", UserID: bob.ID, Physical: true}
+	utils.SeedTestData(db, &deck)
+	utils.SeedTestData(db, &models.DeckCard{DeckID: deck.ID, CardID: magician.ID, Zone: models.ZoneMain, Quantity: 4})
+
+	_, err := s.AddToWishlist(me.ID, models.WishlistEntry{CardID: blueEyes.ID, Quantity: 0})
+	assert.ErrorIs(t, err, ErrInvalidWishlistEntry)
+	_, err = s.AddToWishlist(me.ID, models.WishlistEntry{CardID: blueEyes.ID, Condition: "pristine", Quantity: 1})
+	assert.ErrorIs(t, err, ErrInvalidCopyAttributes)
+	_, err = s.AddToWishlist(me.ID, models.WishlistEntry{CardID: blueEyes.ID, PrintingID: &mrd.ID, Quantity: 1})
+	assert.ErrorIs(t, err, ErrPrintingNotFound)
+
+	wish, err := s.AddToWishlist(me.ID, models.WishlistEntry{CardID: blueEyes.ID, Condition: models.ConditionNearMint, Quantity: 1})
+	require.NoError(t, err)
+	again, err := s.AddToWishlist(me.ID, models.WishlistEntry{CardID: blueEyes.ID, Condition: models.ConditionNearMint, Quantity: 3})
+	require.NoError(t, err)
+	assert.Equal(t, wish.ID, again.ID)
+	_, err = s.AddToWishlist(me.ID, models.WishlistEntry{CardID: magician.ID, Quantity: 2})
+	require.NoError(t, err)
+
+	aliceWish, err := s.AddToWishlist(alice.ID, models.WishlistEntry{CardID: pot.ID, Quantity: 5})
+	require.NoError(t, err)
+	_, err = s.AddToWishlist(bob.ID, models.WishlistEntry{CardID: pot.ID, PrintingID: &mrd.ID, Quantity: 1})
+	require.NoError(t, err)
+
+	wishlist, err := s.GetWishlist(me.ID)
+	require.NoError(t, err)
+	require.Len(t, wishlist, 2)
+	assert.Equal(t, 3, wishlist[0].Quantity)
+	assert.Equal(t, "Blue-Eyes White Dragon", wishlist[0].Card.Name)
+
+	tradeable, err := s.GetTradeable(bob.ID)
+	require.NoError(t, err)
+	assert.Len(t, tradeable, 2)
+	assert.Equal(t, "Blue-Eyes White Dragon", tradeable[0].Card.Name)
+	assert.Equal(t, 2, tradeable[0].Quantity)
+	assert.Equal(t, TradeableCard{Card: tradeable[1].Card, Owned: 5, Reserved: 4, Quantity: 1}, tradeable[1])
+
+	matches, err := s.GetMatches(me.ID)
+	require.NoError(t, err)
+	// Bob's damaged Blue-Eyes are worse than wished for, and his Dark Magicians are mostly reserved.
+	assert.Equal(t, []TradeMatch{
+		{UserID: alice.ID, Username: "alice", CardID: blueEyes.ID, CardName: "Blue-Eyes White Dragon", WishlistEntryID: wish.ID, Quantity: 2},
+		{UserID: bob.ID, Username: "bob", CardID: magician.ID, CardName: "Dark Magician", WishlistEntryID: wishlist[1].ID, Quantity: 1},
+	}, matches.TheyHave)
+	// Bob wants a printing of Pot of Greed I do not have.
+	assert.Equal(t, []TradeMatch{
+		{UserID: alice.ID, Username: "alice", CardID: pot.ID, CardName: "Pot of Greed", WishlistEntryID: aliceWish.ID, Quantity: 3},
+	}, matches.TheyWant)
+
+	assert.ErrorIs(t, s.RemoveFromWishlist(me.ID, aliceWish.ID), ErrWishlistEntryNotFound)
+	require.NoError(t, s.RemoveFromWishlist(alice.ID, aliceWish.ID))
+	matches, err = s.GetMatches(me.ID)
+	require.NoError(t, err)
+	assert.Empty(t, matches.TheyWant)
+}
+
+func Test_tradeService_MatchesCountCopiesOnce(t *testing.T) {
+	db := utils.SetupTestDB(
+		&models.User{}, &models.Card{}, &models.CardSet{}, &models.CardPrinting{}, &models.UserCard{}, &models.WishlistEntry{},
+		&models.StorageLocation{}, &models.StoredCard{}, &models.Format{}, &models.Deck{}, &models.DeckCard{},
+	)
+
+	carol := models.User{Username: "carol", Email: "carol@example.com"}
+	dave := models.User{Username: "dave", Email: "dave@example.com"}
+	blueEyes := models.Card{CardYGOID: 89631139, Name: "Blue-Eyes White Dragon"}
+	utils.SeedTestData(db, &carol, &dave, &blueEyes)
+
+	collection := NewCollectionService(repository.NewCollectionRepositoryWithDB(db))
+	s := NewTradeService(repository.NewTradeRepositoryWithDB(db), repository.NewCollectionRepositoryWithDB(db))
+
+	// Carol keeps her damaged copy and two mint ones, and spares the third mint one.
+	require.NoError(t, collection.AddCardToCollection(carol.ID, blueEyes.ID, 0, models.CopyAttributes{Condition: models.ConditionMint}, 3))
+	require.NoError(t, collection.AddCardToCollection(carol.ID, blueEyes.ID, 0, models.CopyAttributes{Condition: models.ConditionDamaged}, 1))
+
+	nearMint, err := s.AddToWishlist(dave.ID, models.WishlistEntry{CardID: blueEyes.ID, Condition: models.ConditionNearMint, Quantity: 1})
+	require.NoError(t, err)
+	_, err = s.AddToWishlist(dave.ID, models.WishlistEntry{CardID: blueEyes.ID, Quantity: 2})
+	require.NoError(t, err)
+
+	matches, err := s.GetMatches(dave.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []TradeMatch{
+		{UserID: carol.ID, Username: "carol", CardID: blueEyes.ID, CardName: "Blue-Eyes White Dragon", WishlistEntryID: nearMint.ID, Quantity: 1},
+	}, matches.TheyHave)
+
+	matches, err = s.GetMatches(carol.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []TradeMatch{
+		{UserID: dave.ID, Username: "dave", CardID: blueEyes.ID, CardName: "Blue-Eyes White Dragon", WishlistEntryID: nearMint.ID, Quantity: 1},
+	}, matches.TheyWant)
+}
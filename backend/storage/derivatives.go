@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // card images are JPEGs, but some mirrors serve PNGs
+	"strings"
+)
+
+// ThumbnailWidth is the width of card thumbnails in pixels. The height keeps the card's aspect ratio.
+const ThumbnailWidth = 168
+
+const jpegQuality = 85
+
+// Position of the artwork inside a card image, as fractions of its width and height. Pendulum cards have
+// a wider artwork box that ends above the pendulum effect.
+var (
+	artBox         = rect{left: 0.119, top: 0.181, right: 0.881, bottom: 0.702}
+	pendulumArtBox = rect{left: 0.069, top: 0.178, right: 0.931, bottom: 0.620}
+)
+
+type rect struct {
+	left, top, right, bottom float64
+}
+
+// CardImageURLs holds the URLs of a card's stored image and its derivatives.
+type CardImageURLs struct {
+	Image     string
+	Thumbnail string
+	Art       string
+}
+
+// ThumbnailKey returns the key a card's thumbnail is stored under.
+func ThumbnailKey(cardID int) string {
+	return fmt.Sprintf("cards/small/%d.jpg", cardID)
+}
+
+// ArtKey returns the key a card's cropped artwork is stored under.
+func ArtKey(cardID int) string {
+	return fmt.Sprintf("cards/art/%d.jpg", cardID)
+}
+
+// StoreCardImages downloads a card's image from imageURL and saves it in store together with its thumbnail
+// and cropped artwork.
+func StoreCardImages(ctx context.Context, store ImageStore, cardID int, frameType, imageURL string) (*CardImageURLs, error) {
+	data, contentType, err := DownloadImage(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode before storing anything so a broken image is not saved.
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image from %s: %w", imageURL, err)
+	}
+
+	urls := &CardImageURLs{}
+	if urls.Image, err = store.Put(ctx, CardImageKey(cardID), data, contentType); err != nil {
+		return nil, err
+	}
+	if urls.Thumbnail, urls.Art, err = storeDerivatives(ctx, store, cardID, frameType, img); err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// StoreCardDerivatives saves the thumbnail and cropped artwork of an already stored card image.
+func StoreCardDerivatives(ctx context.Context, store ImageStore, cardID int, frameType string, data []byte) (thumbnailURL, artURL string, err error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode image of card %d: %w", cardID, err)
+	}
+	return storeDerivatives(ctx, store, cardID, frameType, img)
+}
+
+func storeDerivatives(ctx context.Context, store ImageStore, cardID int, frameType string, img image.Image) (thumbnailURL, artURL string, err error) {
+	thumbnail, err := encodeJPEG(Thumbnail(img, ThumbnailWidth))
+	if err != nil {
+		return "", "", err
+	}
+	if thumbnailURL, err = store.Put(ctx, ThumbnailKey(cardID), thumbnail, "image/jpeg"); err != nil {
+		return "", "", err
+	}
+
+	art, err := encodeJPEG(CropArt(img, frameType))
+	if err != nil {
+		return "", "", err
+	}
+	if artURL, err = store.Put(ctx, ArtKey(cardID), art, "image/jpeg"); err != nil {
+		return "", "", err
+	}
+
+	return thumbnailURL, artURL, nil
+}
+
+// Thumbnail scales img down to the given width, averaging the source pixels covered by each thumbnail
+// pixel. Images that are already narrow enough are returned unchanged.
+func Thumbnail(img image.Image, width int) image.Image {
+	src := toRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= width || sh == 0 {
+		return src
+	}
+
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// CropArt returns the artwork of a full card image.
+func CropArt(img image.Image, frameType string) image.Image {
+	box := artBox
+	if strings.Contains(frameType, "pendulum") {
+		box = pendulumArtBox
+	}
+
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	crop := image.Rect(
+		b.Min.X+int(box.left*w), b.Min.Y+int(box.top*h),
+		b.Min.X+int(box.right*w), b.Min.Y+int(box.bottom*h),
+	)
+
+	dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, crop.Min, draw.Src)
+	return dst
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
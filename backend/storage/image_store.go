@@ -0,0 +1,127 @@
+// Package storage contains the backends card images are stored in.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Supported values for the IMAGE_STORE environment variable.
+const (
+	BackendLocal        = "local"
+	BackendS3           = "s3"
+	BackendS3Compatible = "s3-compatible"
+)
+
+// DefaultImageDir is where the local backend writes images unless configured otherwise.
+const DefaultImageDir = "images"
+
+// ImagesPath is the route the local backend's images are served under.
+const ImagesPath = "/images"
+
+// Images larger than this are rejected rather than read into memory.
+const maxImageSize = 10 << 20
+
+var ErrUnknownBackend = errors.New("unknown image store backend")
+var ErrImageNotFound = errors.New("image not found")
+
+// ImageStore saves images under a key and returns the URL they can be fetched from.
+type ImageStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Get returns the content of a stored image, or ErrImageNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Config selects and configures an ImageStore.
+type Config struct {
+	Backend string
+
+	// Local backend
+	Dir     string
+	BaseURL string
+
+	// S3 backends
+	Bucket    string
+	Region    string
+	Endpoint  string
+	PublicURL string
+}
+
+// ConfigFromEnv reads the image store configuration from the environment.
+// When IMAGE_STORE is not set, S3 is used if a bucket is configured, so existing deployments keep working,
+// and the local backend otherwise.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Backend:   os.Getenv("IMAGE_STORE"),
+		Dir:       os.Getenv("IMAGE_DIR"),
+		BaseURL:   os.Getenv("IMAGE_BASE_URL"),
+		Bucket:    os.Getenv("AWS_BUCKET_NAME"),
+		Region:    os.Getenv("AWS_REGION"),
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		PublicURL: os.Getenv("S3_PUBLIC_URL"),
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = BackendLocal
+		if cfg.Bucket != "" {
+			cfg.Backend = BackendS3
+		}
+	}
+	return cfg
+}
+
+// NewImageStore creates the ImageStore selected by cfg.Backend.
+func NewImageStore(ctx context.Context, cfg Config) (ImageStore, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		return NewLocalImageStore(cfg.Dir, cfg.BaseURL)
+	case BackendS3:
+		return NewS3ImageStore(ctx, cfg.Bucket, cfg.Region)
+	case BackendS3Compatible:
+		return NewS3CompatibleImageStore(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// CardImageKey returns the key a card's full size image is stored under.
+func CardImageKey(cardID int) string {
+	return fmt.Sprintf("cards/%d.jpg", cardID)
+}
+
+// DownloadImage fetches an image and returns its content and content type.
+func DownloadImage(ctx context.Context, imageURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build request for %s: %w", imageURL, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch image from %s: %w", imageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("image download failed with status code %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("unexpected content type: %s", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, "", fmt.Errorf("image from %s is larger than %d bytes", imageURL, maxImageSize)
+	}
+
+	return data, contentType, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalImageStore_Put(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalImageStore(dir, "http://localhost:8080/images/")
+	require.NoError(t, err)
+
+	url, err := store.Put(context.Background(), "cards/1.jpg", []byte("jpeg"), "image/jpeg")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/images/cards/1.jpg", url)
+
+	data, err := os.ReadFile(filepath.Join(dir, "cards", "1.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(data))
+
+	_, err = store.Put(context.Background(), "../outside.jpg", []byte("jpeg"), "image/jpeg")
+	assert.Error(t, err)
+}
+
+func TestStoreCardImages(t *testing.T) {
+	card := testCardJPEG(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+		case "/broken.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("jpeg"))
+		default:
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write(card)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	store, err := NewLocalImageStore(dir, "")
+	require.NoError(t, err)
+
+	urls, err := StoreCardImages(context.Background(), store, 46986414, "normal", server.URL+"/46986414.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, &CardImageURLs{
+		Image:     "/images/cards/46986414.jpg",
+		Thumbnail: "/images/cards/small/46986414.jpg",
+		Art:       "/images/cards/art/46986414.jpg",
+	}, urls)
+
+	thumbnail := readJPEG(t, filepath.Join(dir, "cards", "small", "46986414.jpg"))
+	assert.Equal(t, image.Rect(0, 0, ThumbnailWidth, 245), thumbnail.Bounds())
+
+	_, err = StoreCardImages(context.Background(), store, 1, "normal", server.URL+"/page.html")
+	assert.ErrorContains(t, err, "unexpected content type")
+
+	_, err = StoreCardImages(context.Background(), store, 2, "normal", server.URL+"/broken.jpg")
+	assert.ErrorContains(t, err, "failed to decode image")
+	_, err = store.Get(context.Background(), CardImageKey(2))
+	assert.ErrorIs(t, err, ErrImageNotFound)
+}
+
+func TestCropArt(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 421, 614))
+
+	assert.Equal(t, image.Rect(0, 0, 320, 320), CropArt(img, "effect").Bounds())
+	assert.Equal(t, image.Rect(0, 0, 362, 271), CropArt(img, "effect_pendulum").Bounds())
+}
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+		img.Set(x, 1, color.RGBA{B: 100, A: 255})
+	}
+
+	thumbnail := Thumbnail(img, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), thumbnail.Bounds())
+	assert.Equal(t, color.RGBA{R: 100, B: 50, A: 255}, thumbnail.At(0, 0))
+
+	assert.Equal(t, img.Bounds(), Thumbnail(img, 10).Bounds())
+}
+
+// testCardJPEG returns a JPEG with the size of the YGOProDeck card images.
+func testCardJPEG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 421, 614)), nil))
+	return buf.Bytes()
+}
+
+func readJPEG(t *testing.T, path string) image.Image {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	img, err := jpeg.Decode(f)
+	require.NoError(t, err)
+	return img
+}
+
+func TestNewImageStore(t *testing.T) {
+	_, err := NewImageStore(context.Background(), Config{Backend: "ftp"})
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+
+	_, err = NewImageStore(context.Background(), Config{Backend: BackendS3})
+	assert.ErrorIs(t, err, ErrMissingBucket)
+
+	store, err := NewImageStore(context.Background(), Config{
+		Backend:  BackendS3Compatible,
+		Bucket:   "cards",
+		Endpoint: "http://localhost:9000/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/cards", store.(*S3ImageStore).publicURL)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("IMAGE_STORE", "")
+	t.Setenv("AWS_BUCKET_NAME", "")
+	assert.Equal(t, BackendLocal, ConfigFromEnv().Backend)
+
+	t.Setenv("AWS_BUCKET_NAME", "cards")
+	assert.Equal(t, BackendS3, ConfigFromEnv().Backend)
+
+	t.Setenv("IMAGE_STORE", BackendS3Compatible)
+	assert.Equal(t, BackendS3Compatible, ConfigFromEnv().Backend)
+}
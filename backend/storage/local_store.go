@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalImageStore writes images to a directory on disk. The router serves that directory under ImagesPath.
+type LocalImageStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalImageStore creates the directory if needed. baseURL is prefixed to the keys of stored images and
+// defaults to ImagesPath, which makes the returned URLs relative to the backend.
+func NewLocalImageStore(dir, baseURL string) (*LocalImageStore, error) {
+	if dir == "" {
+		dir = DefaultImageDir
+	}
+	if baseURL == "" {
+		baseURL = ImagesPath
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create image directory %s: %w", dir, err)
+	}
+
+	return &LocalImageStore{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+// Dir returns the directory images are written to.
+func (s *LocalImageStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalImageStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %w", err)
+	}
+
+	// Write to a temporary file first so the image is never served half written.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create image file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write image %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write image %s: %w", key, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write image %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to write image %s: %w", key, err)
+	}
+
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *LocalImageStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// path maps a key to a file inside the store's directory.
+func (s *LocalImageStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid image key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
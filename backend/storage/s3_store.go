@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var ErrMissingBucket = errors.New("AWS_BUCKET_NAME environment variable is not set")
+
+// S3ImageStore uploads images to an S3 bucket, on AWS or on an S3-compatible service such as MinIO.
+type S3ImageStore struct {
+	client    *s3.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3ImageStore uploads to an AWS bucket using the default AWS credential chain.
+// Images are served from the bucket's virtual-hosted URL.
+func NewS3ImageStore(ctx context.Context, bucket, region string) (*S3ImageStore, error) {
+	if bucket == "" {
+		return nil, ErrMissingBucket
+	}
+
+	cfg, err := loadAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3ImageStore{
+		client:    s3.NewFromConfig(cfg),
+		bucket:    bucket,
+		publicURL: fmt.Sprintf("https://%s.s3.amazonaws.com", bucket),
+	}, nil
+}
+
+// NewS3CompatibleImageStore uploads to cfg.Endpoint with path-style addressing, which is what MinIO and most
+// other S3-compatible services expect. Images are served from cfg.PublicURL, or from
+// <endpoint>/<bucket> when it is not set.
+func NewS3CompatibleImageStore(ctx context.Context, cfg Config) (*S3ImageStore, error) {
+	if cfg.Bucket == "" {
+		return nil, ErrMissingBucket
+	}
+	if cfg.Endpoint == "" {
+		return nil, errors.New("S3_ENDPOINT environment variable is not set")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		// Most S3-compatible services ignore the region, but requests still have to be signed with one.
+		region = "us-east-1"
+	}
+
+	awsCfg, err := loadAWSConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	publicURL := strings.TrimRight(cfg.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + cfg.Bucket
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+		o.UsePathStyle = true
+	})
+
+	return &S3ImageStore{client: client, bucket: cfg.Bucket, publicURL: publicURL}, nil
+}
+
+func loadAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (s *S3ImageStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	size := int64(len(data))
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(data),
+		ContentLength: &size,
+		ContentType:   aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image to S3: %w", err)
+	}
+
+	return s.publicURL + "/" + key, nil
+}
+
+func (s *S3ImageStore) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("%w: %s", ErrImageNotFound, key)
+		}
+		return nil, fmt.Errorf("failed to download image from S3: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image from S3: %w", err)
+	}
+	return data, nil
+}
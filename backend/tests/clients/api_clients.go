@@ -2,6 +2,7 @@ package api_clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -21,7 +22,7 @@ import (
 
 // SetupTestRouter sets up the Gin router for testing purposes
 func SetupTestRouter() *gin.Engine {
-	router := routes.SetupRouter()
+	router := routes.SetupRouter(context.Background())
 	return router
 }
 
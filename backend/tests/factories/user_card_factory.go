@@ -13,13 +13,21 @@ func UserCardFactory(userID, cardID uint, quantity int) models.UserCard {
 
 	db := testutils.TestDB
 
+	// Copies without a known printing are filed under the unspecified printing of their card.
+	printing := models.CardPrinting{CardID: cardID}
+	err := db.Where("card_id = ? AND set_code = '' AND rarity = ''", cardID).FirstOrCreate(&printing).Error
+	if err != nil {
+		log.Panicf("failed to create unspecified printing: %v", err)
+	}
+
 	userCard := models.UserCard{
-		UserID:   userID,
-		CardID:   cardID,
-		Quantity: quantity,
+		UserID:     userID,
+		PrintingID: printing.ID,
+		CardID:     cardID,
+		Quantity:   quantity,
 	}
 
-	err := db.Create(&userCard).Error
+	err = db.Create(&userCard).Error
 	if err != nil {
 		log.Panicf("failed to create user card: %v", err)
 	}
@@ -96,9 +96,18 @@ func SetupTestDatabase() {
 		models.MonsterCard{},
 		models.LinkMonsterCard{},
 		models.PendulumMonsterCard{},
+		models.CardSet{},
+		models.CardPrinting{},
 		models.UserCard{},
+		models.StorageLocation{},
+		models.StoredCard{},
+		models.WishlistEntry{},
+		models.Format{},
 		models.Deck{},
 		models.DeckCard{},
+		models.Banlist{},
+		models.BanlistEntry{},
+		models.ImageJob{},
 	); err != nil {
 		log.Fatalf("Failed to auto migrate database schema: %v", err)
 	}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// BanlistFileEntry is a single row of an imported banlist file.
+type BanlistFileEntry struct {
+	CardYGOID int    `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+}
+
+// ParseBanlistJSON reads a banlist from a JSON array of {"id", "name", "status"} objects.
+// An object with a "cards" array is also accepted.
+func ParseBanlistJSON(r io.Reader) ([]BanlistFileEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []BanlistFileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var wrapped struct {
+			Cards []BanlistFileEntry `json:"cards"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid banlist JSON: %w", err)
+		}
+		entries = wrapped.Cards
+	}
+
+	return entries, nil
+}
+
+// ParseBanlistCSV reads a banlist from CSV rows in the form "id,name,status".
+// A header row is skipped when its first column is not a number.
+func ParseBanlistCSV(r io.Reader) ([]BanlistFileEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []BanlistFileEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid banlist CSV: %w", err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected id,name,status", line)
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid card ID %q", line, record[0])
+		}
+
+		entries = append(entries, BanlistFileEntry{
+			CardYGOID: id,
+			Name:      strings.TrimSpace(record[1]),
+			Status:    strings.TrimSpace(record[2]),
+		})
+	}
+
+	return entries, nil
+}
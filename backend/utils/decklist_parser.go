@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DecklistLine is a card line of a plain-text decklist. Section is "main", "extra" or "side" when the
+// line follows a heading, and empty when the list has no headings. When the line starts with a number
+// that is not marked with an "x", such as "7 Colored Fish", the number may be part of the card name,
+// and WholeName holds the line read as a single copy of that name.
+type DecklistLine struct {
+	Number    int
+	Text      string
+	Name      string
+	Quantity  int
+	WholeName string
+	Section   string
+}
+
+var (
+	decklistHeading     = regexp.MustCompile(`(?i)^[#!]?\s*(main|extra|side)(\s*deck)?\s*(\(\d+\))?\s*:?$`)
+	decklistMainHeading = regexp.MustCompile(`(?i)^(monsters?|spells?|traps?)(\s*cards?)?\s*(\(\d+\))?\s*:?$`)
+	quantityPrefix      = regexp.MustCompile(`(?i)^([x×])?(\d+)\s*([x×])?\s+(.+)$`)
+	quantitySuffix      = regexp.MustCompile(`(?i)^(.+?)\s+[x×]\s*(\d+)$`)
+)
+
+// ParseDecklist reads a human-readable decklist such as "3x Ash Blossom & Joyous Spring".
+// Quantities may be written as "3x Name", "3 Name", "x3 Name" or "Name x3", and default to 1; a bare
+// leading number may also be part of the name, see DecklistLine.
+// "Main", "Extra" and "Side" headings (optionally followed by "Deck", a count or a colon) switch
+// sections, "Monsters", "Spells" and "Traps" headings count as the main deck, and lines starting
+// with "//" or "#" are ignored.
+func ParseDecklist(file io.Reader) ([]DecklistLine, error) {
+	var (
+		lines   []DecklistLine
+		section string
+		number  int
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		text = strings.TrimSpace(strings.TrimLeft(text, "-*•"))
+		if text == "" {
+			continue
+		}
+
+		if m := decklistHeading.FindStringSubmatch(text); m != nil {
+			section = strings.ToLower(m[1])
+			continue
+		}
+		if decklistMainHeading.MatchString(text) {
+			section = "main"
+			continue
+		}
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "!") {
+			continue
+		}
+
+		name, quantity, wholeName := text, 1, ""
+		if m := quantityPrefix.FindStringSubmatch(text); m != nil {
+			quantity, _ = strconv.Atoi(m[2])
+			name = m[4]
+			if m[1] == "" && m[3] == "" {
+				wholeName = text
+			}
+		} else if m := quantitySuffix.FindStringSubmatch(text); m != nil {
+			quantity, _ = strconv.Atoi(m[2])
+			name = m[1]
+		}
+
+		lines = append(lines, DecklistLine{
+			Number:    number,
+			Text:      text,
+			Name:      strings.TrimSpace(name),
+			Quantity:  quantity,
+			WholeName: wholeName,
+			Section:   section,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
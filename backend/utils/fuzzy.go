@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeCardName lowercases a card name and keeps only letters and digits separated by single
+// spaces, so that punctuation and "&"/"and" differences do not affect comparisons.
+func NormalizeCardName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, "&", " and "))
+
+	var sb strings.Builder
+	space := false
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && sb.Len() > 0 {
+				sb.WriteRune(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+			continue
+		}
+		space = true
+	}
+	return sb.String()
+}
+
+// EditDistance returns the Levenshtein distance between two strings, counted in runes.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -1,71 +0,0 @@
-package utils
-
-import (
-	"bytes"
-	"context"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"strings"
-
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-)
-
-var UploadImage = UploadCardImageToS3
-
-func UploadCardImageToS3(cardID int, imageURL string) (string, error) {
-	ctx := context.Background()
-
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to load AWS config: %w", err)
-	}
-
-	client := s3.NewFromConfig(cfg)
-
-	resp, err := http.Get(imageURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch image from %s: %w", imageURL, err)
-	}
-
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("image download failed with status code %d", resp.StatusCode)
-	}
-
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(contentType, "image/") {
-		return "", fmt.Errorf("unexpected content type: %s", contentType)
-	}
-
-	key := fmt.Sprintf("cards/%d.jpg", cardID)
-	bucket := os.Getenv("AWS_BUCKET_NAME")
-	if bucket == "" {
-		return "", fmt.Errorf("AWS_BUCKET_NAME environment variable is not set")
-	}
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	reader := bytes.NewReader(bodyBytes)
-	size := int64(reader.Len())
-
-	_, err = client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(bucket),
-		Key:           aws.String(key),
-		Body:          reader,
-		ContentLength: &size,
-		ContentType:   aws.String(contentType),
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to upload image to S3: %w", err)
-	}
-
-	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
-	return url, nil
-}
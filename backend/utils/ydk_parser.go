@@ -3,16 +3,24 @@ package utils
 import (
 	"bufio"
 	"io"
-	"mime/multipart"
 	"strings"
 )
 
-func ParseYDK(file multipart.File) ([]string, []string, []string, error) {
+// YDKDeck holds the passcodes listed in each section of a .ydk file, one entry per copy,
+// and the author given in its "#created by" header, if any.
+type YDKDeck struct {
+	Main      []string
+	Extra     []string
+	Side      []string
+	CreatedBy string
+}
+
+// ParseYDK reads a .ydk file. Both "#side" and the standard "!side" mark the side deck section,
+// and comment lines starting with "#" are ignored.
+func ParseYDK(file io.Reader) (*YDKDeck, error) {
 	var (
-		mainIDs  []string
-		extraIDs []string
-		sideIDs  []string
-		current  *[]string
+		deck    YDKDeck
+		current *[]string
 	)
 
 	reader := bufio.NewReader(file)
@@ -20,10 +28,13 @@ func ParseYDK(file multipart.File) ([]string, []string, []string, error) {
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil && err != io.EOF {
-			return nil, nil, nil, err
+			return nil, err
 		}
 
 		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#created by") {
+			deck.CreatedBy = strings.TrimSpace(strings.TrimPrefix(line, "#created by"))
+		}
 		if line == "" || strings.HasPrefix(line, "#created") {
 			if err == io.EOF {
 				break
@@ -33,13 +44,13 @@ func ParseYDK(file multipart.File) ([]string, []string, []string, error) {
 
 		switch line {
 		case "#main":
-			current = &mainIDs
+			current = &deck.Main
 		case "#extra":
-			current = &extraIDs
-		case "#side":
-			current = &sideIDs
+			current = &deck.Extra
+		case "!side", "#side":
+			current = &deck.Side
 		default:
-			if current == nil {
+			if current == nil || strings.HasPrefix(line, "#") {
 				// Alternativa: log.Printf("Ignoring line outside of section: %s", line)
 				continue
 			}
@@ -51,5 +62,5 @@ func ParseYDK(file multipart.File) ([]string, []string, []string, error) {
 		}
 	}
 
-	return mainIDs, extraIDs, sideIDs, nil
+	return &deck, nil
 }
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// YDKEPrefix is the scheme used by EDOPro, Dueling Book and YGOProDeck to share decks as text.
+const YDKEPrefix = "ydke://"
+
+// ErrInvalidYDKE is returned when a string is not a well-formed ydke:// URL.
+var ErrInvalidYDKE = errors.New("invalid ydke URL")
+
+// ParseYDKE decodes a ydke:// URL. Each of the main, extra and side sections is a base64 string
+// of little-endian 32-bit passcodes, and the sections are separated by "!".
+func ParseYDKE(url string) (*YDKDeck, error) {
+	url = strings.TrimSpace(url)
+	if !strings.HasPrefix(url, YDKEPrefix) {
+		return nil, ErrInvalidYDKE
+	}
+
+	parts := strings.Split(strings.TrimPrefix(url, YDKEPrefix), "!")
+	if len(parts) < 3 {
+		return nil, ErrInvalidYDKE
+	}
+
+	var deck YDKDeck
+	sections := []*[]string{&deck.Main, &deck.Extra, &deck.Side}
+	for i, section := range sections {
+		passcodes, err := decodeYDKESection(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		*section = passcodes
+	}
+
+	return &deck, nil
+}
+
+// FormatYDKE encodes the sections of a deck as a ydke:// URL.
+func FormatYDKE(deck *YDKDeck) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(YDKEPrefix)
+
+	for _, section := range [][]string{deck.Main, deck.Extra, deck.Side} {
+		buf := make([]byte, 4*len(section))
+		for i, passcode := range section {
+			id, err := strconv.ParseUint(passcode, 10, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid passcode %q: %w", passcode, err)
+			}
+			binary.LittleEndian.PutUint32(buf[4*i:], uint32(id))
+		}
+		sb.WriteString(base64.StdEncoding.EncodeToString(buf))
+		sb.WriteString("!")
+	}
+
+	return sb.String(), nil
+}
+
+func decodeYDKESection(section string) ([]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(section)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidYDKE, err)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("%w: section length is not a multiple of 4", ErrInvalidYDKE)
+	}
+
+	passcodes := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		passcodes = append(passcodes, strconv.FormatUint(uint64(binary.LittleEndian.Uint32(raw[i:])), 10))
+	}
+	return passcodes, nil
+}